@@ -7,6 +7,7 @@ import (
 	"encoding/base64"
 	"io"
 	"log"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
@@ -18,10 +19,13 @@ import (
 
 	"disposable-email-domains/internal/config"
 	"disposable-email-domains/internal/domain"
+	"disposable-email-domains/internal/history"
 	"disposable-email-domains/internal/pslrefresher"
 	"disposable-email-domains/internal/router"
 	"disposable-email-domains/internal/storage"
+	"disposable-email-domains/internal/subscriptions"
 	slogadapter "disposable-email-domains/internal/util/logadapter"
+	"disposable-email-domains/internal/watcher"
 )
 
 var version string
@@ -109,10 +113,72 @@ func main() {
 			slog.Bool("trust_proxy_headers", cfg.TrustProxyHeaders),
 			slog.Any("admin_tokens", redacted),
 			slog.Any("rate_limit_bypass_domains", cfg.RateLimitBypassDomains),
+			slog.Bool("mx_check", cfg.MXCheckEnabled),
+			slog.String("list_index", cfg.ListIndex),
 		)
 	}
+	checker := domain.NewChecker("allowlist.conf", "blocklist.conf")
+	if err := checker.SetIndexMode(cfg.ListIndex); err != nil {
+		logger.Printf("lists: %v", err)
+	}
+	checker.SetRoleListPath(cfg.RoleListPath)
+	if err := checker.Load(); err != nil {
+		logger.Printf("failed to load lists: %v", err)
+	}
+	// Use the on-disk PSL snapshot for checks when present; the compiled-in
+	// table remains the fallback until a valid snapshot is available.
+	if err := checker.LoadPSL("public_suffix_list.dat"); err != nil {
+		logger.Printf("psl: using builtin table: %v", err)
+	}
+
+	if cfg.MXCheckEnabled {
+		var resolver domain.Resolver = net.DefaultResolver
+		if cfg.DNSServer != "" {
+			addr := cfg.DNSServer
+			resolver = &net.Resolver{PreferGo: true, Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+				var d net.Dialer
+				return d.DialContext(ctx, network, addr)
+			}}
+		}
+		infra, err := domain.LoadInfraList(cfg.MXInfraPath)
+		mx := domain.NewMXDetector(resolver, infra)
+		mx.Path = cfg.MXInfraPath
+		mx.Timeout = cfg.MXLookupTimeout
+		mx.CacheTTL = cfg.MXCacheTTL
+		mx.Logger = logger
+		checker.SetMXDetector(mx)
+		if err != nil {
+			logger.Printf("mx: %v; detection disabled until the list is fixed", err)
+		} else {
+			logger.Printf("mx: detection enabled with %d infrastructure entries", mx.InfraCount())
+		}
+	}
+
+	if sc, err := domain.NewScoreConfig(cfg.ScoreWeights, cfg.ScoreMediumThreshold, cfg.ScoreHighThreshold); err != nil {
+		logger.Printf("score: using default weights: %v", err)
+	} else {
+		checker.SetScoreConfig(sc)
+	}
+	if cfg.SuspectThreshold > 0 {
+		checker.SetSuspectThreshold(cfg.SuspectThreshold)
+	}
+	if !cfg.TypoSuggestions {
+		checker.SetTypoProviders(nil)
+	} else if cfg.TypoProviders != nil {
+		checker.SetTypoProviders(cfg.TypoProviders)
+	}
+
 	refresher := pslrefresher.New(logger, "public_suffix_list.dat")
 	refresher.Interval = cfg.PSLRefreshInterval
+	refresher.OnUpdate = func(data []byte) {
+		p, err := domain.ParsePSL(data)
+		if err != nil {
+			logger.Printf("psl: parse refreshed list: %v", err)
+			return
+		}
+		checker.SetPSL(p)
+		logger.Printf("psl: swapped runtime list version=%s rules=%d", p.Info().Version, p.Info().Rules)
+	}
 	// Perform an initial validated refresh (writes only if valid). If it fails,
 	// the background loop will retry with backoff; readiness will reflect PSL presence.
 	_ = refresher.RefreshNow()
@@ -122,15 +188,54 @@ func main() {
 
 	store := storage.NewMemoryStore()
 
-	checker := domain.NewChecker("allowlist.conf", "blocklist.conf")
-	if err := checker.Load(); err != nil {
-		logger.Printf("failed to load lists: %v", err)
-	}
-
 	if len(cfg.AdminTokens) == 0 {
 		rootLogger.Warn("no valid admin tokens configured - mutating endpoints disabled")
 	}
-	mux := router.New(store, logger, checker, cfg, refresher, version)
+	// Reload the lists when deploys or editors change the files on disk.
+	var watch *watcher.Watcher
+	if cfg.ListWatch != "" {
+		paths := []string{"allowlist.conf", "blocklist.conf"}
+		if cfg.RoleListPath != "" {
+			paths = append(paths, cfg.RoleListPath)
+		}
+		watch = watcher.New(logger, checker.Reload, paths...)
+		watch.Debounce = cfg.ListWatchDebounce
+		watch.Interval = cfg.ListWatchPoll
+		watch.Poll = cfg.ListWatch == "poll"
+		watch.Strict = cfg.ListWatchStrict
+	}
+	// Journal list mutations with periodic snapshots for diffs and rollbacks.
+	var hist *history.Store
+	if cfg.HistoryDir != "" {
+		hist = history.New(logger, cfg.HistoryDir, map[string]string{"allowlist": "allowlist.conf", "blocklist": "blocklist.conf"}, checker.ListFiles()...)
+		hist.Keep = cfg.HistoryKeep
+		hist.SnapshotEvery = cfg.HistorySnapshotEvery
+		hist.Reload = checker.Reload
+		hist.Generation = checker.Generation
+		if err := hist.Open(); err != nil {
+			logger.Printf("history: disabled: %v", err)
+			hist = nil
+		} else if _, _, err := hist.Record(history.Change{Op: history.OpStartup}); err != nil {
+			logger.Printf("history: record startup: %v", err)
+		}
+	}
+	// Follow upstream blocklists; router.New wires the sync into the API.
+	var subs *subscriptions.Subscriber
+	if len(cfg.Subscriptions) > 0 {
+		subs = subscriptions.New(logger, cfg.SubscriptionDir, subscriptions.Sources(cfg.Subscriptions, cfg.SubscriptionsPrune)...)
+		subs.Interval = cfg.SubscriptionInterval
+		if err := subs.Open(); err != nil {
+			logger.Printf("subscriptions: disabled: %v", err)
+			subs = nil
+		}
+	}
+	mux := router.New(store, logger, checker, cfg, refresher, watch, hist, subs, version)
+	if watch != nil {
+		watch.Start()
+	}
+	if subs != nil {
+		subs.Start()
+	}
 
 	srv := &http.Server{
 		Addr:              ":4343",
@@ -199,6 +304,12 @@ func main() {
 
 	// stop refresher first so no new file operations start during shutdown
 	refresher.Stop()
+	if subs != nil {
+		subs.Stop()
+	}
+	if watch != nil {
+		watch.Stop()
+	}
 	close(internalStop)
 
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
@@ -2,6 +2,7 @@ package config
 
 import (
 	"log"
+	"net"
 	"os"
 	"strconv"
 	"strings"
@@ -24,6 +25,41 @@ type Config struct {
 	BatchStreamMaxItems int // cap for streaming (NDJSON) endpoints
 
 	EnableCheckRedirects bool // redirect GET /check* to alias paths
+
+	ListIndex    string // exact-entry index for the shared lists: map, compact or mmap
+	RoleListPath string // role account local parts; empty disables detection
+
+	MXCheckEnabled  bool          // resolve MX records of undecided domains
+	MXInfraPath     string        // disposable mail infrastructure list
+	MXLookupTimeout time.Duration // per-check DNS budget
+	MXCacheTTL      time.Duration // how long MX verdicts are reused
+	DNSServer       string        // optional host:port used instead of the system resolver
+
+	ScoreWeights         map[string]float64 // signal -> weight overrides for the risk score
+	ScoreMediumThreshold int                // score at which risk_level becomes "medium" (0 = default)
+	ScoreHighThreshold   int                // score at which risk_level becomes "high" (0 = default)
+	SuspectThreshold     float64            // lexical classifier probability for "suspect" (0 = default)
+
+	TenantAPIKeys map[string]string // X-API-Key value -> tenant id
+	TenantHeader  string            // header selecting a tenant directly; empty disables
+
+	ListWatch         string        // "auto" (inotify, polling fallback), "poll" or "" (disabled)
+	ListWatchDebounce time.Duration // quiet period after a file change before reloading
+	ListWatchPoll     time.Duration // stat interval when polling
+	ListWatchStrict   bool          // validate changed lists before publishing them
+
+	HistoryDir           string // mutation journal and list snapshots; empty disables
+	HistoryKeep          int    // versions retained restorable (0 = all)
+	HistorySnapshotEvery int    // versions per full snapshot; deltas in between
+	AsOfCacheSize        int    // historical list versions kept loaded for as_of
+
+	Subscriptions        map[string]string // source name -> https URL of an upstream blocklist
+	SubscriptionsPrune   []string          // sources whose dropped entries are removed; "*" for all
+	SubscriptionInterval time.Duration     // refresh cadence per source
+	SubscriptionDir      string            // per-source state and last applied list
+
+	TypoSuggestions bool     // suggest provider domains for likely typos
+	TypoProviders   []string // suggestion targets besides the allowlist; nil uses the built-in list
 }
 
 func Load(logger *log.Logger) Config {
@@ -36,6 +72,22 @@ func Load(logger *log.Logger) Config {
 		BatchMaxItems:        200_000,
 		BatchStreamMaxItems:  1_000_000,
 		EnableCheckRedirects: true,
+		MXInfraPath:          "mx_infrastructure.conf",
+		MXLookupTimeout:      2 * time.Second,
+		MXCacheTTL:           10 * time.Minute,
+		ListIndex:            "map",
+		RoleListPath:         "rolelist.conf",
+		TenantHeader:         "X-Tenant",
+		TypoSuggestions:      true,
+		ListWatch:            "auto",
+		ListWatchDebounce:    2 * time.Second,
+		ListWatchPoll:        5 * time.Second,
+		HistoryDir:           "history",
+		HistoryKeep:          50,
+		HistorySnapshotEvery: 10,
+		AsOfCacheSize:        16,
+		SubscriptionInterval: 6 * time.Hour,
+		SubscriptionDir:      "subscriptions",
 	}
 	if v := os.Getenv("RATE_LIMIT_RPS"); v != "" {
 		if f, err := strconv.ParseFloat(v, 64); err == nil && f > 0 {
@@ -127,5 +179,225 @@ func Load(logger *log.Logger) Config {
 		vl := strings.ToLower(v)
 		c.EnableCheckRedirects = vl == "1" || vl == "true" || vl == "yes" || vl == "on"
 	}
+	if v, ok := os.LookupEnv("ROLE_LIST_FILE"); ok {
+		if vl := strings.ToLower(strings.TrimSpace(v)); vl == "" || vl == "off" {
+			c.RoleListPath = ""
+		} else {
+			c.RoleListPath = strings.TrimSpace(v)
+		}
+	}
+	if v := os.Getenv("MX_CHECK"); v != "" {
+		vl := strings.ToLower(v)
+		c.MXCheckEnabled = vl == "1" || vl == "true" || vl == "yes" || vl == "on"
+	}
+	if v := os.Getenv("MX_INFRA_FILE"); v != "" {
+		c.MXInfraPath = v
+	}
+	if v := os.Getenv("MX_LOOKUP_TIMEOUT"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil && d > 0 {
+			c.MXLookupTimeout = d
+		} else if err != nil {
+			logger.Printf("config: invalid MX_LOOKUP_TIMEOUT=%q: %v", v, err)
+		}
+	}
+	if v := os.Getenv("MX_CACHE_TTL"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil && d >= 0 {
+			c.MXCacheTTL = d
+		} else if err != nil {
+			logger.Printf("config: invalid MX_CACHE_TTL=%q: %v", v, err)
+		}
+	}
+	if v := os.Getenv("DNS_SERVER"); v != "" {
+		if _, _, err := net.SplitHostPort(v); err == nil {
+			c.DNSServer = v
+		} else {
+			logger.Printf("config: invalid DNS_SERVER=%q: %v", v, err)
+		}
+	}
+	if v := os.Getenv("SCORE_WEIGHTS"); v != "" { // comma-separated signal=weight
+		for _, part := range strings.Split(v, ",") {
+			name, val, ok := strings.Cut(strings.TrimSpace(part), "=")
+			if !ok {
+				logger.Printf("config: invalid SCORE_WEIGHTS entry %q", part)
+				continue
+			}
+			f, err := strconv.ParseFloat(strings.TrimSpace(val), 64)
+			if err != nil {
+				logger.Printf("config: invalid SCORE_WEIGHTS entry %q: %v", part, err)
+				continue
+			}
+			if c.ScoreWeights == nil {
+				c.ScoreWeights = make(map[string]float64)
+			}
+			c.ScoreWeights[strings.TrimSpace(name)] = f
+		}
+	}
+	if v := os.Getenv("SCORE_MEDIUM_THRESHOLD"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 && n <= 100 {
+			c.ScoreMediumThreshold = n
+		} else {
+			logger.Printf("config: invalid SCORE_MEDIUM_THRESHOLD=%q", v)
+		}
+	}
+	if v := os.Getenv("SCORE_HIGH_THRESHOLD"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 && n <= 100 {
+			c.ScoreHighThreshold = n
+		} else {
+			logger.Printf("config: invalid SCORE_HIGH_THRESHOLD=%q", v)
+		}
+	}
+	if v := os.Getenv("SUSPECT_THRESHOLD"); v != "" {
+		if f, err := strconv.ParseFloat(v, 64); err == nil && f > 0 && f <= 1 {
+			c.SuspectThreshold = f
+		} else {
+			logger.Printf("config: invalid SUSPECT_THRESHOLD=%q", v)
+		}
+	}
+	if v := strings.TrimSpace(os.Getenv("LIST_INDEX")); v != "" {
+		switch vl := strings.ToLower(v); vl {
+		case "map", "compact", "mmap":
+			c.ListIndex = vl
+		default:
+			logger.Printf("config: invalid LIST_INDEX=%q (want map, compact or mmap)", v)
+		}
+	}
+	if v := os.Getenv("TENANT_API_KEYS"); v != "" { // comma-separated key=tenant
+		for _, part := range strings.Split(v, ",") {
+			key, tenant, ok := strings.Cut(strings.TrimSpace(part), "=")
+			key, tenant = strings.TrimSpace(key), strings.TrimSpace(tenant)
+			if !ok || key == "" || tenant == "" {
+				logger.Printf("config: invalid TENANT_API_KEYS entry (want key=tenant)")
+				continue
+			}
+			if len(key) < 16 {
+				logger.Printf("config: ignoring short tenant API key (<16 chars) for tenant %q", tenant)
+				continue
+			}
+			if c.TenantAPIKeys == nil {
+				c.TenantAPIKeys = make(map[string]string)
+			}
+			c.TenantAPIKeys[key] = tenant
+		}
+	}
+	if v, ok := os.LookupEnv("TENANT_HEADER"); ok {
+		v = strings.TrimSpace(v)
+		if vl := strings.ToLower(v); vl == "" || vl == "off" || vl == "false" || vl == "0" {
+			c.TenantHeader = ""
+		} else {
+			c.TenantHeader = v
+		}
+	}
+	if v := strings.ToLower(strings.TrimSpace(os.Getenv("LIST_WATCH"))); v != "" {
+		switch v {
+		case "auto", "on", "true", "1":
+			c.ListWatch = "auto"
+		case "poll":
+			c.ListWatch = "poll"
+		case "off", "false", "0":
+			c.ListWatch = ""
+		default:
+			logger.Printf("config: invalid LIST_WATCH=%q (want auto, poll or off)", v)
+		}
+	}
+	if v := os.Getenv("LIST_WATCH_DEBOUNCE"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil && d >= 0 {
+			c.ListWatchDebounce = d
+		} else {
+			logger.Printf("config: invalid LIST_WATCH_DEBOUNCE=%q", v)
+		}
+	}
+	if v := os.Getenv("LIST_WATCH_POLL_INTERVAL"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil && d > 0 {
+			c.ListWatchPoll = d
+		} else {
+			logger.Printf("config: invalid LIST_WATCH_POLL_INTERVAL=%q", v)
+		}
+	}
+	if v := os.Getenv("LIST_WATCH_STRICT"); v != "" {
+		vl := strings.ToLower(v)
+		c.ListWatchStrict = vl == "1" || vl == "true" || vl == "yes" || vl == "on"
+	}
+	if v, ok := os.LookupEnv("HISTORY_DIR"); ok {
+		if vl := strings.ToLower(strings.TrimSpace(v)); vl == "" || vl == "off" || vl == "false" || vl == "0" {
+			c.HistoryDir = ""
+		} else {
+			c.HistoryDir = strings.TrimSpace(v)
+		}
+	}
+	if v := os.Getenv("HISTORY_KEEP"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n >= 0 {
+			c.HistoryKeep = n
+		} else {
+			logger.Printf("config: invalid HISTORY_KEEP=%q", v)
+		}
+	}
+	if v := os.Getenv("HISTORY_SNAPSHOT_EVERY"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n >= 1 {
+			c.HistorySnapshotEvery = n
+		} else {
+			logger.Printf("config: invalid HISTORY_SNAPSHOT_EVERY=%q", v)
+		}
+	}
+	if v := os.Getenv("AS_OF_CACHE_SIZE"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n >= 1 {
+			c.AsOfCacheSize = n
+		} else {
+			logger.Printf("config: invalid AS_OF_CACHE_SIZE=%q", v)
+		}
+	}
+	if v := os.Getenv("SUBSCRIPTIONS"); v != "" { // comma-separated name=https://url
+		for _, part := range strings.Split(v, ",") {
+			name, u, ok := strings.Cut(strings.TrimSpace(part), "=")
+			name, u = strings.ToLower(strings.TrimSpace(name)), strings.TrimSpace(u)
+			if !ok || !validSubscriptionName(name) || !strings.HasPrefix(u, "https://") {
+				logger.Printf("config: invalid SUBSCRIPTIONS entry %q (want name=https://url, name of a-z 0-9 . _ -)", part)
+				continue
+			}
+			if c.Subscriptions == nil {
+				c.Subscriptions = make(map[string]string)
+			}
+			c.Subscriptions[name] = u
+		}
+	}
+	if v := os.Getenv("SUBSCRIPTIONS_PRUNE"); v != "" { // comma-separated names or "*"
+		for _, part := range strings.Split(v, ",") {
+			if part = strings.ToLower(strings.TrimSpace(part)); part != "" {
+				c.SubscriptionsPrune = append(c.SubscriptionsPrune, part)
+			}
+		}
+	}
+	if v := os.Getenv("SUBSCRIPTION_INTERVAL"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil && d >= time.Minute {
+			c.SubscriptionInterval = d
+		} else {
+			logger.Printf("config: invalid SUBSCRIPTION_INTERVAL=%q (minimum 1m)", v)
+		}
+	}
+	if v := strings.TrimSpace(os.Getenv("SUBSCRIPTION_DIR")); v != "" {
+		c.SubscriptionDir = v
+	}
+	if v := os.Getenv("TYPO_PROVIDERS"); v != "" { // comma/space separated; "off" disables suggestions
+		if vl := strings.ToLower(strings.TrimSpace(v)); vl == "off" || vl == "false" || vl == "0" {
+			c.TypoSuggestions = false
+		} else {
+			for _, part := range strings.FieldsFunc(vl, func(r rune) bool { return r == ',' || r == ' ' || r == ';' }) {
+				c.TypoProviders = append(c.TypoProviders, part)
+			}
+		}
+	}
 	return c
 }
+
+// validSubscriptionName reports whether name is usable as a state file name
+// and metric label.
+func validSubscriptionName(name string) bool {
+	if name == "" || name[0] == '.' || len(name) > 64 {
+		return false
+	}
+	for _, r := range name {
+		if (r < 'a' || r > 'z') && (r < '0' || r > '9') && r != '-' && r != '_' && r != '.' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,203 @@
+// Package dnstest runs a minimal in-process DNS server for offline tests. It
+// answers MX, A and AAAA questions from static tables over UDP on the
+// loopback interface.
+package dnstest
+
+import (
+	"context"
+	"net"
+	"strings"
+	"sync"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// MX is a mail exchanger record served for a zone.
+type MX struct {
+	Host string
+	Pref uint16
+}
+
+// Server is a fake authoritative DNS server. Names not present in any table
+// are answered with NXDOMAIN; known names without records of the requested
+// type get an empty NOERROR answer.
+type Server struct {
+	Addr string
+
+	mu    sync.RWMutex
+	mx    map[string][]MX
+	ips   map[string][]net.IP
+	fail  map[string]bool
+	conn  net.PacketConn
+	done  chan struct{}
+	count int
+}
+
+// NewServer starts a server listening on 127.0.0.1 at a random UDP port.
+func NewServer() (*Server, error) {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+	s := &Server{
+		Addr: pc.LocalAddr().String(),
+		mx:   make(map[string][]MX),
+		ips:  make(map[string][]net.IP),
+		fail: make(map[string]bool),
+		conn: pc,
+		done: make(chan struct{}),
+	}
+	go s.serve()
+	return s, nil
+}
+
+// AddMX registers MX records for name.
+func (s *Server) AddMX(name string, records ...MX) {
+	s.mu.Lock()
+	s.mx[fqdn(name)] = append(s.mx[fqdn(name)], records...)
+	s.mu.Unlock()
+}
+
+// AddIP registers A or AAAA records (depending on the address family) for name.
+func (s *Server) AddIP(name string, ips ...string) {
+	s.mu.Lock()
+	for _, v := range ips {
+		if ip := net.ParseIP(v); ip != nil {
+			s.ips[fqdn(name)] = append(s.ips[fqdn(name)], ip)
+		}
+	}
+	s.mu.Unlock()
+}
+
+// Fail makes every question for name return SERVFAIL.
+func (s *Server) Fail(name string) {
+	s.mu.Lock()
+	s.fail[fqdn(name)] = true
+	s.mu.Unlock()
+}
+
+// Queries returns the number of questions answered so far.
+func (s *Server) Queries() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.count
+}
+
+// Resolver returns a pure-Go resolver that sends every query to this server.
+func (s *Server) Resolver() *net.Resolver {
+	return &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, "udp", s.Addr)
+		},
+	}
+}
+
+// Close stops the server.
+func (s *Server) Close() error {
+	err := s.conn.Close()
+	<-s.done
+	return err
+}
+
+func (s *Server) serve() {
+	defer close(s.done)
+	buf := make([]byte, 1500)
+	for {
+		n, addr, err := s.conn.ReadFrom(buf)
+		if err != nil {
+			return
+		}
+		if resp, ok := s.answer(buf[:n]); ok {
+			_, _ = s.conn.WriteTo(resp, addr)
+		}
+	}
+}
+
+func (s *Server) answer(req []byte) ([]byte, bool) {
+	var p dnsmessage.Parser
+	h, err := p.Start(req)
+	if err != nil {
+		return nil, false
+	}
+	q, err := p.Question()
+	if err != nil {
+		return nil, false
+	}
+	name := strings.ToLower(q.Name.String())
+
+	s.mu.Lock()
+	s.count++
+	mxs, hasMX := s.mx[name]
+	ips, hasIP := s.ips[name]
+	failed := s.fail[name]
+	s.mu.Unlock()
+
+	rh := dnsmessage.Header{ID: h.ID, Response: true, Authoritative: true, RecursionDesired: h.RecursionDesired, RecursionAvailable: true}
+	switch {
+	case failed:
+		rh.RCode = dnsmessage.RCodeServerFailure
+	case !hasMX && !hasIP:
+		rh.RCode = dnsmessage.RCodeNameError
+	}
+	b := dnsmessage.NewBuilder(nil, rh)
+	b.EnableCompression()
+	if err := b.StartQuestions(); err != nil {
+		return nil, false
+	}
+	if err := b.Question(q); err != nil {
+		return nil, false
+	}
+	if err := b.StartAnswers(); err != nil {
+		return nil, false
+	}
+	if rh.RCode == dnsmessage.RCodeSuccess {
+		rrh := dnsmessage.ResourceHeader{Name: q.Name, Class: dnsmessage.ClassINET, TTL: 60}
+		switch q.Type {
+		case dnsmessage.TypeMX:
+			for _, m := range mxs {
+				host, err := dnsmessage.NewName(fqdn(m.Host))
+				if err != nil {
+					return nil, false
+				}
+				if err := b.MXResource(rrh, dnsmessage.MXResource{Pref: m.Pref, MX: host}); err != nil {
+					return nil, false
+				}
+			}
+		case dnsmessage.TypeA:
+			for _, ip := range ips {
+				if v4 := ip.To4(); v4 != nil {
+					var a [4]byte
+					copy(a[:], v4)
+					if err := b.AResource(rrh, dnsmessage.AResource{A: a}); err != nil {
+						return nil, false
+					}
+				}
+			}
+		case dnsmessage.TypeAAAA:
+			for _, ip := range ips {
+				if ip.To4() == nil {
+					var a [16]byte
+					copy(a[:], ip.To16())
+					if err := b.AAAAResource(rrh, dnsmessage.AAAAResource{AAAA: a}); err != nil {
+						return nil, false
+					}
+				}
+			}
+		}
+	}
+	out, err := b.Finish()
+	if err != nil {
+		return nil, false
+	}
+	return out, true
+}
+
+func fqdn(name string) string {
+	name = strings.ToLower(name)
+	if !strings.HasSuffix(name, ".") {
+		name += "."
+	}
+	return name
+}
@@ -0,0 +1,280 @@
+package domain
+
+import (
+	"net/netip"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/net/idna"
+)
+
+// Machine-readable reason codes reported in Result.FormatErrors.
+const (
+	ReasonEmpty                   = "empty"
+	ReasonMissingAt               = "missing_at"
+	ReasonMultipleAt              = "multiple_at_signs"
+	ReasonInvalidDisplayName      = "invalid_display_name"
+	ReasonEmptyLocalPart          = "empty_local_part"
+	ReasonLocalPartTooLong        = "local_part_too_long"
+	ReasonInvalidLocalPart        = "invalid_local_part"
+	ReasonLocalPartLeadingDot     = "local_part_leading_dot"
+	ReasonLocalPartTrailingDot    = "local_part_trailing_dot"
+	ReasonLocalPartConsecutiveDot = "local_part_consecutive_dots"
+	ReasonUnterminatedQuote       = "unterminated_quoted_string"
+	ReasonEmptyDomain             = "empty_domain"
+	ReasonDomainTooLong           = "domain_too_long"
+	ReasonDomainLabelTooLong      = "domain_label_too_long"
+	ReasonInvalidDomainLabel      = "invalid_domain_label"
+	ReasonDomainTrailingDot       = "domain_trailing_dot"
+	ReasonInvalidIDN              = "invalid_idn"
+	ReasonInvalidDomainLiteral    = "invalid_domain_literal"
+	ReasonAddressTooLong          = "address_too_long"
+)
+
+// Length limits from RFC 5321 section 4.5.3.1 (path limit minus the angle brackets).
+const (
+	maxLocalPartLen = 64
+	maxDomainLen    = 253
+	maxLabelLen     = 63
+	maxAddressLen   = 254
+)
+
+// Address is the result of strict addr-spec parsing.
+type Address struct {
+	DisplayName   string
+	LocalPart     string // as written, including quotes for quoted local parts
+	Domain        string // as written, including brackets for domain literals
+	Quoted        bool   // local part is a quoted-string
+	DomainLiteral bool   // domain is an address literal such as [192.0.2.1]
+	SMTPUTF8      bool   // local part needs SMTPUTF8 (RFC 6531)
+}
+
+// ParseAddress validates s as an RFC 5321/5322 mailbox. It accepts an optional
+// display name ("Name <addr>"), dot-atom and quoted local parts, UTF-8 local
+// parts (RFC 6531), hostnames (including IDNs) and IPv4/IPv6 address literals.
+// Obsolete syntax such as comments and folding whitespace is rejected. The
+// returned Address is filled on a best-effort basis even when reasons are
+// reported, so callers can still inspect the domain.
+func ParseAddress(s string) (Address, []string) {
+	var a Address
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return a, []string{ReasonEmpty}
+	}
+	if strings.HasSuffix(s, ">") {
+		lt := strings.LastIndexByte(s, '<')
+		if lt == -1 {
+			return a, []string{ReasonInvalidDisplayName}
+		}
+		a.DisplayName = strings.TrimSpace(s[:lt])
+		s = s[lt+1 : len(s)-1]
+	}
+
+	var reasons []string
+	add := func(r string) {
+		for _, x := range reasons {
+			if x == r {
+				return
+			}
+		}
+		reasons = append(reasons, r)
+	}
+
+	// Local part: quoted-string or dot-atom, terminated by the first '@' outside quotes.
+	var rest string
+	if strings.HasPrefix(s, `"`) {
+		a.Quoted = true
+		end := -1
+		for i := 1; i < len(s); i++ {
+			c := s[i]
+			if c == '\\' {
+				i++
+				continue
+			}
+			if c == '"' {
+				end = i
+				break
+			}
+			if c < 0x20 || c == 0x7f {
+				add(ReasonInvalidLocalPart)
+			}
+			if c >= 0x80 {
+				a.SMTPUTF8 = true
+			}
+		}
+		if end == -1 {
+			a.LocalPart = s
+			return a, append(reasons, ReasonUnterminatedQuote)
+		}
+		a.LocalPart = s[:end+1]
+		rest = s[end+1:]
+		if !strings.HasPrefix(rest, "@") {
+			if rest == "" {
+				return a, append(reasons, ReasonMissingAt)
+			}
+			add(ReasonInvalidLocalPart)
+			if i := strings.IndexByte(rest, '@'); i != -1 {
+				rest = rest[i:]
+			} else {
+				return a, append(reasons, ReasonMissingAt)
+			}
+		}
+		rest = rest[1:]
+		if len(a.LocalPart) == 2 {
+			add(ReasonEmptyLocalPart)
+		}
+	} else {
+		at := strings.IndexByte(s, '@')
+		if at == -1 {
+			a.LocalPart = s
+			return a, []string{ReasonMissingAt}
+		}
+		a.LocalPart = s[:at]
+		rest = s[at+1:]
+		for _, r := range validateDotAtom(a.LocalPart, &a.SMTPUTF8) {
+			add(r)
+		}
+	}
+	if len(a.LocalPart) > maxLocalPartLen {
+		add(ReasonLocalPartTooLong)
+	}
+	if strings.Contains(rest, "@") {
+		add(ReasonMultipleAt)
+		rest = rest[strings.LastIndexByte(rest, '@')+1:]
+	}
+	a.Domain = rest
+
+	// Domain: address literal or hostname.
+	var asciiDomain string
+	if strings.HasPrefix(rest, "[") {
+		a.DomainLiteral = true
+		if !validDomainLiteral(rest) {
+			add(ReasonInvalidDomainLiteral)
+		}
+		asciiDomain = rest
+	} else {
+		var dr []string
+		asciiDomain, dr = validateHostname(rest)
+		for _, r := range dr {
+			add(r)
+		}
+	}
+	if len(a.LocalPart)+1+len(asciiDomain) > maxAddressLen {
+		add(ReasonAddressTooLong)
+	}
+	return a, reasons
+}
+
+// validateDotAtom checks an unquoted local part against RFC 5322 dot-atom,
+// extended with UTF-8 characters per RFC 6531.
+func validateDotAtom(lp string, smtputf8 *bool) []string {
+	if lp == "" {
+		return []string{ReasonEmptyLocalPart}
+	}
+	var out []string
+	if lp[0] == '.' {
+		out = append(out, ReasonLocalPartLeadingDot)
+	}
+	if lp[len(lp)-1] == '.' {
+		out = append(out, ReasonLocalPartTrailingDot)
+	}
+	if strings.Contains(lp, "..") {
+		out = append(out, ReasonLocalPartConsecutiveDot)
+	}
+	if !utf8.ValidString(lp) {
+		return append(out, ReasonInvalidLocalPart)
+	}
+	for _, r := range lp {
+		if r >= utf8.RuneSelf {
+			*smtputf8 = true
+			continue
+		}
+		if r == '.' || isAtext(byte(r)) {
+			continue
+		}
+		return append(out, ReasonInvalidLocalPart)
+	}
+	return out
+}
+
+func isAtext(c byte) bool {
+	if (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9') {
+		return true
+	}
+	return strings.IndexByte("!#$%&'*+-/=?^_`{|}~", c) != -1
+}
+
+// validateHostname checks a mail domain (possibly Unicode) and returns its
+// ASCII form together with any reason codes.
+func validateHostname(d string) (string, []string) {
+	if d == "" {
+		return "", []string{ReasonEmptyDomain}
+	}
+	var out []string
+	if strings.HasSuffix(d, ".") {
+		out = append(out, ReasonDomainTrailingDot)
+		d = strings.TrimSuffix(d, ".")
+	}
+	ascii := strings.ToLower(d)
+	if needsIDNA(d) {
+		a, err := idna.Lookup.ToASCII(d)
+		if err != nil {
+			return ascii, append(out, ReasonInvalidIDN)
+		}
+		ascii = a
+	}
+	if len(ascii) > maxDomainLen {
+		out = append(out, ReasonDomainTooLong)
+	}
+	badLabel := false
+	longLabel := false
+	for _, label := range strings.Split(ascii, ".") {
+		if len(label) > maxLabelLen {
+			longLabel = true
+		}
+		if label == "" || label[0] == '-' || label[len(label)-1] == '-' {
+			badLabel = true
+			continue
+		}
+		for i := 0; i < len(label); i++ {
+			c := label[i]
+			if !((c >= 'a' && c <= 'z') || (c >= '0' && c <= '9') || c == '-') {
+				badLabel = true
+				break
+			}
+		}
+	}
+	if longLabel {
+		out = append(out, ReasonDomainLabelTooLong)
+	}
+	if badLabel {
+		out = append(out, ReasonInvalidDomainLabel)
+	}
+	return ascii, out
+}
+
+// needsIDNA reports whether d has non-ASCII characters or punycode labels.
+// Other ASCII names are checked directly so that e.g. underscores are
+// reported as invalid labels rather than IDNA failures.
+func needsIDNA(d string) bool {
+	for i := 0; i < len(d); i++ {
+		if d[i] >= utf8.RuneSelf {
+			return true
+		}
+	}
+	return strings.Contains(strings.ToLower(d), "xn--")
+}
+
+// validDomainLiteral accepts "[IPv4]" and "[IPv6:addr]" address literals.
+func validDomainLiteral(s string) bool {
+	if len(s) < 3 || s[0] != '[' || s[len(s)-1] != ']' {
+		return false
+	}
+	inner := s[1 : len(s)-1]
+	if len(inner) > 5 && strings.EqualFold(inner[:5], "IPv6:") {
+		ip, err := netip.ParseAddr(inner[5:])
+		return err == nil && ip.Is6() && ip.Zone() == ""
+	}
+	ip, err := netip.ParseAddr(inner)
+	return err == nil && ip.Is4()
+}
@@ -0,0 +1,70 @@
+package domain
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseAddress(t *testing.T) {
+	cases := []struct {
+		in      string
+		reasons []string
+	}{
+		{"user@example.com", nil},
+		{"first.last+tag@sub.example.co.uk", nil},
+		{"Jane Doe <jane@example.com>", nil},
+		{`"john doe"@example.com`, nil},
+		{`"a\"b"@example.com`, nil},
+		{"user@[192.0.2.1]", nil},
+		{"user@[IPv6:2001:db8::1]", nil},
+		{"δοκιμή@παράδειγμα.δοκιμή", nil},
+		{"", []string{ReasonEmpty}},
+		{"example.com", []string{ReasonMissingAt}},
+		{"a@@b.com", []string{ReasonMultipleAt}},
+		{"a b@c.com", []string{ReasonInvalidLocalPart}},
+		{"@example.com", []string{ReasonEmptyLocalPart}},
+		{".a@example.com", []string{ReasonLocalPartLeadingDot}},
+		{"a.@example.com", []string{ReasonLocalPartTrailingDot}},
+		{"a..b@example.com", []string{ReasonLocalPartConsecutiveDot}},
+		{`"abc@example.com`, []string{ReasonUnterminatedQuote}},
+		{strings.Repeat("a", 65) + "@example.com", []string{ReasonLocalPartTooLong}},
+		{"a@", []string{ReasonEmptyDomain}},
+		{"a@example.com.", []string{ReasonDomainTrailingDot}},
+		{"a@exa_mple.com", []string{ReasonInvalidDomainLabel}},
+		{"a@-example.com", []string{ReasonInvalidDomainLabel}},
+		{"a@" + strings.Repeat("x", 64) + ".com", []string{ReasonDomainLabelTooLong}},
+		{"a@[300.1.1.1]", []string{ReasonInvalidDomainLiteral}},
+		{"a@[IPv6:nope]", []string{ReasonInvalidDomainLiteral}},
+		{strings.Repeat("a", 64) + "@" + strings.Repeat(strings.Repeat("b", 60)+".", 4) + "com", []string{ReasonAddressTooLong}},
+	}
+	for _, c := range cases {
+		_, got := ParseAddress(c.in)
+		if !reflect.DeepEqual(got, c.reasons) {
+			t.Errorf("ParseAddress(%q) reasons = %v, want %v", c.in, got, c.reasons)
+		}
+	}
+}
+
+func TestCheckFormatErrors(t *testing.T) {
+	writeTempList(t, "allowlist.conf", []string{"good.com"})
+	writeTempList(t, "blocklist.conf", []string{"bad.com"})
+	c := NewChecker("allowlist.conf", "blocklist.conf")
+	if err := c.Load(); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	res := c.Check("a b@bad.com")
+	if res.ValidFormat || len(res.FormatErrors) == 0 {
+		t.Fatalf("expected invalid format, got %+v", res)
+	}
+	if !res.Blocklisted {
+		t.Fatalf("expected list lookup on best-effort domain, got %+v", res)
+	}
+	lit := c.Check("user@[192.0.2.1]")
+	if !lit.ValidFormat || !lit.DomainLiteral || lit.Status != "neutral" {
+		t.Fatalf("unexpected domain literal result: %+v", lit)
+	}
+	if utf := c.Check("δοκιμή@good.com"); !utf.ValidFormat || !utf.SMTPUTF8 || utf.Status != "allow" {
+		t.Fatalf("unexpected SMTPUTF8 result: %+v", utf)
+	}
+}
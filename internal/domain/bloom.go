@@ -0,0 +1,168 @@
+package domain
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+	"slices"
+	"strings"
+)
+
+// bloomFilter is a Bloom filter over list keys. Probe positions come from one
+// 64-bit FNV-1a hash split into h1 (low 32 bits) and h2 (high 32 bits, low
+// bit forced to 1 so the probes never collapse onto one position):
+// position i of k is (h1 + i*h2) mod m. The layout is stable so edge services
+// can evaluate the exported artifact (see marshal).
+type bloomFilter struct {
+	bits []uint64
+	m    uint64 // number of bits
+	k    uint32
+	n    int // keys added
+}
+
+// bloomFPRate is the target false-positive rate filters are sized for.
+const bloomFPRate = 0.01
+
+// newBloomFilter sizes a filter for n keys at the given false-positive rate.
+func newBloomFilter(n int, fpRate float64) *bloomFilter {
+	n = max(n, 1)
+	m := uint64(math.Ceil(-float64(n) * math.Log(fpRate) / (math.Ln2 * math.Ln2)))
+	m = max((m+63)/64*64, 64)
+	k := uint32(max(1, math.Round(float64(m)/float64(n)*math.Ln2)))
+	return &bloomFilter{bits: make([]uint64, m/64), m: m, k: k}
+}
+
+func bloomHash(s string) (h1, h2 uint64) {
+	h := uint64(14695981039346656037)
+	for i := 0; i < len(s); i++ {
+		h ^= uint64(s[i])
+		h *= 1099511628211
+	}
+	return h & 0xffffffff, h>>32 | 1
+}
+
+func (f *bloomFilter) add(s string) {
+	h1, h2 := bloomHash(s)
+	for i := uint64(0); i < uint64(f.k); i++ {
+		p := (h1 + i*h2) % f.m
+		f.bits[p/64] |= 1 << (p % 64)
+	}
+	f.n++
+}
+
+func (f *bloomFilter) has(s string) bool {
+	h1, h2 := bloomHash(s)
+	for i := uint64(0); i < uint64(f.k); i++ {
+		p := (h1 + i*h2) % f.m
+		if f.bits[p/64]&(1<<(p%64)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// hasSuffixOf reports whether the filter may contain d or any of its parent
+// domains, which covers exact, eTLD+1 and suffix pattern matches.
+func (f *bloomFilter) hasSuffixOf(d string) bool {
+	for s := d; s != ""; {
+		if f.has(s) {
+			return true
+		}
+		dot := strings.IndexByte(s, '.')
+		if dot == -1 {
+			break
+		}
+		s = s[dot+1:]
+	}
+	return false
+}
+
+func (f *bloomFilter) clone() *bloomFilter {
+	out := *f
+	out.bits = slices.Clone(f.bits)
+	return &out
+}
+
+// buildListFilter indexes the exact entries of idx and the anchors of the
+// suffix patterns in rs. Regex patterns cannot be represented.
+func buildListFilter(idx listIndex, rs *ruleSet) *bloomFilter {
+	f := newBloomFilter(idx.len()+rs.len(), bloomFPRate)
+	idx.each(func(k string, _ int) { f.add(k) })
+	if rs != nil {
+		for _, r := range rs.rules {
+			if r.Kind != RuleRegex {
+				f.add(r.Suffix)
+			}
+		}
+	}
+	return f
+}
+
+// Bloom artifact layout (little endian), served at /blocklist.bloom:
+//
+//	magic      [8]byte "DEDBLM1\n"
+//	generation uint64  list snapshot the filter was taken from
+//	m          uint64  number of bits
+//	k          uint32  probes per key
+//	regex      uint32  regex patterns not represented in the filter
+//	n          uint64  keys added
+//	bits       [m/64]uint64
+const bloomMagic = "DEDBLM1\n"
+
+var errBadBloom = errors.New("malformed bloom filter")
+
+func (f *bloomFilter) marshal(generation uint64, regex int) []byte {
+	buf := make([]byte, 0, 40+8*len(f.bits))
+	buf = append(buf, bloomMagic...)
+	buf = binary.LittleEndian.AppendUint64(buf, generation)
+	buf = binary.LittleEndian.AppendUint64(buf, f.m)
+	buf = binary.LittleEndian.AppendUint32(buf, f.k)
+	buf = binary.LittleEndian.AppendUint32(buf, uint32(regex))
+	buf = binary.LittleEndian.AppendUint64(buf, uint64(f.n))
+	for _, w := range f.bits {
+		buf = binary.LittleEndian.AppendUint64(buf, w)
+	}
+	return buf
+}
+
+func unmarshalBloom(buf []byte) (f *bloomFilter, generation uint64, regex int, err error) {
+	if len(buf) < 40 || string(buf[:8]) != bloomMagic {
+		return nil, 0, 0, errBadBloom
+	}
+	generation = binary.LittleEndian.Uint64(buf[8:])
+	f = &bloomFilter{m: binary.LittleEndian.Uint64(buf[16:]), k: binary.LittleEndian.Uint32(buf[24:])}
+	regex = int(binary.LittleEndian.Uint32(buf[28:]))
+	f.n = int(binary.LittleEndian.Uint64(buf[32:]))
+	words := buf[40:]
+	if f.m == 0 || f.m%64 != 0 || f.k == 0 || uint64(len(words)) != f.m/8 {
+		return nil, 0, 0, errBadBloom
+	}
+	f.bits = make([]uint64, f.m/64)
+	for i := range f.bits {
+		f.bits[i] = binary.LittleEndian.Uint64(words[8*i:])
+	}
+	return f, generation, regex, nil
+}
+
+// BlocklistBloom returns the blocklist filter of the current snapshot in the
+// artifact format together with its generation.
+func (c *Checker) BlocklistBloom() ([]byte, uint64) {
+	s := c.current()
+	if s.blockFilter == nil {
+		return nil, s.generation
+	}
+	return s.blockFilter.marshal(s.generation, s.blockRules.regexLen()), s.generation
+}
+
+// filterWith adds key to filter, cloning it first while it is still shared
+// with the published snapshot (base).
+func filterWith(filter, base *bloomFilter, key string) *bloomFilter {
+	if filter == nil {
+		return nil
+	}
+	if filter == base {
+		filter = base.clone()
+	}
+	filter.add(key)
+	return filter
+}
@@ -0,0 +1,104 @@
+package domain
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+func TestBloomFilter(t *testing.T) {
+	const n = 20_000
+	f := newBloomFilter(n, bloomFPRate)
+	for i := 0; i < n; i++ {
+		f.add("listed-" + strconv.Itoa(i) + ".example")
+	}
+	for i := 0; i < n; i++ {
+		if !f.has("listed-" + strconv.Itoa(i) + ".example") {
+			t.Fatalf("false negative for entry %d", i)
+		}
+	}
+	fp := 0
+	for i := 0; i < n; i++ {
+		if f.has("other-" + strconv.Itoa(i) + ".example") {
+			fp++
+		}
+	}
+	if rate := float64(fp) / n; rate > 2*bloomFPRate {
+		t.Fatalf("false-positive rate %.4f exceeds twice the target", rate)
+	}
+
+	g, gen, regex, err := unmarshalBloom(f.marshal(7, 2))
+	if err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if gen != 7 || regex != 2 || !reflect.DeepEqual(f, g) {
+		t.Fatalf("artifact round trip mismatch: gen %d regex %d", gen, regex)
+	}
+	if _, _, _, err := unmarshalBloom(f.marshal(7, 2)[:100]); err != errBadBloom {
+		t.Fatalf("expected errBadBloom for truncated artifact, got %v", err)
+	}
+	for i := 0; i < n; i++ {
+		if _, h2 := bloomHash("listed-" + strconv.Itoa(i) + ".example"); h2%2 == 0 {
+			t.Fatalf("even probe step %d for entry %d", h2, i)
+		}
+	}
+}
+
+// TestBloomFastPath checks that results are identical with and without the
+// filters, including patched entries and regex patterns.
+func TestBloomFastPath(t *testing.T) {
+	dir := t.TempDir()
+	allowPath := filepath.Join(dir, "allowlist.conf")
+	blockPath := filepath.Join(dir, "blocklist.conf")
+	writeTempList(t, allowPath, []string{"good.com", "*.trusted.net"})
+	writeTempList(t, blockPath, []string{"bad.com", "*.usa.cc", "||burner.io^", "/^tmp[0-9]+\\.example$/"})
+	c := NewChecker(allowPath, blockPath)
+	if err := c.Load(); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	c.PatchBlock([]string{"fresh.io", "||spam.example^"})
+	if c.current().blockFilter == nil || !c.current().blockFilter.has("fresh.io") {
+		t.Fatal("patched entry missing from the blocklist filter")
+	}
+
+	inputs := []string{"bad.com", "user@x.bad.com", "a.usa.cc", "mail.burner.io", "tmp42.example", "fresh.io", "a.spam.example", "good.com", "a.trusted.net", "gmail.com", "neutral.org"}
+	ctx := context.Background()
+	with := make([]Result, len(inputs))
+	for i, in := range inputs {
+		with[i] = c.CheckTenant(ctx, "", in)
+	}
+	s := *c.current()
+	s.allowFilter, s.blockFilter = nil, nil
+	c.snap.Store(&s)
+	for i, in := range inputs {
+		want := c.CheckTenant(ctx, "", in)
+		want.CheckedAt = with[i].CheckedAt
+		if !reflect.DeepEqual(with[i], want) {
+			t.Errorf("%s: filtered result %+v differs from unfiltered %+v", in, with[i], want)
+		}
+	}
+
+	buf, gen := c.BlocklistBloom()
+	if buf != nil || gen != s.generation {
+		t.Fatalf("expected no artifact without a filter, got %d bytes", len(buf))
+	}
+
+	// Removals rebuild the filter instead of leaving stale hits.
+	if err := c.Load(); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := c.RemoveBlock([]string{"bad.com", "*.usa.cc"}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if f := c.current().blockFilter; f == nil || f.has("bad.com") || f.has("usa.cc") || !f.has("burner.io") {
+		t.Fatal("expected the blocklist filter rebuilt without the removed entries")
+	}
+	if _, _, err := c.RemoveAllow([]string{"good.com"}); err != nil {
+		t.Fatal(err)
+	}
+	if f := c.current().allowFilter; f == nil || f.has("good.com") || !f.has("trusted.net") {
+		t.Fatal("expected the allowlist filter rebuilt without the removed entries")
+	}
+}
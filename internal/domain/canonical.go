@@ -0,0 +1,69 @@
+package domain
+
+import "strings"
+
+// CanonicalRule describes how a mailbox provider maps addresses to inboxes.
+type CanonicalRule struct {
+	// Domains served by the provider; the first one is the canonical domain
+	// the others are aliases of (googlemail.com -> gmail.com).
+	Domains []string
+	// Separators starts a sub-address: everything from the first separator
+	// in the local part is dropped (jane+news -> jane).
+	Separators string
+	// Ignore lists characters that do not change the inbox (Gmail dots).
+	Ignore string
+}
+
+// DefaultCanonicalRules are the provider rules used for canonical_address.
+// Addresses at other domains only get their local part lowercased.
+var DefaultCanonicalRules = []CanonicalRule{
+	{Domains: []string{"gmail.com", "googlemail.com"}, Separators: "+", Ignore: "."},
+	{Domains: []string{"outlook.com"}, Separators: "+"},
+	{Domains: []string{"hotmail.com"}, Separators: "+"},
+	{Domains: []string{"live.com"}, Separators: "+"},
+	{Domains: []string{"msn.com"}, Separators: "+"},
+	{Domains: []string{"icloud.com", "me.com", "mac.com"}, Separators: "+"},
+	{Domains: []string{"proton.me", "protonmail.com", "protonmail.ch", "pm.me"}, Separators: "+", Ignore: ".-_"},
+	{Domains: []string{"fastmail.com"}, Separators: "+"},
+	{Domains: []string{"yahoo.com"}, Separators: "-"},
+	{Domains: []string{"yandex.ru", "yandex.com", "ya.ru"}, Separators: "+"},
+}
+
+// canonicalRules indexes DefaultCanonicalRules by domain.
+var canonicalRules = func() map[string]*CanonicalRule {
+	m := make(map[string]*CanonicalRule)
+	for i := range DefaultCanonicalRules {
+		r := &DefaultCanonicalRules[i]
+		for _, d := range r.Domains {
+			m[d] = r
+		}
+	}
+	return m
+}()
+
+// canonicalAddress returns the provider-canonical form of local@domain, where
+// domain is the normalized (IDNA ASCII) domain. Local parts are compared case
+// insensitively; quoted local parts are kept apart from provider rules since
+// providers do not issue them.
+func canonicalAddress(local, domain string, quoted bool) string {
+	if local == "" || domain == "" {
+		return ""
+	}
+	local = strings.ToLower(local)
+	r := canonicalRules[domain]
+	if r == nil || quoted {
+		return local + "@" + domain
+	}
+	if i := strings.IndexAny(local, r.Separators); r.Separators != "" && i > 0 {
+		local = local[:i]
+	}
+	if r.Ignore != "" {
+		local = strings.Map(func(c rune) rune {
+			if strings.ContainsRune(r.Ignore, c) {
+				return -1
+			}
+			return c
+		}, local)
+	}
+	return local + "@" + r.Domains[0]
+}
@@ -0,0 +1,28 @@
+package domain
+
+import "testing"
+
+func TestCanonicalAddress(t *testing.T) {
+	c := NewChecker("", "")
+	cases := map[string]string{
+		"J.Doe+news@Gmail.com":        "jdoe@gmail.com",
+		"jdoe@googlemail.com":         "jdoe@gmail.com",
+		"john.doe+x@outlook.com":      "john.doe@outlook.com",
+		"jane-shopping@yahoo.com":     "jane@yahoo.com",
+		"Jane@me.com":                 "jane@icloud.com",
+		"first.last-x@protonmail.com": "firstlastx@proton.me",
+		"first.last+tag@example.com":  "first.last+tag@example.com",
+		"+only@gmail.com":             "+only@gmail.com",
+		`"j.doe+x"@gmail.com`:         `"j.doe+x"@gmail.com`,
+		"not an address@gmail.com":    "",
+		"user@bücher.example":         "user@xn--bcher-kva.example",
+	}
+	for in, want := range cases {
+		if got := c.Check(in).CanonicalAddress; got != want {
+			t.Errorf("%s: expected canonical %q, got %q", in, want, got)
+		}
+	}
+	if got := c.Check("gmail.com").CanonicalAddress; got != "" {
+		t.Errorf("domain checks have no canonical address, got %q", got)
+	}
+}
@@ -1,18 +1,20 @@
 package domain
 
 import (
-	"bufio"
+	"bytes"
+	"context"
 	"errors"
-	"net/mail"
+	"maps"
 	"os"
+	"slices"
 	"sort"
+	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"disposable-email-domains/internal/metrics"
-
-	"golang.org/x/net/publicsuffix"
 )
 
 // Loads and evaluates allow/block lists and provides PSL-based domain checks.
@@ -20,13 +22,36 @@ type Checker struct {
 	allowPath string
 	blockPath string
 
-	mu        sync.RWMutex
-	allow     map[string]struct{}
-	block     map[string]struct{}
-	rawAllow  []string
-	rawBlock  []string
-	updatedAt time.Time
-	loaded    bool
+	// snap is the published list state; see snapshot. writeMu serializes
+	// the writers that build the next one.
+	snap      atomic.Pointer[snapshot]
+	writeMu   sync.Mutex
+	indexMode string // applied by the next Load (guarded by writeMu)
+	rolePath  string // role account list read by Load (guarded by writeMu)
+
+	// psl holds the runtime public suffix list; nil means the table compiled
+	// into golang.org/x/net/publicsuffix is used.
+	psl atomic.Pointer[PSL]
+	// mx is the optional MX infrastructure detector; nil disables DNS lookups.
+	mx atomic.Pointer[MXDetector]
+	// scoring holds the risk score weights; nil means DefaultScoreConfig.
+	scoring atomic.Pointer[ScoreConfig]
+	// typos holds the typo suggestion providers; nil means
+	// DefaultTypoProviders.
+	typos atomic.Pointer[typoProviders]
+	// suspect is the probability from which neutral domains are suspect.
+	suspect suspectThreshold
+	// wrote is called with the contents the checker writes to a list file;
+	// see SetWriteHook.
+	wrote atomic.Pointer[func(path string, data []byte)]
+	// validation caches the Validate report; validateMu serializes full
+	// validations.
+	validation atomic.Pointer[validation]
+	validateMu sync.Mutex
+	// derived checkers (see Derive) leave the list gauges alone and use the
+	// lexical classifier of the checker they were derived from.
+	derived bool
+	lexical *lexicalModel
 }
 
 func NewChecker(allowPath, blockPath string) *Checker {
@@ -36,44 +61,425 @@ func NewChecker(allowPath, blockPath string) *Checker {
 	}
 }
 
+// Derive loads the lists at allowPath and blockPath into a new checker that
+// shares this checker's role list, PSL, score weights, typo providers, lexical
+// classifier and suspect threshold. The MX detector is not shared, and IndexMmap becomes
+// IndexCompact so the files may be removed after loading.
+func (c *Checker) Derive(allowPath, blockPath string) (*Checker, error) {
+	d := NewChecker(allowPath, blockPath)
+	d.derived = true
+	c.writeMu.Lock()
+	d.indexMode, d.rolePath = c.indexMode, c.rolePath
+	c.writeMu.Unlock()
+	if d.indexMode == IndexMmap {
+		d.indexMode = IndexCompact
+	}
+	d.psl.Store(c.psl.Load())
+	d.scoring.Store(c.scoring.Load())
+	d.typos.Store(c.typos.Load())
+	d.suspect.bits.Store(c.suspect.bits.Load())
+	d.lexical = c.current().lexical
+	if err := d.Load(); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// SetPSL atomically swaps the public suffix list used by Check and Validate.
+// Passing nil reverts to the compiled-in table.
+func (c *Checker) SetPSL(p *PSL) {
+	c.psl.Store(p)
+}
+
+// LoadPSL parses the public suffix list file at path and swaps it in. On error
+// the previously active list stays in place.
+func (c *Checker) LoadPSL(path string) error {
+	p, err := LoadPSLFile(path)
+	if err != nil {
+		return err
+	}
+	c.SetPSL(p)
+	return nil
+}
+
+// SetMXDetector enables MX infrastructure detection in Check. Passing nil
+// disables it.
+func (c *Checker) SetMXDetector(d *MXDetector) {
+	c.mx.Store(d)
+}
+
+// SetWriteHook registers fn to be called with the contents the checker wrote
+// to a list file (removals, fixes), so a file watcher can tell them from
+// outside edits. Passing nil removes it.
+func (c *Checker) SetWriteHook(fn func(path string, data []byte)) {
+	if fn == nil {
+		c.wrote.Store(nil)
+		return
+	}
+	c.wrote.Store(&fn)
+}
+
+func (c *Checker) written(path string, data []byte) {
+	if fn := c.wrote.Load(); fn != nil {
+		(*fn)(path, data)
+	}
+}
+
+// MXDetector returns the active MX detector or nil.
+func (c *Checker) MXDetector() *MXDetector {
+	return c.mx.Load()
+}
+
+// PSLInfo describes the public suffix list currently used for checks.
+func (c *Checker) PSLInfo() PSLInfo {
+	return c.suffixes().Info()
+}
+
+// RegistrableDomain returns the eTLD+1 of d under the public suffix list
+// currently used for checks, so callers agree with Check on what a
+// registrable domain is after a PSL refresh.
+func (c *Checker) RegistrableDomain(d string) (string, error) {
+	return c.suffixes().EffectiveTLDPlusOne(d)
+}
+
+func (c *Checker) suffixes() suffixList {
+	if p := c.psl.Load(); p != nil {
+		return p
+	}
+	return builtinPSL{}
+}
+
 // PatchBlock incrementally adds new blocklist domains to the in-memory indexes without
 // re-reading the underlying file. It assumes the canonical file has already been
 // atomically updated (append / rewrite) by the caller. Domains are normalized to
-// lowercase and trimmed; empty or comment lines are ignored. Duplicate entries are
-// skipped. updatedAt is refreshed only if at least one new domain was inserted.
+// their IDNA ASCII form (lowercase) and trimmed; empty or comment lines are ignored. Duplicate entries are
+// skipped. Pattern entries are compiled into the rule matcher; malformed patterns
+// are ignored here and surface through Validate after the next Load.
+// updatedAt is refreshed only if at least one new domain was inserted.
 func (c *Checker) PatchBlock(domains []string) {
-	if len(domains) == 0 {
-		return
+	c.patchList(false, domains, nil)
+}
+
+// PatchBlockFrom is PatchBlock for entries with known provenance: inserted
+// entries are recorded in the blocklist metadata sidecar so GET /blocklist and
+// check explanations can report when, from where and by whom they were added.
+func (c *Checker) PatchBlockFrom(domains []string, p Provenance) error {
+	_, err := c.patchListFrom(false, domains, p)
+	return err
+}
+
+// PatchAllow is PatchBlock for the allowlist.
+func (c *Checker) PatchAllow(domains []string) {
+	c.patchList(true, domains, nil)
+}
+
+// PatchAllowFrom is PatchBlockFrom for the allowlist.
+func (c *Checker) PatchAllowFrom(domains []string, p Provenance) error {
+	_, err := c.patchListFrom(true, domains, p)
+	return err
+}
+
+func (c *Checker) listPath(allow bool) string {
+	if allow {
+		return c.allowPath
 	}
-	c.mu.Lock()
-	if c.block == nil { // in case Load was never called yet; be defensive
-		c.block = make(map[string]struct{})
+	return c.blockPath
+}
+
+// ListFiles returns the files the list state is loaded from: both lists and
+// their provenance and tombstone sidecars.
+func (c *Checker) ListFiles() []string {
+	return []string{c.allowPath, metaPath(c.allowPath), c.blockPath, metaPath(c.blockPath), tombstonePath(c.blockPath)}
+}
+
+// patchListFrom patches a list and records the provenance of the inserted
+// entries.
+func (c *Checker) patchListFrom(allow bool, domains []string, p Provenance) ([]string, error) {
+	if p.AddedAt.IsZero() {
+		p.AddedAt = time.Now().UTC()
 	}
-	inserted := 0
+	return c.patchList(allow, domains, &p)
+}
+
+// patchList applies PatchBlock or PatchAllow and returns the inserted entries
+// in their stored form. With provenance, the inserted entries are recorded in
+// the metadata sidecar and, for manual blocklist additions, their tombstones
+// are lifted; both sidecars are written before the new state is published so
+// a crash cannot leave the served state ahead of what a restart would load.
+// The list file already holds the entries, so a failed metadata write still
+// publishes them; a failed tombstone write keeps the tombstones.
+func (c *Checker) patchList(allow bool, domains []string, p *Provenance) ([]string, error) {
+	if len(domains) == 0 {
+		return nil, nil
+	}
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	cur := c.current()
+	next := *cur
+	base, l := cur.list(allow), next.list(allow)
+	idx := withDelta(*base.idx)
+	rules := *base.rules
+	filter := *base.filter
+	var meta map[string]Provenance
+	var inserted []string
+	addedRegex := false
 	for _, d := range domains {
-		d = strings.ToLower(strings.TrimSpace(d))
+		d = strings.TrimSpace(d)
 		if d == "" || strings.HasPrefix(d, "#") {
 			continue
 		}
-		if _, exists := c.block[d]; exists {
-			continue
+		if isRuleLine(d) {
+			r, err := parseRule(d, *l.lines+1)
+			if err != nil || rules.has(d) {
+				continue
+			}
+			if rules == *base.rules {
+				rules = rules.clone()
+			}
+			rules.add(r)
+			addedRegex = addedRegex || r.Kind == RuleRegex
+			if r.Kind != RuleRegex {
+				filter = filterWith(filter, *base.filter, r.Suffix)
+			}
+		} else {
+			d = normalizeListEntry(d)
+			if _, exists := idx.lookup(d); exists {
+				continue
+			}
+			idx.delta[d] = *l.lines + 1
+			filter = filterWith(filter, *base.filter, d)
 		}
-		c.block[d] = struct{}{}
-		c.rawBlock = append(c.rawBlock, d)
-		inserted++
+		*l.lines++
+		if next.keepsRaw() {
+			*l.raw = append(*l.raw, d)
+		}
+		inserted = append(inserted, d)
+		if p != nil {
+			if meta == nil {
+				meta = make(map[string]Provenance, len(*base.meta)+len(domains))
+				maps.Copy(meta, *base.meta)
+			}
+			meta[d] = *p
+		}
+	}
+	if len(inserted) == 0 {
+		return nil, nil
+	}
+	var err error
+	if p != nil {
+		err = appendMetaFile(metaPath(c.listPath(allow)), inserted, *p)
+	}
+	if addedRegex {
+		rules.finish()
+	}
+	*l.idx = idx
+	*l.rules = rules
+	*l.filter = filter
+	if meta != nil {
+		*l.meta = meta
+	}
+	if allow {
+		next.allowSkeletons = buildSkeletons(idx)
+		next.allowTypos = buildAllowTypos(idx)
+	} else if p != nil && p.Source == SourceManual {
+		if lifted := liftable(cur.blockTombstones, inserted); lifted != nil {
+			if terr := appendTombstoneFile(tombstonePath(c.blockPath), lifted, Tombstone{}, true); terr != nil {
+				err = errors.Join(err, terr)
+			} else {
+				next.blockTombstones = withoutKeys(cur.blockTombstones, lifted)
+			}
+		}
+	}
+	next.updatedAt = time.Now().UTC()
+	next.loaded = true // ready if the first successful patch precedes Load
+	c.publish(&next)
+	if allow {
+		// New allowlist entries can resolve or create findings anywhere in
+		// the blocklist; the next Validate starts over.
+		metrics.AllowlistSizeGauge.Set(float64(idx.len() + rules.len()))
+	} else {
+		c.advanceValidation(cur, &next, inserted)
+		metrics.BlocklistSizeGauge.Set(float64(idx.len() + rules.len()))
+	}
+	return inserted, err
+}
+
+// removeList deletes entries from a list file and the in-memory indexes
+// without a reload: every line naming one of the entries (domains compared in
+// their lookup form, patterns verbatim) is dropped and the lines after it move
+// up. It returns the removed entries in stored form, in file order, and the
+// requested entries that matched no line. t tombstones removed blocklist
+// entries. Callers serialize removals with other writers of the file.
+func (c *Checker) removeList(allow bool, entries []string, t *Tombstone) (removed, missing []string, err error) {
+	drop := make(map[string]struct{}, len(entries))
+	var requested []string
+	for _, e := range entries {
+		if e = strings.TrimSpace(e); e != "" && !strings.HasPrefix(e, "#") {
+			drop[provenanceKey(e)] = struct{}{}
+			requested = append(requested, e)
+		}
+	}
+	if len(drop) == 0 {
+		return nil, nil, nil
+	}
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	data, err := os.ReadFile(c.listPath(allow))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, nil, err
+	}
+	lines := strings.Split(string(data), "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	var (
+		gone     []int    // 1-based lines dropped, ascending
+		goneKeys []string // exact entry on each dropped line; "" for patterns
+		b        bytes.Buffer
+	)
+	found := make(map[string]bool)
+	for i, l := range lines {
+		e := strings.TrimSpace(l)
+		if e != "" && !strings.HasPrefix(e, "#") {
+			k := provenanceKey(e)
+			if _, ok := drop[k]; ok {
+				gone = append(gone, i+1)
+				if isRuleLine(k) {
+					goneKeys = append(goneKeys, "")
+				} else {
+					goneKeys = append(goneKeys, k)
+				}
+				if !found[k] {
+					found[k] = true
+					removed = append(removed, k)
+				}
+				continue
+			}
+		}
+		b.WriteString(l)
+		b.WriteByte('\n')
+	}
+	for _, e := range requested {
+		if k := provenanceKey(e); !found[k] {
+			found[k] = true // report repeated requests once
+			missing = append(missing, e)
+		}
+	}
+	if len(removed) == 0 {
+		return nil, missing, nil
 	}
-	if inserted > 0 {
-		c.updatedAt = time.Now().UTC()
-		if !c.loaded { // mark ready if first successful patch before Load
-			c.loaded = true
+	if err := writeFileAtomic(c.listPath(allow), b.Bytes()); err != nil {
+		return nil, nil, err
+	}
+	c.written(c.listPath(allow), b.Bytes())
+
+	cur := c.current()
+	next := *cur
+	base, l := cur.list(allow), next.list(allow)
+	idx := withDelta(*base.idx)
+	for i := len(gone) - 1; i >= 0; i-- {
+		idx.removeLine(gone[i], goneKeys[i])
+	}
+	*l.idx = idx
+	*l.lines -= len(gone)
+	if next.keepsRaw() {
+		raw := make([]string, 0, len(*base.raw))
+		for i, line := range *base.raw {
+			if _, ok := slices.BinarySearch(gone, i+1); !ok {
+				raw = append(raw, line)
+			}
 		}
-		metrics.BlocklistSizeGauge.Set(float64(len(c.block)))
+		*l.raw = raw
+	}
+	*l.rules = (*base.rules).without(drop, gone)
+	// Bloom filters cannot forget keys; removed entries would stay hits.
+	if *base.filter != nil {
+		*l.filter = buildListFilter(idx, *l.rules)
 	}
-	c.mu.Unlock()
+	// The sidecars are written before the snapshot is published, so a reload
+	// never sees provenance or tombstones the running server lacks. The list
+	// file is already rewritten: their errors are returned, the removal stands.
+	for _, k := range removed {
+		if _, ok := (*base.meta)[k]; ok {
+			*l.meta = maps.Clone(*base.meta)
+			for _, k := range removed {
+				delete(*l.meta, k)
+			}
+			err = writeMetaFile(metaPath(c.listPath(allow)), *l.meta)
+			break
+		}
+	}
+	if allow {
+		next.allowSkeletons = buildSkeletons(idx)
+		next.allowTypos = buildAllowTypos(idx)
+	} else if t != nil {
+		if t.RemovedAt.IsZero() {
+			t.RemovedAt = time.Now().UTC()
+		}
+		next.blockTombstones = maps.Clone(cur.blockTombstones)
+		if next.blockTombstones == nil {
+			next.blockTombstones = make(map[string]Tombstone, len(removed))
+		}
+		for _, k := range removed {
+			next.blockTombstones[k] = *t
+		}
+		err = errors.Join(err, appendTombstoneFile(tombstonePath(c.blockPath), removed, *t, false))
+	}
+	next.updatedAt = time.Now().UTC()
+	c.publish(&next)
+	if allow {
+		metrics.AllowlistSizeGauge.Set(float64(idx.len() + (*l.rules).len()))
+	} else {
+		metrics.BlocklistSizeGauge.Set(float64(idx.len() + (*l.rules).len()))
+	}
+	return removed, missing, err
+}
+
+// RemoveAllow is RemoveBlock for the allowlist, without tombstones.
+func (c *Checker) RemoveAllow(entries []string) (removed, missing []string, err error) {
+	return c.removeList(true, entries, nil)
+}
+
+// BlockProvenance returns the recorded provenance of a blocklist entry.
+func (c *Checker) BlockProvenance(entry string) (Provenance, bool) {
+	p, ok := c.current().blockMeta[provenanceKey(entry)]
+	return p, ok
+}
+
+// BlockEntriesFrom returns the exact blocklist entries whose provenance names
+// source, sorted. Subscriptions use it to find what an upstream list
+// contributed.
+func (c *Checker) BlockEntriesFrom(source string) []string {
+	snap := c.current()
+	var out []string
+	for k, p := range snap.blockMeta {
+		if p.Source != source {
+			continue
+		}
+		if _, ok := snap.block.lookup(k); ok {
+			out = append(out, k)
+		}
+	}
+	slices.Sort(out)
+	return out
+}
+
+// AllowProvenance returns the recorded provenance of an allowlist entry.
+func (c *Checker) AllowProvenance(entry string) (Provenance, bool) {
+	p, ok := c.current().allowMeta[provenanceKey(entry)]
+	return p, ok
 }
 
-// Reads the allow/block files into memory (lowercased, trimmed) and updates indexes.
+// Reads the allow/block files into memory (lowercased, trimmed) and publishes
+// them as a new snapshot. Checks in flight keep the snapshot they started
+// with.
 func (c *Checker) Load() error {
+	return c.load(false)
+}
+
+// load builds the next snapshot from the files; with strict set it is only
+// published when validation finds no errors.
+func (c *Checker) load(strict bool) error {
 	if err := ensureFileExists(c.allowPath, "# allowlist\n"); err != nil {
 		return err
 	}
@@ -81,76 +487,122 @@ func (c *Checker) Load() error {
 		return err
 	}
 
-	allow, rawAllow, err := readListFile(c.allowPath)
-	if err != nil {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	next := &snapshot{indexMode: c.indexMode, loaded: true}
+	var err error
+	if next.allow, next.rawAllow, next.allowRules, next.allowLines, err = loadList(c.allowPath, c.indexMode); err != nil {
 		return err
 	}
-	block, rawBlock, err := readListFile(c.blockPath)
-	if err != nil {
+	if next.block, next.rawBlock, next.blockRules, next.blockLines, err = loadList(c.blockPath, c.indexMode); err != nil {
+		return err
+	}
+	if next.allowMeta, err = readMetaFile(metaPath(c.allowPath)); err != nil {
+		return err
+	}
+	if next.blockMeta, err = readMetaFile(metaPath(c.blockPath)); err != nil {
+		return err
+	}
+	if next.blockTombstones, err = readTombstoneFile(tombstonePath(c.blockPath)); err != nil {
+		return err
+	}
+	if c.rolePath != "" {
+		if err := ensureFileExists(c.rolePath, "# rolelist\n"); err != nil {
+			return err
+		}
+		if next.roles, err = readRoleFile(c.rolePath); err != nil {
+			return err
+		}
+	}
+	if next.tenants, err = c.loadTenants(); err != nil {
 		return err
 	}
-	c.mu.Lock()
-	c.allow = allow
-	c.block = block
-	c.rawAllow = rawAllow
-	c.rawBlock = rawBlock
-	c.updatedAt = time.Now().UTC()
-	c.loaded = true
-	metrics.BlocklistSizeGauge.Set(float64(len(block)))
-	metrics.AllowlistSizeGauge.Set(float64(len(allow)))
-	c.mu.Unlock()
+	next.allowSkeletons = buildSkeletons(next.allow)
+	next.allowTypos = buildAllowTypos(next.allow)
+	if next.lexical = c.lexical; !c.derived {
+		next.lexical = trainLexical(next.block, next.allow)
+	}
+	next.allowFilter = buildListFilter(next.allow, next.allowRules)
+	next.blockFilter = buildListFilter(next.block, next.blockRules)
+	next.updatedAt = time.Now().UTC()
+	var rep Report
+	if strict {
+		if rep = c.validate(next); rep.ErrorsFound {
+			return ErrValidation
+		}
+	}
+	// The MX infrastructure list is reloaded only with accepted lists. A
+	// malformed one disables the MX signal rather than failing the reload.
+	if d := c.mx.Load(); d != nil {
+		if err := d.Reload(); err != nil && d.Logger != nil {
+			d.Logger.Printf("mx: %v; detection disabled until the list is fixed", err)
+		}
+	}
+	c.publish(next)
+	if strict {
+		c.storeValidation(next, rep)
+	}
+	if c.derived {
+		return nil
+	}
+	metrics.BlocklistSizeGauge.Set(float64(next.block.len() + next.blockRules.len()))
+	metrics.AllowlistSizeGauge.Set(float64(next.allow.len() + next.allowRules.len()))
+	metrics.RolelistSizeGauge.Set(float64(next.roles.len()))
 	return nil
 }
 
 // Returns true if the checker has successfully loaded lists at least once.
 func (c *Checker) IsReady() bool {
-	c.mu.RLock()
-	ready := c.loaded && !c.updatedAt.IsZero()
-	c.mu.RUnlock()
-	return ready
+	s := c.current()
+	return s.loaded && !s.updatedAt.IsZero()
 }
 
-// Returns the number of domains currently in the blocklist map.
+// Returns the number of entries (domains and patterns) currently in the blocklist.
 func (c *Checker) BlockCount() int {
-	c.mu.RLock()
-	n := len(c.block)
-	c.mu.RUnlock()
-	return n
+	s := c.current()
+	return s.block.len() + s.blockRules.len()
 }
 
-// Returns the number of domains currently in the allowlist map.
+// Returns the number of entries (domains and patterns) currently in the allowlist.
 func (c *Checker) AllowCount() int {
-	c.mu.RLock()
-	n := len(c.allow)
-	c.mu.RUnlock()
-	return n
+	s := c.current()
+	return s.allow.len() + s.allowRules.len()
 }
 
-func readListFile(path string) (set map[string]struct{}, raw []string, err error) {
+// readListFile parses a list file into the exact-domain set (keyed by IDNA ASCII
+// form, valued by the line the entry first appears on), the raw lines (for validation) and the compiled pattern rules. Malformed patterns are skipped;
+// Validate reports them.
+func readListFile(path string) (set map[string]int, raw []string, rules *ruleSet, err error) {
+	rules = newRuleSet()
 	f, err := os.Open(path)
 	if err != nil {
 		if os.IsNotExist(err) {
-			return map[string]struct{}{}, []string{}, nil
+			return map[string]int{}, []string{}, rules, nil
 		}
-		return nil, nil, err
+		return nil, nil, nil, err
 	}
 	defer f.Close()
-	set = make(map[string]struct{})
-	s := bufio.NewScanner(f)
-	s.Buffer(make([]byte, 0, 64*1024), 10*1024*1024) // up to 10MB lines file
-	for s.Scan() {
-		line := strings.TrimSpace(s.Text())
+	set = make(map[string]int)
+	err = scanList(f, func(n int, line string) {
 		raw = append(raw, line)
 		if line == "" || strings.HasPrefix(line, "#") {
-			continue
+			return
 		}
-		l := strings.ToLower(line)
-		set[l] = struct{}{}
-	}
-	if err := s.Err(); err != nil {
-		return nil, nil, err
+		if isRuleLine(line) {
+			if r, err := parseRule(line, n); err == nil {
+				rules.add(r)
+			}
+			return
+		}
+		if key := normalizeListEntry(line); set[key] == 0 {
+			set[key] = n
+		}
+	})
+	if err != nil {
+		return nil, nil, nil, err
 	}
-	return set, raw, nil
+	rules.finish()
+	return set, raw, rules, nil
 }
 
 func ensureFileExists(path, header string) error {
@@ -171,95 +623,255 @@ type Result struct {
 	Input              string    `json:"input"`
 	Type               string    `json:"type"` // "email" or "domain"
 	ValidFormat        bool      `json:"valid_format"`
+	FormatErrors       []string  `json:"format_errors,omitempty"` // reason codes, see Reason* constants
 	LocalPart          string    `json:"local_part,omitempty"`
+	CanonicalAddress   string    `json:"canonical_address,omitempty"` // inbox identity after provider rules (dots, +tags, alias domains)
+	RoleAccount        bool      `json:"role_account"`                // local part is a role or no-reply mailbox (rolelist.conf)
+	RoleEntry          string    `json:"role_entry,omitempty"`
+	SMTPUTF8           bool      `json:"smtputf8,omitempty"`
+	DomainLiteral      bool      `json:"domain_literal,omitempty"`
 	Domain             string    `json:"domain"`
-	NormalizedDomain   string    `json:"normalized_domain"`
+	NormalizedDomain   string    `json:"normalized_domain"` // IDNA ASCII form used for lookups
+	ASCIIDomain        string    `json:"ascii_domain"`
+	UnicodeDomain      string    `json:"unicode_domain"`
+	IDNError           string    `json:"idn_error,omitempty"`
 	PublicSuffix       string    `json:"public_suffix"`
+	PrivateSuffix      bool      `json:"private_suffix"` // public suffix comes from the PSL PRIVATE section
 	RegistrableDomain  string    `json:"registrable_domain"`
 	IsPublicSuffixOnly bool      `json:"is_public_suffix_only"`
 	IsSubdomain        bool      `json:"is_subdomain"`
 	Allowlisted        bool      `json:"allowlisted"`
 	Blocklisted        bool      `json:"blocklisted"`
+	Confusable         bool      `json:"confusable"`
+	ConfusableWith     string    `json:"confusable_with,omitempty"`
+	MixedScript        bool      `json:"mixed_script"`
+	Tenant             string    `json:"tenant,omitempty"`  // overlay applied on top of the shared lists
+	Matches            []Match   `json:"matches,omitempty"` // every list entry that matched, tenant overlay first, allowlist before blocklist
+	MX                 *MXInfo   `json:"mx,omitempty"`
+	MXDisposable       bool      `json:"mx_disposable,omitempty"`
+	Suggestion         string    `json:"suggestion,omitempty"`
 	Status             string    `json:"status"` // one of: allow, block, neutral
 	CheckedAt          time.Time `json:"checked_at"`
 	UpdatedAt          time.Time `json:"lists_updated_at"`
+	PSL                PSLInfo   `json:"psl"`
+	Generation         uint64    `json:"generation"` // list snapshot the verdict was computed from
+	AsOf               *AsOf     `json:"as_of,omitempty"`
+
+	// Lexical classifier verdict on neutral domains; see SuspectDetail.
+	Suspect       bool           `json:"suspect"`
+	SuspectDetail *SuspectDetail `json:"suspect_detail,omitempty"`
+
+	// Risk score combining the signals above; see ScoreConfig.
+	Score        int           `json:"score"` // 0 (trusted) to 100 (disposable)
+	RiskLevel    string        `json:"risk_level"`
+	ScoreSignals []ScoreSignal `json:"score_signals,omitempty"`
+}
+
+// AsOf identifies the historical list state a verdict was computed from.
+type AsOf struct {
+	Requested  time.Time `json:"requested"`
+	Version    int       `json:"version"`     // list history version in effect at Requested
+	RecordedAt time.Time `json:"recorded_at"` // when that version was recorded
 }
 
 // Check accepts either an email address or bare domain. If email contains '@', it's parsed.
 func (c *Checker) Check(input string) Result {
+	return c.CheckContext(context.Background(), input)
+}
+
+// CheckContext is Check with a context bounding the optional MX lookup.
+func (c *Checker) CheckContext(ctx context.Context, input string) Result {
+	return c.CheckTenant(ctx, "", input)
+}
+
+// CheckTenant is CheckContext with the overlay lists of tenant applied on top
+// of the shared lists. An empty or unknown tenant checks the shared lists only.
+func (c *Checker) CheckTenant(ctx context.Context, tenant, input string) Result {
 	now := time.Now().UTC()
-	res := Result{Input: input, CheckedAt: now}
+	// Everything below reads this one snapshot, so the verdict, matches and
+	// timestamps always describe the same list state.
+	snap := c.current()
+	res := Result{Input: input, CheckedAt: now, Generation: snap.generation, UpdatedAt: snap.updatedAt}
 
-	// Extract domain from input
+	// Extract domain from input. Syntax problems are reported as reason codes;
+	// the best-effort domain is still evaluated against the lists.
 	var dom string
+	var quoted bool
 	if strings.Contains(input, "@") {
 		res.Type = "email"
-		addr, err := mail.ParseAddress(input)
-		if err == nil {
-			// addr.Address may include display name, ensure we take domain after '@'
-			parts := strings.Split(addr.Address, "@")
-			if len(parts) == 2 {
-				res.ValidFormat = true
-				res.LocalPart = parts[0]
-				dom = parts[1]
-			}
-		} else {
-			// Fallback simple parse
-			parts := strings.Split(input, "@")
-			if len(parts) == 2 && parts[0] != "" && parts[1] != "" {
-				res.ValidFormat = true
-				res.LocalPart = parts[0]
-				dom = parts[1]
-			}
+		addr, reasons := ParseAddress(input)
+		res.FormatErrors = reasons
+		res.LocalPart = addr.LocalPart
+		res.SMTPUTF8 = addr.SMTPUTF8
+		res.DomainLiteral = addr.DomainLiteral
+		quoted = addr.Quoted
+		dom = addr.Domain
+		if entry, _, ok := snap.roles.match(addr.LocalPart); ok {
+			res.RoleAccount = true
+			res.RoleEntry = entry
 		}
 	} else {
 		res.Type = "domain"
-		res.ValidFormat = true // domain format will be normalized below
-		dom = input
+		dom = strings.TrimSpace(input)
+		_, res.FormatErrors = validateHostname(dom)
 	}
+	res.ValidFormat = len(res.FormatErrors) == 0
 
 	dom = strings.TrimSpace(dom)
 	res.Domain = dom
-	res.NormalizedDomain = strings.ToLower(dom)
+	if res.DomainLiteral {
+		// Address literals have no public suffix and never match list entries.
+		res.NormalizedDomain = strings.ToLower(dom)
+		res.ASCIIDomain = res.NormalizedDomain
+		res.UnicodeDomain = res.NormalizedDomain
+		res.Status = "neutral"
+		res.PSL = c.suffixes().Info()
+		c.scoreConfig().applyScore(&res)
+		return res
+	}
+	ascii, uni, idnErr := normalizeDomain(strings.TrimSuffix(dom, "."))
+	res.NormalizedDomain = ascii
+	res.ASCIIDomain = ascii
+	res.UnicodeDomain = uni
+	if idnErr != nil {
+		res.IDNError = idnErr.Error()
+	}
+	res.MixedScript = hasMixedScriptLabel(uni)
+	if res.Type == "email" && res.ValidFormat {
+		res.CanonicalAddress = canonicalAddress(res.LocalPart, res.NormalizedDomain, quoted)
+	}
 
-	ps, _ := publicsuffix.PublicSuffix(res.NormalizedDomain)
-	etld1, _ := publicsuffix.EffectiveTLDPlusOne(res.NormalizedDomain)
+	// Probe the Bloom filters on the domain and its parents first: a list
+	// that definitely misses needs neither its indexes nor the eTLD+1.
+	allowMiss := snap.allowFilter != nil && !snap.allowFilter.hasSuffixOf(res.NormalizedDomain)
+	blockMiss := snap.blockFilter != nil && !snap.blockFilter.hasSuffixOf(res.NormalizedDomain)
+
+	sl := c.suffixes()
+	res.PSL = sl.Info()
+	ps, icann := sl.PublicSuffix(res.NormalizedDomain)
+	etld1, _ := etld1FromSuffix(res.NormalizedDomain, ps)
 	res.PublicSuffix = ps
+	// Unlisted TLDs also report icann=false; only multi-label suffixes are
+	// treated as PRIVATE section rules.
+	res.PrivateSuffix = !icann && strings.Contains(ps, ".")
 	res.RegistrableDomain = etld1
 	res.IsPublicSuffixOnly = (ps != "" && ps == res.NormalizedDomain)
 	res.IsSubdomain = etld1 != "" && res.NormalizedDomain != etld1
 
 	// Consider both exact domain and registrable domain (eTLD+1) for matching.
 	// This makes a list entry for example.com apply to its subdomains as well.
-	c.mu.RLock()
-	_, allowExact := c.allow[res.NormalizedDomain]
-	_, blockExact := c.block[res.NormalizedDomain]
-	allowETLD1 := false
-	blockETLD1 := false
-	if etld1 != "" {
-		if _, ok := c.allow[etld1]; ok {
-			allowETLD1 = true
-		}
-		if _, ok := c.block[etld1]; ok {
-			blockETLD1 = true
-		}
-	}
-	c.mu.RUnlock()
-	allow := allowExact || allowETLD1
-	block := blockExact || blockETLD1
-	res.Allowlisted = allow
-	res.Blocklisted = block
-	if allow {
+	// Pattern entries cover the cases eTLD+1 cannot (e.g. *.usa.cc, which
+	// spares usa.cc itself, or generated domain families).
+	var matches []Match
+	// lookup records every entry of one list covering the domain and reports
+	// whether there was any. filtered marks lists with a Bloom filter, miss a
+	// definite miss in it.
+	lookup := func(list, tenant, src string, set listIndex, rs *ruleSet, meta map[string]Provenance, filtered, miss bool) bool {
+		n := len(matches)
+		if miss {
+			// Definite miss: only regex patterns can still match.
+			metrics.BloomLookupsTotal.WithLabelValues(list, "negative").Inc()
+			for _, r := range rs.matchRegexAll(res.NormalizedDomain) {
+				matches = append(matches, Match{List: list, Tenant: tenant, Entry: r.Raw, Kind: MatchPattern, PatternKind: r.Kind, Line: r.Line, Source: src, Provenance: lookupProvenance(meta, r.Raw)})
+			}
+			return len(matches) > n
+		}
+		if line, ok := set.lookup(res.NormalizedDomain); ok {
+			matches = append(matches, Match{List: list, Tenant: tenant, Entry: res.NormalizedDomain, Kind: MatchExact, Line: line, Source: src, Provenance: lookupProvenance(meta, res.NormalizedDomain)})
+		}
+		if etld1 != "" && etld1 != res.NormalizedDomain {
+			if line, ok := set.lookup(etld1); ok {
+				matches = append(matches, Match{List: list, Tenant: tenant, Entry: etld1, Kind: MatchETLD1, Line: line, Source: src, Provenance: lookupProvenance(meta, etld1)})
+			}
+		}
+		hit := false
+		for _, r := range rs.matchAll(res.NormalizedDomain) {
+			hit = hit || r.Kind != RuleRegex
+			matches = append(matches, Match{List: list, Tenant: tenant, Entry: r.Raw, Kind: MatchPattern, PatternKind: r.Kind, Line: r.Line, Source: src, Provenance: lookupProvenance(meta, r.Raw)})
+		}
+		if filtered {
+			// A filter hit without an exact, eTLD+1 or suffix match is a false
+			// positive; regex matches are not represented in the filter.
+			result := "false_positive"
+			if hit || (len(matches) > n && matches[n].Kind != MatchPattern) {
+				result = "true_positive"
+			}
+			metrics.BloomLookupsTotal.WithLabelValues(list, result).Inc()
+		}
+		return len(matches) > n
+	}
+	var tenantAllow, tenantBlock bool
+	if ov := snap.tenants[tenant]; ov != nil {
+		res.Tenant = tenant
+		tenantAllow = lookup("allowlist", tenant, ov.allowPath, mapIndex(ov.allow), ov.allowRules, nil, false, false)
+		tenantBlock = lookup("blocklist", tenant, ov.blockPath, mapIndex(ov.block), ov.blockRules, nil, false, false)
+	}
+	allow := lookup("allowlist", "", c.allowPath, snap.allow, snap.allowRules, snap.allowMeta, snap.allowFilter != nil, allowMiss)
+	block := lookup("blocklist", "", c.blockPath, snap.block, snap.blockRules, snap.blockMeta, snap.blockFilter != nil, blockMiss)
+	res.Matches = matches
+	// Homograph signal: compare the visual skeleton of the registrable domain
+	// against the allowlist, e.g. Cyrillic "gmаil.com" imitating gmail.com.
+	// Plain ASCII names are skipped: the digit and "rn"/"vv" folds would
+	// otherwise flag ordinary domains such as corn.com or web1.de.
+	if etld1 != "" && !allow && !tenantAllow && !isPlainASCIIDomain(etld1) {
+		u := etld1
+		if _, ue, err := normalizeDomain(etld1); err == nil {
+			u = ue
+		}
+		for _, target := range snap.allowSkeletons[skeleton(u)] {
+			if target != etld1 {
+				res.Confusable = true
+				res.ConfusableWith = target
+				break
+			}
+		}
+	}
+	res.Allowlisted = allow || tenantAllow
+	res.Blocklisted = block || tenantBlock
+	// Tenant overlays take precedence over the shared lists, and within each
+	// layer allow wins over block.
+	switch {
+	case tenantAllow:
 		res.Status = "allow"
-	} else if block {
+	case tenantBlock:
 		res.Status = "block"
-	} else {
+	case allow:
+		res.Status = "allow"
+	case block:
+		res.Status = "block"
+	default:
 		res.Status = "neutral"
 	}
-
-	c.mu.RLock()
-	res.UpdatedAt = c.updatedAt
-	c.mu.RUnlock()
+	// Fresh domains of known providers are caught through the mail servers
+	// they share. Only otherwise undecided, well-formed domains are resolved.
+	if d := c.mx.Load(); d != nil && d.Enabled() && !mxSkipped(ctx) && res.Status == "neutral" && res.ValidFormat && !res.IsPublicSuffixOnly {
+		info := d.Lookup(ctx, res.NormalizedDomain)
+		res.MX = &info
+		if info.Disposable {
+			res.MXDisposable = true
+			res.Status = "block"
+			res.Matches = append(res.Matches, Match{List: "mx_infrastructure", Entry: info.MatchedEntry, Kind: MatchMX, Source: d.Path})
+		}
+	}
+	// "Did you mean": domains a keystroke away from a mailbox provider or
+	// allowlisted domain are most likely typos. Blocked ones are included as
+	// typo-squatting domains end up on the blocklist.
+	if tp := c.typoProviders(); !tp.off && res.Status != "allow" && res.ValidFormat && !res.IsPublicSuffixOnly {
+		if s := suggestDomain(res.NormalizedDomain, tp.candidates, snap.allowTypos); s != "" {
+			res.Suggestion = s
+			if res.Type == "email" {
+				res.Suggestion = res.LocalPart + "@" + s
+			}
+		}
+	}
+	// Unlisted domains are rated by the classifier trained on the lists.
+	if m := snap.lexical; m != nil && res.Status == "neutral" && res.ValidFormat && !res.IsPublicSuffixOnly {
+		if label := registrableLabel(&res); label != "" && !strings.HasPrefix(label, "xn--") {
+			tld := res.PublicSuffix[strings.LastIndexByte(res.PublicSuffix, '.')+1:]
+			res.SuspectDetail, res.Suspect = m.assess(label, tld, c.suspect.load())
+		}
+	}
+	c.scoreConfig().applyScore(&res)
 	return res
 }
 
@@ -275,34 +887,54 @@ type Report struct {
 	UnsortedAllowHint   string    `json:"unsorted_allowlist_hint,omitempty"`
 	UnsortedBlockHint   string    `json:"unsorted_blocklist_hint,omitempty"`
 	Intersection        []string  `json:"intersection_between_lists"`
+	MalformedPatterns   []string  `json:"malformed_patterns"`
+	BroadPatterns       []string  `json:"overly_broad_patterns"`
+	BroadAllowPatterns  []string  `json:"overly_broad_allowlist_patterns,omitempty"` // warnings only
+	NonLowercaseRole    []string  `json:"non_lowercase_in_rolelist"`
+	DuplicatesRole      []string  `json:"duplicates_in_rolelist"`
+	InvalidRole         []string  `json:"invalid_rolelist_entries"`
 	CheckedAt           time.Time `json:"checked_at"`
-}
-
-func (c *Checker) Validate() Report {
-	c.mu.RLock()
-	rawA := append([]string(nil), c.rawAllow...)
-	rawB := append([]string(nil), c.rawBlock...)
-	c.mu.RUnlock()
+	PSL                 PSLInfo   `json:"psl"`
+	Generation          uint64    `json:"generation"`          // list generation the report describes
+	IncrementalUpdates  int       `json:"incremental_updates"` // blocklist patches folded in since the full validation
+	Cached              bool      `json:"cached"`
+	AgeSeconds          float64   `json:"age_seconds"` // since checked_at
 
-	rep := Report{CheckedAt: time.Now().UTC()}
+	// last blocklist line, for the sorting hint of patched entries
+	lastBlock string
+}
 
-	isSorted := func(lines []string) (bool, string) {
-		sorted := append([]string(nil), lines...)
-		sort.Strings(sorted)
-		for i := 0; i < len(lines) && i < len(sorted); i++ {
-			if lines[i] != sorted[i] {
-				return false, sorted[i] + " should come before " + lines[i]
-			}
+// sortHint names the first line out of sorted order, or returns "" when the
+// lines are sorted.
+func sortHint(lines []string) string {
+	sorted := append([]string(nil), lines...)
+	sort.Strings(sorted)
+	for i := range lines {
+		if lines[i] != sorted[i] {
+			return sorted[i] + " should come before " + lines[i]
 		}
-		return true, ""
+	}
+	return ""
+}
+
+func (c *Checker) validate(snap *snapshot) Report {
+	rawA, rawB := snap.rawAllow, snap.rawBlock
+	if !snap.keepsRaw() {
+		// Compact indexes do not keep the raw lines; patches are appended to
+		// the files before they are applied, so the files are current.
+		rawA, _ = readListLines(c.allowPath)
+		rawB, _ = readListLines(c.blockPath)
 	}
 
+	sl := c.suffixes()
+	rep := Report{CheckedAt: time.Now().UTC(), PSL: sl.Info()}
+
 	// Lowercase violations and duplicates for each list (on non-empty, non-comment lines)
 	lowerViol := func(lines []string) []string {
 		m := make(map[string]struct{})
 		var out []string
 		for _, l := range lines {
-			if l == "" || strings.HasPrefix(l, "#") {
+			if l == "" || strings.HasPrefix(l, "#") || strings.HasPrefix(l, "/") { // regex bodies may be case-sensitive
 				continue
 			}
 			if l != strings.ToLower(l) {
@@ -335,41 +967,54 @@ func (c *Checker) Validate() Report {
 	var thirdLevel []string
 	for _, l := range rawB {
 		line := strings.TrimSpace(l)
-		if line == "" || strings.HasPrefix(line, "#") {
+		if line == "" || strings.HasPrefix(line, "#") || isRuleLine(line) {
 			continue
 		}
-		d := strings.ToLower(line)
-		ps, _ := publicsuffix.PublicSuffix(d)
-		if ps == d {
+		psOnly, third := blockEntryFindings(sl, line)
+		if psOnly {
 			publicSuffixOnly = append(publicSuffixOnly, line)
 		}
-		if etld1, _ := publicsuffix.EffectiveTLDPlusOne(d); etld1 != "" && etld1 != d {
+		if third {
 			thirdLevel = append(thirdLevel, line)
 		}
 	}
 
-	// Intersections
-	c.mu.RLock()
-	allowSet := make(map[string]struct{}, len(c.allow))
-	for k := range c.allow {
-		allowSet[k] = struct{}{}
+	// Pattern lint: malformed syntax and patterns that would swallow a whole
+	// public suffix or well-known mailbox providers. Allowlist patterns are
+	// meant to match providers, so only whole public suffixes are reported
+	// for them, and only as warnings.
+	var malformed, broad, broadAllow []string
+	lintPatterns := func(list string, lines []string, probes []string, broad *[]string) {
+		for i, l := range lines {
+			if l == "" || strings.HasPrefix(l, "#") || !isRuleLine(l) {
+				continue
+			}
+			where := list + " line " + strconv.Itoa(i+1) + ": " + l + ": "
+			r, err := parseRule(l, i+1)
+			if err != nil {
+				malformed = append(malformed, where+err.Error())
+				continue
+			}
+			if why := ruleTooBroad(r, sl, probes); why != "" {
+				*broad = append(*broad, where+why)
+			}
+		}
 	}
+	lintPatterns("allowlist", rawA, nil, &broadAllow)
+	lintPatterns("blocklist", rawB, blockRuleProbes(snap), &broad)
+
+	// Intersections
 	var inter []string
-	for k := range c.block {
-		if _, ok := allowSet[k]; ok {
+	snap.allow.each(func(k string, _ int) {
+		if _, ok := snap.block.lookup(k); ok {
 			inter = append(inter, k)
 		}
-	}
-	c.mu.RUnlock()
+	})
 	sort.Strings(inter)
 
 	// Sorted hints (non-fatal)
-	if ok, hint := isSorted(rawA); !ok {
-		rep.UnsortedAllowHint = hint
-	}
-	if ok, hint := isSorted(rawB); !ok {
-		rep.UnsortedBlockHint = hint
-	}
+	rep.UnsortedAllowHint = sortHint(rawA)
+	rep.UnsortedBlockHint = sortHint(rawB)
 
 	rep.PublicSuffixInBlock = publicSuffixOnly
 	rep.ThirdLevelInBlock = thirdLevel
@@ -378,24 +1023,85 @@ func (c *Checker) Validate() Report {
 	rep.DuplicatesAllow = dupes(rawA)
 	rep.DuplicatesBlock = dupes(rawB)
 	rep.Intersection = inter
+	rep.MalformedPatterns = malformed
+	rep.BroadPatterns = broad
+	rep.BroadAllowPatterns = broadAllow
+	if snap.roles != nil {
+		rep.NonLowercaseRole = lowerViol(snap.roles.raw)
+		// Entries folding to the same key (no-reply, noreply) are duplicates.
+		var folded []string
+		for _, l := range snap.roles.raw {
+			if l != "" && !strings.HasPrefix(l, "#") {
+				key, prefix := strings.CutSuffix(l, "*")
+				key = roleKey(key)
+				if prefix {
+					key += "*"
+				}
+				folded = append(folded, key)
+			}
+		}
+		rep.DuplicatesRole = dupes(folded)
+		rep.InvalidRole = snap.roles.invalidEntries()
+	}
 
-	if len(rep.PublicSuffixInBlock) > 0 || len(rep.NonLowercaseAllow) > 0 || len(rep.NonLowercaseBlock) > 0 || len(rep.DuplicatesAllow) > 0 || len(rep.DuplicatesBlock) > 0 || len(rep.Intersection) > 0 {
-		rep.ErrorsFound = true
+	if len(rawB) > 0 {
+		rep.lastBlock = rawB[len(rawB)-1]
 	}
+	rep.Generation = snap.generation
+	rep.setErrorsFound()
 	return rep
 }
 
-// Convenience that calls Load and returns an error if validation has fatal issues when strict is true.
-func (c *Checker) Reload(strict bool) error {
-	if err := c.Load(); err != nil {
-		return err
-	}
-	if !strict {
-		return nil
+func (rep *Report) setErrorsFound() {
+	rep.ErrorsFound = len(rep.PublicSuffixInBlock) > 0 || len(rep.NonLowercaseAllow) > 0 || len(rep.NonLowercaseBlock) > 0 || len(rep.DuplicatesAllow) > 0 || len(rep.DuplicatesBlock) > 0 || len(rep.Intersection) > 0 || len(rep.MalformedPatterns) > 0 || len(rep.BroadPatterns) > 0 ||
+		len(rep.NonLowercaseRole) > 0 || len(rep.DuplicatesRole) > 0 || len(rep.InvalidRole) > 0
+}
+
+// blockEntryFindings reports whether a blocklist domain entry is a bare
+// public suffix or lies below its registrable domain.
+func blockEntryFindings(sl suffixList, line string) (publicSuffixOnly, thirdLevel bool) {
+	d := strings.ToLower(line)
+	ps, _ := sl.PublicSuffix(d)
+	etld1, _ := sl.EffectiveTLDPlusOne(d)
+	return ps == d, etld1 != "" && etld1 != d
+}
+
+// blockRuleProbes are the domains blocklist patterns must not match: the
+// well-known providers and every exact allowlist entry.
+func blockRuleProbes(snap *snapshot) []string {
+	probes := append([]string(nil), broadRuleProbes...)
+	snap.allow.each(func(k string, _ int) {
+		probes = append(probes, k)
+	})
+	sort.Strings(probes[len(broadRuleProbes):])
+	return probes
+}
+
+// broadRuleProbes are ordinary registrable domains no pattern should match.
+var broadRuleProbes = []string{"example.com", "example.net", "example.org", "example.co.uk", "gmail.com", "outlook.com", "yahoo.com"}
+
+// ruleTooBroad returns a reason when r covers an entire public suffix or any
+// of the probe domains, and "" otherwise.
+func ruleTooBroad(r Rule, sl suffixList, probes []string) string {
+	if r.Kind != RuleRegex {
+		if ps, _ := sl.PublicSuffix(r.Suffix); ps == r.Suffix {
+			return "covers entire public suffix " + ps
+		}
 	}
-	rep := c.Validate()
-	if rep.ErrorsFound {
-		return errors.New("list validation errors present")
+	for _, p := range probes {
+		if r.Matches(p) {
+			return "matches " + p
+		}
 	}
-	return nil
+	return ""
+}
+
+// ErrValidation is returned by a strict Reload when the lists on disk fail
+// validation; the previous snapshot stays in place.
+var ErrValidation = errors.New("list validation errors present")
+
+// Convenience that calls Load; when strict is true the files are validated
+// first and the current lists are kept if validation has fatal issues.
+func (c *Checker) Reload(strict bool) error {
+	return c.load(strict)
 }
@@ -39,14 +39,12 @@ func TestPatchBlock(t *testing.T) {
 		t.Fatalf("expected quux.org blocklisted (case-normalized)")
 	}
 	// Ensure duplicate didn't create multiple raw entries for bar.com by counting occurrences.
-	c.mu.RLock()
 	countBar := 0
-	for _, raw := range c.rawBlock {
+	for _, raw := range c.current().rawBlock {
 		if raw == "bar.com" {
 			countBar++
 		}
 	}
-	c.mu.RUnlock()
 	if countBar != 1 {
 		t.Fatalf("expected single bar.com in rawBlock, got %d", countBar)
 	}
@@ -0,0 +1,142 @@
+package domain
+
+import (
+	"sort"
+	"strings"
+)
+
+// Conflict kinds reported in AllowConflict.Kind, seen from the new entry.
+const (
+	ConflictExact     = "exact"     // the same domain is on the other list
+	ConflictParent    = "parent"    // the registrable domain of the entry is on the other list
+	ConflictSubdomain = "subdomain" // a domain on the other list lies below the (registrable) entry
+	ConflictPattern   = "pattern"   // a pattern covers the domain or its subdomains
+)
+
+// AllowConflict pairs an allowlist entry with a blocklist entry it
+// overrides, since allow wins over block.
+type AllowConflict struct {
+	Entry      string      `json:"entry"`   // allowlist entry
+	Blocked    string      `json:"blocked"` // blocklist entry or pattern
+	Kind       string      `json:"kind"`
+	Line       int         `json:"line"` // of the entry already listed
+	Provenance *Provenance `json:"provenance,omitempty"`
+}
+
+// AllowConflicts reports the blocklist entries the given allowlist entries
+// override in the current lists: entries covering the domain itself and, for
+// registrable domains (whose allowlisting covers all their subdomains),
+// entries below it. Pattern entries conflict with every blocklisted domain
+// they match.
+func (c *Checker) AllowConflicts(entries []string) []AllowConflict {
+	snap := c.current()
+	return c.conflicts(entries, snap.block, snap.blockRules, snap.blockMeta, false)
+}
+
+// BlockConflicts is the converse of AllowConflicts: it reports the allowlist
+// entries that would override the given blocklist entries.
+func (c *Checker) BlockConflicts(entries []string) []AllowConflict {
+	snap := c.current()
+	return c.conflicts(entries, snap.allow, snap.allowRules, snap.allowMeta, true)
+}
+
+// conflicts matches new entries against the other list (idx, rules, meta).
+// block says the new entries are blocklist entries.
+func (c *Checker) conflicts(entries []string, idx listIndex, rules *ruleSet, meta map[string]Provenance, block bool) []AllowConflict {
+	sl := c.suffixes()
+	var out []AllowConflict
+	seen := make(map[[2]string]bool)
+	add := func(entry, listed, kind string, line int) {
+		if k := [2]string{entry, listed}; !seen[k] {
+			seen[k] = true
+			cf := AllowConflict{entry, listed, kind, line, lookupProvenance(meta, listed)}
+			if block {
+				cf.Entry, cf.Blocked = listed, entry
+			}
+			out = append(out, cf)
+		}
+	}
+	var patterns []Rule
+	registrable := make(map[string]bool)
+	for _, e := range entries {
+		e = strings.TrimSpace(e)
+		if e == "" || strings.HasPrefix(e, "#") {
+			continue
+		}
+		if isRuleLine(e) {
+			if r, err := parseRule(e, 0); err == nil {
+				patterns = append(patterns, r)
+			}
+			continue
+		}
+		d := normalizeListEntry(e)
+		if line, ok := idx.lookup(d); ok {
+			add(d, d, ConflictExact, line)
+		}
+		etld1, _ := sl.EffectiveTLDPlusOne(d)
+		if etld1 != "" && etld1 != d {
+			if line, ok := idx.lookup(etld1); ok {
+				add(d, etld1, ConflictParent, line)
+			}
+		}
+		for _, r := range rules.matchAll(d) {
+			add(d, r.Raw, ConflictPattern, r.Line)
+		}
+		if etld1 == d {
+			registrable[d] = true
+		}
+	}
+	if len(registrable) > 0 || len(patterns) > 0 {
+		// under returns the registrable entry d lies strictly below, if any.
+		under := func(d string) string {
+			for s := d; ; {
+				dot := strings.IndexByte(s, '.')
+				if dot == -1 {
+					return ""
+				}
+				if s = s[dot+1:]; registrable[s] {
+					return s
+				}
+			}
+		}
+		idx.each(func(k string, line int) {
+			if r := under(k); r != "" {
+				add(r, k, ConflictSubdomain, line)
+			}
+			for _, p := range patterns {
+				if p.Matches(k) {
+					add(p.Raw, k, ConflictPattern, line)
+				}
+			}
+		})
+		if rules != nil {
+			for _, r := range rules.rules {
+				if r.Kind == RuleRegex {
+					continue
+				}
+				// *.d covers only subdomains of d, so it is not matched above.
+				if registrable[r.Suffix] {
+					add(r.Suffix, r.Raw, ConflictPattern, r.Line)
+				} else if d := under(r.Suffix); d != "" {
+					add(d, r.Raw, ConflictPattern, r.Line)
+				}
+			}
+		}
+	}
+	// Sorted by the new entries.
+	key := func(cf AllowConflict) (string, string) {
+		if block {
+			return cf.Blocked, cf.Entry
+		}
+		return cf.Entry, cf.Blocked
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		ai, bi := key(out[i])
+		aj, bj := key(out[j])
+		if ai != aj {
+			return ai < aj
+		}
+		return bi < bj
+	})
+	return out
+}
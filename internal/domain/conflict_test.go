@@ -0,0 +1,100 @@
+package domain
+
+import (
+	"maps"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestAllowConflicts(t *testing.T) {
+	dir := t.TempDir()
+	allowPath := filepath.Join(dir, "allowlist.conf")
+	blockPath := filepath.Join(dir, "blocklist.conf")
+	writeTempList(t, allowPath, []string{"gmail.com"})
+	writeTempList(t, blockPath, []string{"example.com", "mail.other.org", "*.other.org", "||ads.net^", "spam.io", "x1.io", "/^x\\d+\\.io$/"})
+	c := NewChecker(allowPath, blockPath)
+	if err := c.Load(); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+
+	got := make(map[string]string)
+	for _, cf := range c.AllowConflicts([]string{"Example.com", "sub.example.com", "other.org", "tracker.ads.net", "/^x1\\.io$/", "clean.net"}) {
+		got[cf.Entry+" "+cf.Blocked] = cf.Kind
+	}
+	want := map[string]string{
+		"example.com example.com":     ConflictExact,
+		"sub.example.com example.com": ConflictParent,
+		"other.org mail.other.org":    ConflictSubdomain,
+		"other.org *.other.org":       ConflictPattern,
+		"tracker.ads.net ||ads.net^":  ConflictPattern,
+		"/^x1\\.io$/ x1.io":           ConflictPattern,
+	}
+	if !maps.Equal(got, want) {
+		t.Fatalf("unexpected conflicts:\n got %v\nwant %v", got, want)
+	}
+}
+
+func TestPatchAndRemoveAllow(t *testing.T) {
+	dir := t.TempDir()
+	allowPath := filepath.Join(dir, "allowlist.conf")
+	blockPath := filepath.Join(dir, "blocklist.conf")
+	writeTempList(t, allowPath, []string{"# allowlist", "gmail.com", "yahoo.com"})
+	writeTempList(t, blockPath, []string{"example.com"})
+	c := NewChecker(allowPath, blockPath)
+	if err := c.Load(); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	f, _ := os.OpenFile(allowPath, os.O_APPEND|os.O_WRONLY, 0o644)
+	_, _ = f.WriteString("example.com\n")
+	_ = f.Close()
+	if err := c.PatchAllowFrom([]string{"example.com"}, Provenance{Source: SourceManual}); err != nil {
+		t.Fatal(err)
+	}
+	res := c.Check("example.com")
+	if res.Status != "allow" || res.Matches[0].Line != 4 || res.Matches[0].Provenance == nil {
+		t.Fatalf("expected example.com allowlisted on line 4 with provenance: %+v", res.Matches)
+	}
+	if res := c.Check("exampIe.com"); res.Suggestion != "example.com" {
+		t.Fatalf("expected typo suggestion from the patched entry, got %q", res.Suggestion)
+	}
+
+	removed, missing, err := c.RemoveAllow([]string{"gmail.com", "nope.com"})
+	if err != nil || len(removed) != 1 || len(missing) != 1 {
+		t.Fatalf("unexpected removal: %v %v %v", removed, missing, err)
+	}
+	if c.Check("gmail.com").Allowlisted || c.AllowCount() != 2 {
+		t.Fatal("expected gmail.com removed")
+	}
+	if _, ok := c.BlockTombstone("gmail.com"); ok {
+		t.Fatal("allowlist removals must not tombstone")
+	}
+	if res := c.Check("example.com"); res.Matches[0].Line != 3 {
+		t.Fatalf("expected example.com renumbered to line 3, got %+v", res.Matches)
+	}
+}
+
+func TestBlockConflicts(t *testing.T) {
+	dir := t.TempDir()
+	allowPath := filepath.Join(dir, "allowlist.conf")
+	blockPath := filepath.Join(dir, "blocklist.conf")
+	writeTempList(t, allowPath, []string{"gmail.com", "mail.corp.org", "*.partner.net"})
+	writeTempList(t, blockPath, []string{"spam.io"})
+	c := NewChecker(allowPath, blockPath)
+	if err := c.Load(); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+
+	got := make(map[string]string)
+	for _, cf := range c.BlockConflicts([]string{"gmail.com", "corp.org", "x.partner.net", "new.io"}) {
+		got[cf.Blocked+" "+cf.Entry] = cf.Kind
+	}
+	want := map[string]string{
+		"gmail.com gmail.com":         ConflictExact,
+		"corp.org mail.corp.org":      ConflictSubdomain,
+		"x.partner.net *.partner.net": ConflictPattern,
+	}
+	if !maps.Equal(got, want) {
+		t.Fatalf("unexpected conflicts:\n got %v\nwant %v", got, want)
+	}
+}
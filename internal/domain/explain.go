@@ -0,0 +1,170 @@
+package domain
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Match kinds reported in Result.Matches.
+const (
+	MatchExact   = "exact"   // list entry equals the normalized domain
+	MatchETLD1   = "etld1"   // list entry equals the registrable domain (eTLD+1)
+	MatchPattern = "pattern" // *.suffix, ||domain^ or /regex/ entry
+	MatchMX      = "mx"      // exchanger listed in the MX infrastructure list
+)
+
+// Match is a single list entry that applied to a checked domain.
+type Match struct {
+	List        string      `json:"list"`             // "allowlist", "blocklist" or "mx_infrastructure"
+	Tenant      string      `json:"tenant,omitempty"` // set for tenant overlay entries
+	Entry       string      `json:"entry"`
+	Kind        string      `json:"kind"`
+	PatternKind RuleKind    `json:"pattern_kind,omitempty"`
+	Line        int         `json:"line,omitempty"`   // 1-based line in Source
+	Source      string      `json:"source,omitempty"` // list file the entry was loaded from
+	Provenance  *Provenance `json:"provenance,omitempty"`
+}
+
+// Explain walks through how the result was reached, one step per line, in
+// the order Check evaluates the input.
+func (r Result) Explain() []string {
+	var steps []string
+	add := func(s string) { steps = append(steps, s) }
+
+	if r.Type == "email" {
+		add("parsed " + strconv.Quote(r.Input) + " as an email address; domain " + strconv.Quote(r.Domain))
+	} else {
+		add("treated " + strconv.Quote(r.Input) + " as a domain")
+	}
+	if r.RoleAccount {
+		add("local part " + strconv.Quote(r.LocalPart) + " is a role account (rolelist entry " + r.RoleEntry + "); the status only reflects the domain")
+	}
+	if len(r.FormatErrors) > 0 {
+		add("syntax problems: " + strings.Join(r.FormatErrors, ", ") + " (lists are still consulted for the best-effort domain)")
+	}
+	if r.DomainLiteral {
+		add("address literals are never list-matched; status " + r.Status)
+		return steps
+	}
+	norm := "normalized to " + r.NormalizedDomain
+	if r.UnicodeDomain != r.ASCIIDomain {
+		norm += " (displayed as " + r.UnicodeDomain + ")"
+	}
+	add(norm)
+	if r.IDNError != "" {
+		add("IDNA mapping failed (" + r.IDNError + "); matched on the lowercased input")
+	}
+	psl := r.PSL.Source
+	if r.PSL.Version != "" {
+		psl += " " + r.PSL.Version
+	}
+	switch {
+	case r.IsPublicSuffixOnly:
+		add("the domain is itself a public suffix (PSL " + psl + ")")
+	case r.RegistrableDomain != "":
+		add("public suffix " + r.PublicSuffix + ", registrable domain " + r.RegistrableDomain + " (PSL " + psl + ")")
+	default:
+		add("no registrable domain (PSL " + psl + ")")
+	}
+
+	if len(r.Matches) == 0 {
+		add("no allowlist or blocklist entry matches")
+	}
+	for _, m := range r.Matches {
+		add(describeMatch(m, r))
+	}
+	if r.Confusable {
+		add("looks like allowlisted " + r.ConfusableWith + " (signal only)")
+	}
+
+	tenantAllow, tenantBlock := false, false
+	for _, m := range r.Matches {
+		if m.Tenant != "" {
+			tenantAllow = tenantAllow || m.List == "allowlist"
+			tenantBlock = tenantBlock || m.List == "blocklist"
+		}
+	}
+	switch {
+	case tenantAllow:
+		add("tenant " + r.Tenant + " allowlist takes precedence over all other lists: status allow")
+	case tenantBlock && r.Allowlisted:
+		add("tenant " + r.Tenant + " blocklist overrides the shared allowlist: status block")
+	case tenantBlock:
+		add("blocklisted by tenant " + r.Tenant + ": status block")
+	case r.Allowlisted && r.Blocklisted:
+		add("allowlist takes precedence over blocklist: status allow")
+	case r.Allowlisted:
+		add("allowlisted: status allow")
+	case r.Blocklisted:
+		add("blocklisted: status block")
+	case r.MXDisposable:
+		add("mail is handled by disposable infrastructure: status block")
+	default:
+		if r.MX != nil {
+			switch {
+			case r.MX.Error != "":
+				add("MX lookup failed (" + r.MX.Error + ")")
+			case r.MX.NullMX:
+				add("domain publishes a null MX and accepts no mail")
+			default:
+				add("mail exchangers " + strings.Join(r.MX.Hosts, ", ") + " are not known disposable infrastructure")
+			}
+		}
+		add("no rule applies: status neutral")
+		if d := r.SuspectDetail; d != nil {
+			p := strconv.FormatFloat(d.Probability, 'f', -1, 64)
+			if r.Suspect {
+				add("lexical classifier rates it disposable with probability " + p + " (" + d.summary() + "; signal only)")
+			} else {
+				add("lexical classifier probability " + p + " is below the suspect threshold")
+			}
+		}
+	}
+	if r.RiskLevel != "" {
+		add("risk score " + strconv.Itoa(r.Score) + "/100 (" + r.RiskLevel + ")")
+	}
+	return steps
+}
+
+func describeMatch(m Match, r Result) string {
+	var b strings.Builder
+	if m.Tenant != "" {
+		b.WriteString("tenant " + m.Tenant + " ")
+	}
+	b.WriteString(m.List)
+	if m.Line > 0 {
+		b.WriteString(" line " + strconv.Itoa(m.Line))
+	}
+	if m.Source != "" {
+		b.WriteString(" (" + m.Source + ")")
+	}
+	b.WriteString(": ")
+	switch m.Kind {
+	case MatchExact:
+		b.WriteString(m.Entry + " matches the domain exactly")
+	case MatchETLD1:
+		b.WriteString(m.Entry + " matches the registrable domain, covering its subdomains")
+	case MatchPattern:
+		b.WriteString(string(m.PatternKind) + " pattern " + m.Entry + " matches " + r.NormalizedDomain)
+	case MatchMX:
+		if r.MX != nil && r.MX.MatchedHost != "" {
+			via := r.MX.MatchedHost
+			if r.MX.MatchedIP != "" {
+				via += " [" + r.MX.MatchedIP + "]"
+			}
+			b.WriteString("exchanger " + via + " matches " + m.Entry)
+		} else {
+			b.WriteString("exchanger matches " + m.Entry)
+		}
+	default:
+		b.WriteString(m.Entry)
+	}
+	if p := m.Provenance; p != nil {
+		b.WriteString("; added " + p.AddedAt.Format(time.RFC3339) + " from " + p.Source)
+		if p.Actor != "" {
+			b.WriteString(" by " + p.Actor)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,56 @@
+package domain
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCheckMatches(t *testing.T) {
+	dir := t.TempDir()
+	allowPath := filepath.Join(dir, "allowlist.conf")
+	blockPath := filepath.Join(dir, "blocklist.conf")
+	writeTempList(t, allowPath, []string{"# allow", "good.com"})
+	writeTempList(t, blockPath, []string{"# block", "", "bad.com", "*.bad.com", "good.com", "x.spam.net", "/^x\\..*$/"})
+	c := NewChecker(allowPath, blockPath)
+	if err := c.Load(); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+
+	res := c.Check("a.bad.com")
+	want := []Match{
+		{List: "blocklist", Entry: "bad.com", Kind: MatchETLD1, Line: 3, Source: blockPath},
+		{List: "blocklist", Entry: "*.bad.com", Kind: MatchPattern, PatternKind: RuleSubdomains, Line: 4, Source: blockPath},
+	}
+	if len(res.Matches) != len(want) {
+		t.Fatalf("expected %d matches, got %+v", len(want), res.Matches)
+	}
+	for i := range want {
+		if res.Matches[i] != want[i] {
+			t.Errorf("match %d = %+v, want %+v", i, res.Matches[i], want[i])
+		}
+	}
+
+	res = c.Check("x.spam.net")
+	if len(res.Matches) != 2 || res.Matches[0].Kind != MatchExact || res.Matches[0].Line != 6 || res.Matches[1].PatternKind != RuleRegex || res.Matches[1].Line != 7 {
+		t.Fatalf("unexpected matches for x.spam.net: %+v", res.Matches)
+	}
+
+	res = c.Check("user@good.com")
+	if res.Status != "allow" || len(res.Matches) != 2 || res.Matches[0].List != "allowlist" || res.Matches[0].Line != 2 || res.Matches[1].Line != 5 {
+		t.Fatalf("unexpected matches for good.com: %+v", res.Matches)
+	}
+	steps := strings.Join(res.Explain(), "\n")
+	if !strings.Contains(steps, "blocklist line 5") || !strings.Contains(steps, "allowlist takes precedence") {
+		t.Fatalf("explanation misses decision steps:\n%s", steps)
+	}
+
+	// Entries appended at runtime report the line they were appended at.
+	c.PatchBlock([]string{"new.org"})
+	if res = c.Check("new.org"); len(res.Matches) != 1 || res.Matches[0].Line != 8 {
+		t.Fatalf("unexpected matches after patch: %+v", res.Matches)
+	}
+	if res = c.Check("neutral.io"); res.Matches != nil || !strings.Contains(strings.Join(res.Explain(), "\n"), "status neutral") {
+		t.Fatalf("expected no matches for neutral domain: %+v", res.Matches)
+	}
+}
@@ -0,0 +1,386 @@
+package domain
+
+import (
+	"bytes"
+	"maps"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Fix actions reported in FixChange.Action.
+const (
+	FixLowercased   = "lowercased"
+	FixNormalized   = "normalized"            // domain mapped to its IDNA ASCII form
+	FixReduced      = "reduced"               // blocklist entry below its registrable domain replaced by the eTLD+1 (opt-in)
+	FixReducible    = "reducible"             // suggestion only: FixReduced without the opt-in
+	FixCovered      = "removed_covered"       // blocklist entry whose parent domain is listed too
+	FixDuplicate    = "removed_duplicate"     // entry already present earlier in the file
+	FixPublicSuffix = "removed_public_suffix" // blocklist entry is itself a public suffix
+	FixAllowlisted  = "removed_allowlisted"   // blocklist entry is also allowlisted (allow wins anyway)
+)
+
+// maxFixDiffLines caps ListFix.Diff; the changes stay complete.
+const maxFixDiffLines = 2000
+
+// FixChange is one entry rewritten or removed by FixLists.
+type FixChange struct {
+	Line   int    `json:"line"` // 1-based line in the original file
+	Entry  string `json:"entry"`
+	Action string `json:"action"`
+	Result string `json:"result,omitempty"` // entry after the fix; empty when removed
+}
+
+// ListFix is the fixed form of one list file.
+type ListFix struct {
+	List        string      `json:"list"` // "allowlist" or "blocklist"
+	Path        string      `json:"path"`
+	Changed     bool        `json:"changed"`
+	Changes     []FixChange `json:"changes"`
+	Suggestions []FixChange `json:"suggestions"` // reductions that would widen a block, not applied
+	Reordered   int         `json:"reordered"`   // lines moved by sorting
+	LinesBefore int         `json:"lines_before"`
+	LinesAfter  int         `json:"lines_after"`
+	Diff        string      `json:"diff,omitempty"` // unified diff, capped at maxFixDiffLines lines
+
+	content []byte
+}
+
+// FixReport is the outcome of FixLists.
+type FixReport struct {
+	Applied    bool    `json:"applied"`
+	Allowlist  ListFix `json:"allowlist"`
+	Blocklist  ListFix `json:"blocklist"`
+	Generation uint64  `json:"generation"` // list generation after the reload when applied
+}
+
+// FixLists resolves the mechanical Validate findings in the list files:
+// entries are normalized like Load reads them (domains to their IDNA ASCII
+// form, patterns lowercased, regex bodies kept) and deduplicated, and each
+// run of entries between comments and blank lines is sorted, so comments and
+// sections stay where they are. In the blocklist, public-suffix-only entries,
+// entries that are also allowlisted and entries below a listed parent domain
+// are dropped. Fixes never widen a block: other entries below their
+// registrable domain are only reported as suggestions, and reduced to it
+// with reduce set. Pattern findings are left alone.
+//
+// With apply set, the changed lists and their provenance sidecars, which
+// lose the records of dropped entries, are replaced together (see
+// replaceFiles) and the lists are reloaded. Callers serialize FixLists with
+// other writers of the files.
+func (c *Checker) FixLists(apply, reduce bool) (FixReport, error) {
+	allowLines, err := readListLines(c.allowPath)
+	if err != nil {
+		return FixReport{}, err
+	}
+	blockLines, err := readListLines(c.blockPath)
+	if err != nil {
+		return FixReport{}, err
+	}
+	sl := c.suffixes()
+	rep := FixReport{
+		Allowlist: fixList("allowlist", c.allowPath, allowLines, nil, nil, false),
+	}
+	allowed := make(map[string]bool)
+	for _, l := range strings.Split(string(rep.Allowlist.content), "\n") {
+		if l != "" && !strings.HasPrefix(l, "#") && !isRuleLine(l) {
+			allowed[l] = true
+		}
+	}
+	rep.Blocklist = fixList("blocklist", c.blockPath, blockLines, sl, allowed, reduce)
+	if !apply {
+		rep.Generation = c.Generation()
+		return rep, nil
+	}
+	var files []fileWrite
+	for _, f := range []ListFix{rep.Allowlist, rep.Blocklist} {
+		if !f.Changed {
+			continue
+		}
+		files = append(files, fileWrite{f.Path, f.content})
+		meta, err := readMetaFile(metaPath(f.Path))
+		if err != nil {
+			return rep, err
+		}
+		if meta, changed := fixMeta(meta, f); changed {
+			data, err := encodeMeta(meta)
+			if err != nil {
+				return rep, err
+			}
+			files = append(files, fileWrite{metaPath(f.Path), data})
+		}
+	}
+	if err := replaceFiles(files); err != nil {
+		return rep, err
+	}
+	for _, f := range files {
+		c.written(f.path, f.data)
+	}
+	if err := c.Load(); err != nil {
+		return rep, err
+	}
+	rep.Applied = true
+	rep.Generation = c.Generation()
+	return rep, nil
+}
+
+// fixList rewrites one list. sl is nil for the allowlist, which is neither
+// reduced nor checked against public suffixes.
+func fixList(list, path string, lines []string, sl suffixList, allowed map[string]bool, reduce bool) ListFix {
+	fix := ListFix{List: list, Path: path, LinesBefore: len(lines), Changes: []FixChange{}, Suggestions: []FixChange{}}
+	listed := make(map[string]bool)
+	for _, raw := range lines {
+		if raw != "" && !strings.HasPrefix(raw, "#") && !isRuleLine(raw) {
+			listed[normalizeListEntry(raw)] = true
+		}
+	}
+	type line struct {
+		text   string
+		origin int // index in lines
+	}
+	var out, section []line
+	flush := func() {
+		sort.SliceStable(section, func(i, j int) bool { return section[i].text < section[j].text })
+		out = append(out, section...)
+		section = section[:0]
+	}
+	seen := make(map[string]bool)
+	for i, raw := range lines {
+		if raw == "" || strings.HasPrefix(raw, "#") {
+			flush()
+			out = append(out, line{raw, i})
+			continue
+		}
+		change := func(action, result string) {
+			fix.Changes = append(fix.Changes, FixChange{Line: i + 1, Entry: raw, Action: action, Result: result})
+		}
+		e := raw
+		switch {
+		case !isRuleLine(e):
+			if n := normalizeListEntry(e); n != e {
+				action := FixLowercased
+				if n != strings.ToLower(e) {
+					action = FixNormalized
+				}
+				e = n
+				change(action, e)
+			}
+		case !strings.HasPrefix(e, "/"): // regex bodies may be case-sensitive
+			if low := strings.ToLower(e); low != e {
+				e = low
+				change(FixLowercased, e)
+			}
+		}
+		if sl != nil && !isRuleLine(e) {
+			if ps, _ := sl.PublicSuffix(e); ps == e {
+				change(FixPublicSuffix, "")
+				continue
+			}
+			if etld1, _ := sl.EffectiveTLDPlusOne(e); etld1 != "" && etld1 != e {
+				if listedParent(e, etld1, listed) {
+					change(FixCovered, "")
+					continue
+				}
+				switch {
+				case numericTLD(e): // e.g. an IP fragment, not a domain to widen
+				case reduce:
+					e = etld1
+					change(FixReduced, e)
+				default:
+					fix.Suggestions = append(fix.Suggestions, FixChange{Line: i + 1, Entry: raw, Action: FixReducible, Result: etld1})
+				}
+			}
+		}
+		if allowed[e] {
+			change(FixAllowlisted, "")
+			continue
+		}
+		if seen[e] {
+			change(FixDuplicate, "")
+			continue
+		}
+		seen[e] = true
+		section = append(section, line{e, i})
+	}
+	flush()
+
+	var buf bytes.Buffer
+	texts := make([]string, len(out))
+	origins := make([]int, len(out))
+	for i, l := range out {
+		buf.WriteString(l.text)
+		buf.WriteByte('\n')
+		texts[i], origins[i] = l.text, l.origin
+	}
+	fix.content = buf.Bytes()
+	fix.LinesAfter = len(out)
+	fix.Diff, fix.Reordered = fixDiff(path, lines, texts, origins)
+	fix.Changed = fix.Diff != ""
+	return fix
+}
+
+// fixMeta drops the provenance of entries the fix removed from a list and
+// hands that of a reduced entry to its registrable domain when it has none
+// of its own. It reports whether meta changed.
+func fixMeta(meta map[string]Provenance, fix ListFix) (map[string]Provenance, bool) {
+	if len(meta) == 0 {
+		return meta, false
+	}
+	kept := make(map[string]bool)
+	for _, l := range strings.Split(string(fix.content), "\n") {
+		if l != "" && !strings.HasPrefix(l, "#") {
+			kept[provenanceKey(l)] = true
+		}
+	}
+	out := make(map[string]Provenance, len(meta))
+	for _, ch := range fix.Changes {
+		if ch.Action != FixReduced {
+			continue
+		}
+		if p, ok := meta[provenanceKey(ch.Entry)]; ok {
+			if _, own := meta[ch.Result]; !own {
+				out[ch.Result] = p
+			}
+		}
+	}
+	for e, p := range meta {
+		if kept[e] {
+			out[e] = p
+		}
+	}
+	return out, len(out) != len(meta) || !maps.EqualFunc(out, meta, func(a, b Provenance) bool { return a == b })
+}
+
+// listedParent reports whether a parent domain of e, up to its registrable
+// domain etld1, is listed, so dropping e does not change what is blocked.
+func listedParent(e, etld1 string, listed map[string]bool) bool {
+	for p := e; p != etld1; {
+		_, rest, ok := strings.Cut(p, ".")
+		if !ok {
+			return false
+		}
+		if p = rest; listed[p] {
+			return true
+		}
+	}
+	return false
+}
+
+func numericTLD(e string) bool {
+	tld := e[strings.LastIndexByte(e, '.')+1:]
+	return tld != "" && strings.Trim(tld, "0123456789") == ""
+}
+
+// fixDiff renders a unified diff from old to new, where origins[j] is the old
+// line new[j] was derived from. Since fixing only rewrites, drops and
+// reorders lines, the longest increasing run of unchanged origins is a
+// longest common subsequence; the remaining unchanged lines were moved.
+func fixDiff(path string, old, new []string, origins []int) (string, int) {
+	// Patience-style LIS over the new lines that kept their text.
+	var tails []int // index into new of the smallest tail per length
+	prev := make([]int, len(new))
+	for j := range new {
+		if new[j] != old[origins[j]] {
+			continue
+		}
+		k := sort.Search(len(tails), func(i int) bool { return origins[tails[i]] >= origins[j] })
+		prev[j] = -1
+		if k > 0 {
+			prev[j] = tails[k-1]
+		}
+		if k == len(tails) {
+			tails = append(tails, j)
+		} else {
+			tails[k] = j
+		}
+	}
+	keptNew := make([]bool, len(new))
+	keptOld := make([]bool, len(old))
+	if len(tails) > 0 {
+		for j := tails[len(tails)-1]; j >= 0; j = prev[j] {
+			keptNew[j] = true
+			keptOld[origins[j]] = true
+		}
+	}
+	moved := 0
+	for j := range new {
+		if !keptNew[j] && new[j] == old[origins[j]] {
+			moved++
+		}
+	}
+
+	// Walk both files; an edit is a run of unkept old and new lines.
+	type op struct {
+		kind byte // ' ', '-', '+'
+		text string
+		i, j int // 0-based positions before the op
+	}
+	var ops []op
+	i, j := 0, 0
+	for i < len(old) || j < len(new) {
+		switch {
+		case i < len(old) && !keptOld[i]:
+			ops = append(ops, op{'-', old[i], i, j})
+			i++
+		case j < len(new) && !keptNew[j]:
+			ops = append(ops, op{'+', new[j], i, j})
+			j++
+		default:
+			ops = append(ops, op{' ', old[i], i, j})
+			i++
+			j++
+		}
+	}
+
+	const context = 2
+	var b strings.Builder
+	lines := 0
+	for k := 0; k < len(ops); {
+		if ops[k].kind == ' ' {
+			k++
+			continue
+		}
+		start := max(k-context, 0)
+		end := k
+		for end < len(ops) {
+			if ops[end].kind != ' ' {
+				end++
+				continue
+			}
+			run := end
+			for run < len(ops) && ops[run].kind == ' ' {
+				run++
+			}
+			if run == len(ops) || run-end > 2*context {
+				end = min(end+context, len(ops))
+				break
+			}
+			end = run
+		}
+		if b.Len() == 0 {
+			b.WriteString("--- " + path + "\n+++ " + path + " (fixed)\n")
+		}
+		oldN, newN := 0, 0
+		for _, o := range ops[start:end] {
+			if o.kind != '+' {
+				oldN++
+			}
+			if o.kind != '-' {
+				newN++
+			}
+		}
+		b.WriteString("@@ -" + strconv.Itoa(ops[start].i+1) + "," + strconv.Itoa(oldN) + " +" + strconv.Itoa(ops[start].j+1) + "," + strconv.Itoa(newN) + " @@\n")
+		for _, o := range ops[start:end] {
+			if lines == maxFixDiffLines {
+				b.WriteString("... diff truncated\n")
+				return b.String(), moved
+			}
+			b.WriteByte(o.kind)
+			b.WriteString(o.text)
+			b.WriteByte('\n')
+			lines++
+		}
+		k = end
+	}
+	return b.String(), moved
+}
@@ -0,0 +1,133 @@
+package domain
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFixLists(t *testing.T) {
+	dir := t.TempDir()
+	allowPath := filepath.Join(dir, "allowlist.conf")
+	blockPath := filepath.Join(dir, "blocklist.conf")
+	writeTempList(t, allowPath, []string{"# providers", "Gmail.com", "aol.com", "gmail.com", "", "# other", "zoho.com", "bluewin.ch"})
+	writeTempList(t, blockPath, []string{"# blocklist", "zzz.com", "Bad.com", "mail.spam.net", "host.usa-shared.com", "200.12.19", "co.uk", "aol.com", "bad.com", "*.Tmp.example", "/^X\\d+$/", "spam.net"})
+	c := NewChecker(allowPath, blockPath)
+	if err := c.Load(); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+
+	rep, err := c.FixLists(false, false)
+	if err != nil {
+		t.Fatalf("dry run: %v", err)
+	}
+	before, _ := os.ReadFile(blockPath)
+	if rep.Applied || !rep.Blocklist.Changed || strings.Contains(string(before), "spam.net\nzzz") {
+		t.Fatalf("dry run must not write: %+v", rep)
+	}
+	if !strings.Contains(rep.Blocklist.Diff, "-Bad.com\n") || !strings.HasPrefix(rep.Blocklist.Diff, "--- "+blockPath) {
+		t.Fatalf("unexpected diff:\n%s", rep.Blocklist.Diff)
+	}
+	actions := make(map[string]string)
+	for _, ch := range rep.Blocklist.Changes {
+		actions[ch.Entry] += ch.Action + ";"
+	}
+	want := map[string]string{
+		"Bad.com":       FixLowercased + ";",
+		"mail.spam.net": FixCovered + ";",
+		"co.uk":         FixPublicSuffix + ";",
+		"aol.com":       FixAllowlisted + ";",
+		"bad.com":       FixDuplicate + ";",
+		"*.Tmp.example": FixLowercased + ";",
+	}
+	for e, a := range want {
+		if actions[e] != a {
+			t.Errorf("%s: expected %s, got %q", e, a, actions[e])
+		}
+	}
+	// Reducing would widen the block to the whole registrable domain, so it
+	// is only suggested; numeric "domains" are not even suggested.
+	if s := rep.Blocklist.Suggestions; len(s) != 1 || s[0].Entry != "host.usa-shared.com" || s[0].Action != FixReducible || s[0].Result != "usa-shared.com" {
+		t.Fatalf("unexpected suggestions: %+v", s)
+	}
+
+	rep, err = c.FixLists(true, false)
+	if err != nil || !rep.Applied {
+		t.Fatalf("apply: %v %+v", err, rep)
+	}
+	allow, _ := os.ReadFile(allowPath)
+	if got := string(allow); got != "# providers\naol.com\ngmail.com\n\n# other\nbluewin.ch\nzoho.com\n" {
+		t.Fatalf("unexpected allowlist:\n%s", got)
+	}
+	block, _ := os.ReadFile(blockPath)
+	if got := string(block); got != "# blocklist\n*.tmp.example\n/^X\\d+$/\n200.12.19\nbad.com\nhost.usa-shared.com\nspam.net\nzzz.com\n" {
+		t.Fatalf("unexpected blocklist:\n%s", got)
+	}
+	if rep.Generation != c.Generation() || c.Check("x.spam.net").Status != "block" {
+		t.Fatalf("expected reloaded lists")
+	}
+	if v := c.Validate(); len(v.DuplicatesBlock)+len(v.PublicSuffixInBlock)+len(v.Intersection) > 0 || v.UnsortedBlockHint != "" {
+		t.Fatalf("findings left after fix: %+v", v)
+	}
+
+	// Fixed lists are a fixed point.
+	if rep, _ := c.FixLists(false, false); rep.Allowlist.Changed || rep.Blocklist.Changed {
+		t.Fatalf("expected no further changes: %+v", rep)
+	}
+
+	// Reduction is opt-in.
+	if rep, err = c.FixLists(true, true); err != nil || !rep.Applied {
+		t.Fatalf("apply with reduce: %v", err)
+	}
+	if !c.Check("other.usa-shared.com").Blocklisted || c.Check("200.12.19").Status != "block" {
+		t.Fatalf("expected host.usa-shared.com reduced and 200.12.19 kept")
+	}
+}
+
+func TestFixListsNormalizesAndKeepsSidecarsInStep(t *testing.T) {
+	dir := t.TempDir()
+	allowPath := filepath.Join(dir, "allowlist.conf")
+	blockPath := filepath.Join(dir, "blocklist.conf")
+	writeTempList(t, allowPath, []string{"Good.com", "good.com"})
+	writeTempList(t, blockPath, []string{"bücher.example", "xn--bcher-kva.example", "co.uk", "mail.spam.net"})
+	c := NewChecker(allowPath, blockPath)
+	if err := c.Load(); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	for _, e := range []string{"co.uk", "mail.spam.net", "xn--bcher-kva.example"} {
+		if err := appendMetaFile(metaPath(blockPath), []string{e}, Provenance{Source: "https://lists.example/" + e}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// A write failure leaves both lists untouched.
+	if err := os.Mkdir(blockPath+".tmp", 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.FixLists(true, true); err == nil {
+		t.Fatal("expected the blocked temp file to fail the fix")
+	}
+	if allow, _ := os.ReadFile(allowPath); string(allow) != "Good.com\ngood.com\n" {
+		t.Fatalf("allowlist fixed alone:\n%s", allow)
+	}
+	_ = os.Remove(blockPath + ".tmp")
+
+	rep, err := c.FixLists(true, true)
+	if err != nil || !rep.Applied {
+		t.Fatalf("apply: %v", err)
+	}
+	if rep.Blocklist.Changes[0].Action != FixNormalized || rep.Blocklist.Changes[1].Action != FixDuplicate {
+		t.Fatalf("expected the IDN spellings merged: %+v", rep.Blocklist.Changes)
+	}
+	if block, _ := os.ReadFile(blockPath); string(block) != "spam.net\nxn--bcher-kva.example\n" {
+		t.Fatalf("unexpected blocklist:\n%s", block)
+	}
+	meta, err := readMetaFile(metaPath(blockPath))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(meta) != 2 || meta["spam.net"].Source != "https://lists.example/mail.spam.net" || meta["xn--bcher-kva.example"].Source == "" {
+		t.Fatalf("unexpected sidecar after fix: %+v", meta)
+	}
+}
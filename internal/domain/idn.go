@@ -0,0 +1,161 @@
+package domain
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+
+	"golang.org/x/net/idna"
+)
+
+// normalizeDomain maps d to its IDNA (UTS #46, lookup profile) ASCII form and
+// the corresponding Unicode display form. Plain lowercase ASCII names take a
+// fast path. On mapping errors the lowercased input is returned for both
+// forms together with the error, so callers can still perform list lookups.
+func normalizeDomain(d string) (ascii, unicodeForm string, err error) {
+	if isPlainASCIIDomain(d) {
+		ascii = strings.ToLower(d)
+		return ascii, ascii, nil
+	}
+	ascii, err = idna.Lookup.ToASCII(d)
+	if err != nil {
+		l := strings.ToLower(d)
+		return l, l, err
+	}
+	unicodeForm, err = idna.Display.ToUnicode(ascii)
+	if err != nil {
+		return ascii, ascii, err
+	}
+	return ascii, unicodeForm, nil
+}
+
+// normalizeListEntry returns the lookup key for a list file entry: its IDNA
+// ASCII form, or the lowercased entry when it cannot be mapped.
+func normalizeListEntry(e string) string {
+	ascii, _, _ := normalizeDomain(e)
+	return ascii
+}
+
+// NormalizeEntry validates a submitted list entry and returns the form to
+// store: patterns must compile and keep their text (lowercased unless they
+// are regexes, whose escapes are case sensitive), domains are mapped to their
+// IDNA ASCII form.
+func NormalizeEntry(e string) (string, error) {
+	if isRuleLine(e) {
+		if _, err := parseRule(e, 0); err != nil {
+			return "", err
+		}
+		if strings.HasPrefix(e, "/") {
+			return e, nil
+		}
+		return strings.ToLower(e), nil
+	}
+	ascii, _, err := normalizeDomain(e)
+	return ascii, err
+}
+
+// isPlainASCIIDomain reports whether d only contains ASCII letters, digits,
+// hyphens and dots and has no punycode labels, so lowercasing is the complete
+// UTS #46 mapping.
+func isPlainASCIIDomain(d string) bool {
+	for i := 0; i < len(d); i++ {
+		c := d[i]
+		if (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9') || c == '-' || c == '.' {
+			continue
+		}
+		return false
+	}
+	return !strings.Contains(d, "xn--") && !strings.Contains(d, "XN--") && !strings.Contains(d, "Xn--") && !strings.Contains(d, "xN--")
+}
+
+// confusables maps characters that render like ASCII letters or digits to the
+// character they imitate. It covers the common Cyrillic, Greek, Armenian and
+// Latin-extended lookalikes from Unicode's confusables.txt that appear in
+// phishing domains; it is deliberately not exhaustive.
+var confusables = map[rune]rune{
+	// Cyrillic
+	'а': 'a', 'в': 'b', 'с': 'c', 'ԁ': 'd', 'е': 'e', 'ё': 'e', 'һ': 'h', 'і': 'i', 'ї': 'i', 'ј': 'j',
+	'к': 'k', 'ӏ': 'l', 'м': 'm', 'н': 'h', 'о': 'o', 'р': 'p', 'ԛ': 'q', 'ѕ': 's', 'т': 't', 'ц': 'u',
+	'ѵ': 'v', 'ԝ': 'w', 'х': 'x', 'у': 'y', 'ү': 'y', 'ɡ': 'g', 'ԍ': 'g', 'ь': 'b',
+	// Greek
+	'α': 'a', 'β': 'b', 'ε': 'e', 'η': 'n', 'ι': 'i', 'κ': 'k', 'ν': 'v', 'ο': 'o', 'ρ': 'p', 'τ': 't',
+	'υ': 'u', 'χ': 'x', 'γ': 'y', 'ω': 'w', 'μ': 'u',
+	// Armenian
+	'ա': 'w', 'հ': 'h', 'ո': 'n', 'ս': 'u', 'օ': 'o', 'ց': 'g', 'զ': 'q',
+	// Latin extended and IPA
+	'ı': 'i', 'ł': 'l', 'ɩ': 'i', 'ɑ': 'a', 'ɛ': 'e', 'ɪ': 'i', 'ʟ': 'l', 'ɴ': 'n', 'ʀ': 'r', 'ꜱ': 's',
+	'ᴄ': 'c', 'ᴅ': 'd', 'ᴇ': 'e', 'ᴊ': 'j', 'ᴋ': 'k', 'ᴍ': 'm', 'ᴏ': 'o', 'ᴘ': 'p', 'ᴛ': 't', 'ᴜ': 'u',
+	'ᴠ': 'v', 'ᴡ': 'w', 'ᴢ': 'z', 'à': 'a', 'á': 'a', 'â': 'a', 'ã': 'a', 'ä': 'a', 'å': 'a', 'è': 'e',
+	'é': 'e', 'ê': 'e', 'ë': 'e', 'ì': 'i', 'í': 'i', 'î': 'i', 'ï': 'i', 'ò': 'o', 'ó': 'o', 'ô': 'o',
+	'õ': 'o', 'ö': 'o', 'ù': 'u', 'ú': 'u', 'û': 'u', 'ü': 'u', 'ý': 'y', 'ÿ': 'y', 'ç': 'c', 'ñ': 'n',
+	// Digits that pass for letters
+	'0': 'o', '1': 'l',
+}
+
+// skeleton reduces a Unicode domain to the ASCII string it visually imitates,
+// in the spirit of UTS #39. Two domains with equal skeletons are confusable.
+func skeleton(s string) string {
+	var b strings.Builder
+	b.Grow(len(s))
+	for _, r := range s {
+		if m, ok := confusables[r]; ok {
+			r = m
+		}
+		b.WriteRune(r)
+	}
+	out := b.String()
+	// Multi-character lookalikes.
+	out = strings.ReplaceAll(out, "rn", "m")
+	out = strings.ReplaceAll(out, "vv", "w")
+	return out
+}
+
+// lookalikeScripts are the scripts considered when detecting mixed-script labels.
+var lookalikeScripts = []*unicode.RangeTable{
+	unicode.Latin, unicode.Cyrillic, unicode.Greek, unicode.Armenian, unicode.Cherokee,
+	unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul, unicode.Arabic, unicode.Hebrew, unicode.Thai,
+}
+
+// hasMixedScriptLabel reports whether any label of the Unicode domain mixes
+// letters from more than one script (e.g. Latin and Cyrillic).
+func hasMixedScriptLabel(d string) bool {
+	for _, label := range strings.Split(d, ".") {
+		var seen *unicode.RangeTable
+		for _, r := range label {
+			if !unicode.IsLetter(r) {
+				continue
+			}
+			for _, t := range lookalikeScripts {
+				if unicode.Is(t, r) {
+					if seen != nil && seen != t {
+						return true
+					}
+					seen = t
+					break
+				}
+			}
+		}
+	}
+	return false
+}
+
+// buildSkeletons indexes the skeletons of exact allowlist entries. Entries
+// that share a skeleton (gmail.com and gmai1.com, say) are all kept, sorted,
+// so the reported target does not depend on iteration order.
+func buildSkeletons(allow listIndex) map[string][]string {
+	out := make(map[string][]string, allow.len())
+	allow.each(func(d string, _ int) {
+		u := d
+		if _, uni, err := normalizeDomain(d); err == nil {
+			u = uni
+		}
+		k := skeleton(u)
+		out[k] = append(out[k], d)
+	})
+	for _, targets := range out {
+		if len(targets) > 1 {
+			sort.Strings(targets)
+		}
+	}
+	return out
+}
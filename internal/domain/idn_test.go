@@ -0,0 +1,64 @@
+package domain
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestIDNNormalization(t *testing.T) {
+	dir := t.TempDir()
+	allowPath := filepath.Join(dir, "allowlist.conf")
+	blockPath := filepath.Join(dir, "blocklist.conf")
+	writeTempList(t, allowPath, []string{"gmail.com"})
+	// One entry in Unicode form, one in punycode form.
+	writeTempList(t, blockPath, []string{"bücher.example", "xn--mnchen-3ya.example"})
+	c := NewChecker(allowPath, blockPath)
+	if err := c.Load(); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	for _, in := range []string{"user@BÜCHER.example", "xn--bcher-kva.example", "münchen.example", "mail.XN--MNCHEN-3YA.example"} {
+		res := c.Check(in)
+		if !res.Blocklisted {
+			t.Errorf("expected %q blocklisted, got %+v", in, res)
+		}
+	}
+	res := c.Check("Bücher.example")
+	if res.ASCIIDomain != "xn--bcher-kva.example" || res.UnicodeDomain != "bücher.example" {
+		t.Fatalf("unexpected forms: ascii=%q unicode=%q", res.ASCIIDomain, res.UnicodeDomain)
+	}
+}
+
+func TestHomographSignal(t *testing.T) {
+	dir := t.TempDir()
+	allowPath := filepath.Join(dir, "allowlist.conf")
+	blockPath := filepath.Join(dir, "blocklist.conf")
+	writeTempList(t, allowPath, []string{"gmail.com", "outlook.com", "cam.com", "carn.com", "com.com", "webl.de"})
+	writeTempList(t, blockPath, []string{})
+	c := NewChecker(allowPath, blockPath)
+	if err := c.Load(); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	cases := []struct {
+		in         string
+		confusable bool
+		target     string
+		mixed      bool
+	}{
+		{"user@gmаil.com", true, "gmail.com", true}, // Cyrillic а
+		{"gmail.com", false, "", false},
+		{"mail.gmail.com", false, "", false},
+		{"οutlοok.com", true, "outlook.com", true}, // Greek ο
+		{"gmаi1.com", true, "gmail.com", true},     // Cyrillic а plus digit 1
+		{"gmai1.com", false, "", false},            // plain ASCII is not a homograph
+		{"corn.com", false, "", false},             // "rn" fold only applies to IDN input
+		{"web1.de", false, "", false},
+		{"cаrn.com", true, "cam.com", true}, // cam.com and carn.com collide; first sorted wins
+		{"example.com", false, "", false},
+	}
+	for _, tc := range cases {
+		res := c.Check(tc.in)
+		if res.Confusable != tc.confusable || res.ConfusableWith != tc.target || res.MixedScript != tc.mixed {
+			t.Errorf("Check(%q): confusable=%v with=%q mixed=%v, want %v %q %v", tc.in, res.Confusable, res.ConfusableWith, res.MixedScript, tc.confusable, tc.target, tc.mixed)
+		}
+	}
+}
@@ -0,0 +1,430 @@
+package domain
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"io"
+	"os"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Index modes for the exact entries of the shared lists (see SetIndexMode).
+// All modes give the same lookup results; they differ in memory use and in
+// how the lists are loaded.
+const (
+	// IndexMap keeps a Go map plus the raw lines of each list (default).
+	IndexMap = "map"
+	// IndexCompact keeps a sorted string table built at load. Raw lines are
+	// not retained; Validate re-reads the list files instead.
+	IndexCompact = "compact"
+	// IndexMmap is IndexCompact persisted next to each list as <list>.idx and
+	// memory-mapped. The file is rebuilt whenever the list content changes.
+	IndexMmap = "mmap"
+)
+
+// ErrUnknownIndexMode is returned by SetIndexMode for unsupported modes.
+var ErrUnknownIndexMode = errors.New("unknown list index mode (use map, compact or mmap)")
+
+// listIndex maps exact list entries (IDNA ASCII form) to the 1-based line
+// they first appear on. Indexes are immutable once built; see deltaIndex for
+// patched entries.
+type listIndex interface {
+	lookup(key string) (line int, ok bool)
+	len() int
+	each(fn func(key string, line int))
+}
+
+type mapIndex map[string]int
+
+func (m mapIndex) lookup(key string) (int, bool) {
+	line, ok := m[key]
+	return line, ok
+}
+
+func (m mapIndex) len() int { return len(m) }
+
+func (m mapIndex) each(fn func(string, int)) {
+	for k, line := range m {
+		fn(k, line)
+	}
+}
+
+// tableIndex is a sorted string table: the keys are concatenated in data and
+// addressed through little-endian uint32 offsets, so an entry costs its bytes
+// plus 8 bytes instead of a string header, map slot and boxed line number.
+// The slices may point into a memory-mapped file, which is unmapped once the
+// table becomes unreachable; methods reading them end with runtime.KeepAlive
+// so the table outlives the last read even when the caller drops it.
+type tableIndex struct {
+	n     int
+	offs  []byte // n+1 offsets into data
+	lines []byte // n line numbers
+	data  []byte
+}
+
+// key returns the bytes of entry i in place; callers keep t alive while
+// they read them.
+func (t *tableIndex) key(i int) []byte {
+	return t.data[binary.LittleEndian.Uint32(t.offs[4*i:]):binary.LittleEndian.Uint32(t.offs[4*i+4:])]
+}
+
+func (t *tableIndex) lookup(key string) (int, bool) {
+	lo, hi := 0, t.n
+	for lo < hi {
+		m := int(uint(lo+hi) >> 1)
+		if string(t.key(m)) < key {
+			lo = m + 1
+		} else {
+			hi = m
+		}
+	}
+	line, ok := 0, false
+	if lo < t.n && string(t.key(lo)) == key {
+		line, ok = int(binary.LittleEndian.Uint32(t.lines[4*lo:])), true
+	}
+	runtime.KeepAlive(t)
+	return line, ok
+}
+
+func (t *tableIndex) len() int { return t.n }
+
+func (t *tableIndex) each(fn func(string, int)) {
+	for i := 0; i < t.n; i++ {
+		fn(string(t.key(i)), int(binary.LittleEndian.Uint32(t.lines[4*i:])))
+	}
+	runtime.KeepAlive(t)
+}
+
+// newTableIndex copies the entries of idx into an in-memory table.
+func newTableIndex(idx listIndex) *tableIndex {
+	type entry struct {
+		key  string
+		line int
+	}
+	entries := make([]entry, 0, idx.len())
+	dataLen := 0
+	idx.each(func(k string, line int) {
+		entries = append(entries, entry{k, line})
+		dataLen += len(k)
+	})
+	sort.Slice(entries, func(i, j int) bool { return entries[i].key < entries[j].key })
+	t := &tableIndex{
+		n:     len(entries),
+		offs:  make([]byte, 0, 4*len(entries)+4),
+		lines: make([]byte, 0, 4*len(entries)),
+		data:  make([]byte, 0, dataLen),
+	}
+	for _, e := range entries {
+		t.offs = binary.LittleEndian.AppendUint32(t.offs, uint32(len(t.data)))
+		t.lines = binary.LittleEndian.AppendUint32(t.lines, uint32(e.line))
+		t.data = append(t.data, e.key...)
+	}
+	t.offs = binary.LittleEndian.AppendUint32(t.offs, uint32(len(t.data)))
+	return t
+}
+
+// Index file layout (little endian):
+//
+//	magic    [8]byte  "DEDIDX1\n"
+//	sum      [32]byte SHA-256 of the list file the index was built from
+//	n        uint32   exact entries
+//	total    uint32   lines in the list file
+//	dataLen  uint32
+//	rulesLen uint32
+//	offs     [n+1]uint32
+//	lines    [n]uint32
+//	data     [dataLen]byte
+//	rules    [rulesLen]byte "<line>\t<pattern>\n" per pattern entry
+const (
+	indexMagic     = "DEDIDX1\n"
+	indexHeaderLen = 8 + sha256.Size + 16
+)
+
+var errBadIndex = errors.New("malformed list index")
+
+// scanList calls fn for every trimmed line of a list with its 1-based number.
+func scanList(r io.Reader, fn func(n int, line string)) error {
+	s := bufio.NewScanner(r)
+	s.Buffer(make([]byte, 0, 64*1024), 10*1024*1024) // up to 10MB lines file
+	n := 0
+	for s.Scan() {
+		n++
+		fn(n, strings.TrimSpace(s.Text()))
+	}
+	return s.Err()
+}
+
+// encodeIndex parses list content and encodes its exact entries and pattern
+// lines in the index file format.
+func encodeIndex(src []byte) ([]byte, error) {
+	type entry struct {
+		key  string
+		line int
+	}
+	var entries []entry
+	var rules bytes.Buffer
+	total := 0
+	err := scanList(bytes.NewReader(src), func(n int, line string) {
+		total = n
+		if line == "" || strings.HasPrefix(line, "#") {
+			return
+		}
+		if isRuleLine(line) {
+			rules.WriteString(strconv.Itoa(n) + "\t" + line + "\n")
+			return
+		}
+		entries = append(entries, entry{normalizeListEntry(line), n})
+	})
+	if err != nil {
+		return nil, err
+	}
+	// Sort by key, then line, and keep the first occurrence of each key.
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].key != entries[j].key {
+			return entries[i].key < entries[j].key
+		}
+		return entries[i].line < entries[j].line
+	})
+	uniq := entries[:0]
+	for i, e := range entries {
+		if i == 0 || e.key != entries[i-1].key {
+			uniq = append(uniq, e)
+		}
+	}
+	dataLen := 0
+	for _, e := range uniq {
+		dataLen += len(e.key)
+	}
+	n := len(uniq)
+	buf := make([]byte, 0, indexHeaderLen+8*n+4+dataLen+rules.Len())
+	sum := sha256.Sum256(src)
+	buf = append(buf, indexMagic...)
+	buf = append(buf, sum[:]...)
+	for _, v := range []int{n, total, dataLen, rules.Len()} {
+		buf = binary.LittleEndian.AppendUint32(buf, uint32(v))
+	}
+	off := 0
+	for _, e := range uniq {
+		buf = binary.LittleEndian.AppendUint32(buf, uint32(off))
+		off += len(e.key)
+	}
+	buf = binary.LittleEndian.AppendUint32(buf, uint32(off))
+	for _, e := range uniq {
+		buf = binary.LittleEndian.AppendUint32(buf, uint32(e.line))
+	}
+	for _, e := range uniq {
+		buf = append(buf, e.key...)
+	}
+	return append(buf, rules.Bytes()...), nil
+}
+
+// decodeIndex validates an encoded index and returns the table (pointing into
+// buf), the number of lines in the source list and its compiled patterns.
+func decodeIndex(buf []byte) (t *tableIndex, sum []byte, total int, rules *ruleSet, err error) {
+	if len(buf) < indexHeaderLen || string(buf[:8]) != indexMagic {
+		return nil, nil, 0, nil, errBadIndex
+	}
+	sum = buf[8 : 8+sha256.Size]
+	h := buf[8+sha256.Size:]
+	n := int(binary.LittleEndian.Uint32(h))
+	total = int(binary.LittleEndian.Uint32(h[4:]))
+	dataLen := int(binary.LittleEndian.Uint32(h[8:]))
+	rulesLen := int(binary.LittleEndian.Uint32(h[12:]))
+	body := buf[indexHeaderLen:]
+	if len(body) != 8*n+4+dataLen+rulesLen {
+		return nil, nil, 0, nil, errBadIndex
+	}
+	t = &tableIndex{n: n, offs: body[:4*n+4], lines: body[4*n+4 : 8*n+4], data: body[8*n+4 : 8*n+4+dataLen]}
+	if int(binary.LittleEndian.Uint32(t.offs[4*n:])) != dataLen {
+		return nil, nil, 0, nil, errBadIndex
+	}
+	rules = newRuleSet()
+	for _, l := range strings.Split(string(body[8*n+4+dataLen:]), "\n") {
+		num, raw, ok := strings.Cut(l, "\t")
+		if !ok {
+			continue
+		}
+		line, _ := strconv.Atoi(num)
+		if r, err := parseRule(raw, line); err == nil {
+			rules.add(r)
+		}
+	}
+	rules.finish()
+	return t, sum, total, rules, nil
+}
+
+// indexPath returns the prebuilt index file used by IndexMmap for a list.
+func indexPath(listPath string) string { return listPath + ".idx" }
+
+// BuildIndexFile writes the prebuilt index for the list at listPath to
+// <listPath>.idx, replacing it atomically. Load rebuilds stale indexes on its
+// own; this allows shipping the index alongside the list.
+func BuildIndexFile(listPath string) error {
+	src, err := os.ReadFile(listPath)
+	if err != nil {
+		return err
+	}
+	buf, err := encodeIndex(src)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(indexPath(listPath), buf)
+}
+
+// fileWrite is one file of a replaceFiles call.
+type fileWrite struct {
+	path string
+	data []byte
+}
+
+// replaceFiles replaces several files as close to together as the file
+// system allows: every file is written to a temp file first, and the renames
+// only start once all writes succeeded. Should a rename fail, the files
+// already replaced get their previous contents back.
+func replaceFiles(files []fileWrite) error {
+	type previous struct {
+		data    []byte
+		existed bool
+	}
+	prev := make([]previous, len(files))
+	cleanup := func(from int) {
+		for _, f := range files[from:] {
+			_ = os.Remove(f.path + ".tmp")
+		}
+	}
+	for i, f := range files {
+		data, err := os.ReadFile(f.path)
+		if err != nil && !os.IsNotExist(err) {
+			cleanup(0)
+			return err
+		}
+		prev[i] = previous{data, err == nil}
+		if err := os.WriteFile(f.path+".tmp", f.data, 0o644); err != nil {
+			cleanup(0)
+			return err
+		}
+	}
+	for i, f := range files {
+		if err := os.Rename(f.path+".tmp", f.path); err != nil {
+			cleanup(i)
+			for j, g := range files[:i] {
+				var rerr error
+				if prev[j].existed {
+					rerr = writeFileAtomic(g.path, prev[j].data)
+				} else {
+					rerr = os.Remove(g.path)
+				}
+				err = errors.Join(err, rerr)
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+func writeFileAtomic(path string, data []byte) error {
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// loadList reads a list in the given index mode. raw is only retained for
+// IndexMap; total is the number of lines in the file.
+func loadList(path, mode string) (idx listIndex, raw []string, rules *ruleSet, total int, err error) {
+	switch mode {
+	case IndexCompact, IndexMmap:
+	default:
+		set, raw, rules, err := readListFile(path)
+		return mapIndex(set), raw, rules, len(raw), err
+	}
+	src, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, nil, nil, 0, err
+	}
+	if mode == IndexCompact {
+		buf, err := encodeIndex(src)
+		if err != nil {
+			return nil, nil, nil, 0, err
+		}
+		t, _, total, rules, err := decodeIndex(buf)
+		return t, nil, rules, total, err
+	}
+	// Reuse the prebuilt index when it was built from the same content.
+	want := sha256.Sum256(src)
+	if data, unmap, err := mmapFile(indexPath(path)); err == nil {
+		t, sum, total, rules, err := decodeIndex(data)
+		if err == nil && bytes.Equal(sum, want[:]) {
+			runtime.AddCleanup(t, func(unmap func() error) { _ = unmap() }, unmap)
+			return t, nil, rules, total, nil
+		}
+		_ = unmap()
+	}
+	buf, err := encodeIndex(src)
+	if err != nil {
+		return nil, nil, nil, 0, err
+	}
+	if err := writeFileAtomic(indexPath(path), buf); err != nil {
+		return nil, nil, nil, 0, err
+	}
+	data, unmap, err := mmapFile(indexPath(path))
+	if err != nil {
+		return nil, nil, nil, 0, err
+	}
+	t, _, total, rules, err := decodeIndex(data)
+	if err != nil {
+		_ = unmap()
+		return nil, nil, nil, 0, err
+	}
+	// Snapshots are read without locks, so the mapping is released only
+	// when no snapshot references the table anymore.
+	runtime.AddCleanup(t, func(unmap func() error) { _ = unmap() }, unmap)
+	return t, nil, rules, total, nil
+}
+
+// readListLines returns the trimmed lines of a list file; a missing file has
+// none.
+func readListLines(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+	var lines []string
+	err = scanList(f, func(_ int, line string) { lines = append(lines, line) })
+	return lines, err
+}
+
+// SetIndexMode selects how the shared lists are indexed (IndexMap,
+// IndexCompact or IndexMmap). It takes effect on the next Load.
+func (c *Checker) SetIndexMode(mode string) error {
+	switch mode {
+	case "":
+		mode = IndexMap
+	case IndexMap, IndexCompact, IndexMmap:
+	default:
+		return ErrUnknownIndexMode
+	}
+	c.writeMu.Lock()
+	c.indexMode = mode
+	c.writeMu.Unlock()
+	return nil
+}
+
+// IndexMode returns the index mode of the current snapshot.
+func (c *Checker) IndexMode() string {
+	if m := c.current().indexMode; m != "" {
+		return m
+	}
+	return IndexMap
+}
@@ -0,0 +1,186 @@
+package domain
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// TestIndexModes checks that every index mode gives the same verdicts, match
+// lines and patch behavior.
+func TestIndexModes(t *testing.T) {
+	allowLines := []string{"# allow", "good.com", "*.trusted.net"}
+	blockLines := []string{"# block", "bad.com", "", "BÜCHER.example", "bad.com", "*.usa.cc", "||burner.io^", "zzz.org"}
+	inputs := []string{"bad.com", "x.bad.com", "xn--bcher-kva.example", "a.usa.cc", "mail.burner.io", "good.com", "a.trusted.net", "zzz.org", "aaa.org", "fresh.io"}
+
+	type outcome struct {
+		status string
+		lines  string
+	}
+	var want map[string]outcome
+	for _, mode := range []string{IndexMap, IndexCompact, IndexMmap} {
+		t.Run(mode, func(t *testing.T) {
+			dir := t.TempDir()
+			allowPath := filepath.Join(dir, "allowlist.conf")
+			blockPath := filepath.Join(dir, "blocklist.conf")
+			writeTempList(t, allowPath, allowLines)
+			writeTempList(t, blockPath, blockLines)
+			c := NewChecker(allowPath, blockPath)
+			if err := c.SetIndexMode(mode); err != nil {
+				t.Fatal(err)
+			}
+			if err := c.Load(); err != nil {
+				t.Fatalf("load: %v", err)
+			}
+			// Patched entries are numbered after the lines of the file.
+			f, _ := os.OpenFile(blockPath, os.O_APPEND|os.O_WRONLY, 0o644)
+			_, _ = f.WriteString("fresh.io\nbad.com\n")
+			_ = f.Close()
+			c.PatchBlock([]string{"fresh.io", "bad.com"})
+
+			got := make(map[string]outcome)
+			for _, in := range inputs {
+				res := c.Check(in)
+				var lines []string
+				for _, m := range res.Matches {
+					lines = append(lines, m.List+":"+strconv.Itoa(m.Line))
+				}
+				got[in] = outcome{res.Status, strings.Join(lines, ",")}
+			}
+			if got["xn--bcher-kva.example"].lines != "blocklist:4" || got["fresh.io"].lines != "blocklist:9" {
+				t.Fatalf("unexpected match lines: %+v", got)
+			}
+			if want == nil {
+				want = got
+			}
+			for in, o := range want {
+				if got[in] != o {
+					t.Errorf("%s: got %+v, map index gave %+v", in, got[in], o)
+				}
+			}
+			if n := c.BlockCount(); n != 6 {
+				t.Errorf("expected 6 block entries, got %d", n)
+			}
+			if rep := c.Validate(); len(rep.DuplicatesBlock) != 1 || rep.DuplicatesBlock[0] != "bad.com" {
+				t.Errorf("expected bad.com duplicate from raw lines, got %v", rep.DuplicatesBlock)
+			}
+			if mode == IndexMmap {
+				if _, err := os.Stat(indexPath(blockPath)); err != nil {
+					t.Fatalf("expected prebuilt index: %v", err)
+				}
+				// A changed list invalidates the prebuilt index.
+				writeTempList(t, blockPath, []string{"other.com"})
+				if err := c.Load(); err != nil {
+					t.Fatalf("reload: %v", err)
+				}
+				if c.Check("bad.com").Blocklisted || !c.Check("other.com").Blocklisted {
+					t.Fatal("stale index used after the list changed")
+				}
+			}
+		})
+	}
+	if err := NewChecker("", "").SetIndexMode("trie"); err != ErrUnknownIndexMode {
+		t.Fatalf("expected ErrUnknownIndexMode, got %v", err)
+	}
+}
+
+// Reloads drop mmap tables while lookups may still read them; the mapping
+// must stay until the last read.
+func TestMmapTableOutlivesReloads(t *testing.T) {
+	dir := t.TempDir()
+	allowPath := filepath.Join(dir, "allowlist.conf")
+	blockPath := filepath.Join(dir, "blocklist.conf")
+	writeTempList(t, allowPath, nil)
+	if err := os.WriteFile(blockPath, benchmarkList(5000), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	c := NewChecker(allowPath, blockPath)
+	if err := c.SetIndexMode(IndexMmap); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Load(); err != nil {
+		t.Fatal(err)
+	}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 20; i++ {
+			_ = c.Reload(false)
+			runtime.GC()
+		}
+	}()
+	for {
+		select {
+		case <-done:
+			return
+		default:
+		}
+		if !c.Check("throwaway-7919.example").Blocklisted {
+			t.Fatal("expected entry blocklisted during reloads")
+		}
+	}
+}
+
+// benchmarkList returns blocklist content with n generated entries.
+func benchmarkList(n int) []byte {
+	var b strings.Builder
+	for i := 0; i < n; i++ {
+		b.WriteString("throwaway-")
+		b.WriteString(strconv.Itoa(i * 7919))
+		b.WriteString(".example\n")
+	}
+	return []byte(b.String())
+}
+
+// BenchmarkListIndex compares exact-entry lookups and retained heap per entry
+// of the map and table indexes for a list larger than the production one.
+func BenchmarkListIndex(b *testing.B) {
+	const n = 500_000
+	path := filepath.Join(b.TempDir(), "blocklist.conf")
+	if err := os.WriteFile(path, benchmarkList(n), 0o644); err != nil {
+		b.Fatal(err)
+	}
+	inputs := []string{"throwaway-" + strconv.Itoa(123*7919) + ".example", "gmail.com", "throwaway-1.example"}
+	for _, mode := range []string{IndexMap, IndexCompact, IndexMmap} {
+		b.Run(mode, func(b *testing.B) {
+			var before, after runtime.MemStats
+			runtime.GC()
+			runtime.ReadMemStats(&before)
+			idx, raw, _, _, err := loadList(path, mode)
+			if err != nil {
+				b.Fatal(err)
+			}
+			runtime.GC()
+			runtime.ReadMemStats(&after)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				idx.lookup(inputs[i%len(inputs)])
+			}
+			b.StopTimer()
+			runtime.KeepAlive(raw)
+			b.ReportMetric(float64(int64(after.HeapAlloc)-int64(before.HeapAlloc))/n, "heap-B/entry")
+		})
+	}
+}
+
+// BenchmarkListLoad measures loading a large list in each mode; mmap reuses
+// the index built by the first iteration.
+func BenchmarkListLoad(b *testing.B) {
+	path := filepath.Join(b.TempDir(), "blocklist.conf")
+	if err := os.WriteFile(path, benchmarkList(200_000), 0o644); err != nil {
+		b.Fatal(err)
+	}
+	for _, mode := range []string{IndexMap, IndexCompact, IndexMmap} {
+		b.Run(mode, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, _, _, _, err := loadList(path, mode); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
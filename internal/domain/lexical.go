@@ -0,0 +1,326 @@
+package domain
+
+import (
+	_ "embed"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"sync/atomic"
+)
+
+// lexicalReference is the built-in negative class of the lexical classifier:
+// legitimate domains across many TLDs. The allowlist is added at Load.
+//
+//go:embed lexical_reference.txt
+var lexicalReference string
+
+// DefaultSuspectThreshold is the classifier probability at or above which a
+// neutral domain is reported as suspect.
+const DefaultSuspectThreshold = 0.9
+
+// SuspectFeature is one feature's share of a suspect assessment, in log-odds
+// (positive values point towards disposable).
+type SuspectFeature struct {
+	Feature      string  `json:"feature"`
+	Value        string  `json:"value"`
+	Contribution float64 `json:"contribution"`
+}
+
+// SuspectDetail is the lexical classifier's assessment of a neutral domain.
+type SuspectDetail struct {
+	Probability float64          `json:"probability"`
+	Threshold   float64          `json:"threshold"`
+	Features    []SuspectFeature `json:"features"` // largest contribution first
+}
+
+// lexicalFeature is a binned property of a registrable label.
+type lexicalFeature struct {
+	name  string
+	edges []float64 // upper bounds of all but the last bin
+	value func(label string) float64
+}
+
+func (f lexicalFeature) bin(label string) (int, float64) {
+	v := f.value(label)
+	for i, e := range f.edges {
+		if v <= e {
+			return i, v
+		}
+	}
+	return len(f.edges), v
+}
+
+var lexicalBins = []lexicalFeature{
+	{"length", []float64{4, 8, 12, 16, 24}, func(l string) float64 { return float64(len(l)) }},
+	{"digit_ratio", []float64{0.25, 0.5}, func(l string) float64 {
+		return float64(countBytes(l, func(c byte) bool { return c >= '0' && c <= '9' })) / float64(len(l))
+	}},
+	{"hyphens", []float64{0, 1}, func(l string) float64 {
+		return float64(strings.Count(l, "-"))
+	}},
+	{"entropy", []float64{0.8, 0.9, 0.95}, func(l string) float64 {
+		if len(l) < 2 {
+			return 0
+		}
+		return labelEntropy(l) / math.Log2(float64(len(l)))
+	}},
+	{"digit_run", []float64{0, 1, 3, 5}, func(l string) float64 {
+		longest, _ := digitRuns(l)
+		return float64(longest)
+	}},
+	{"digit_runs", []float64{0, 1, 2}, func(l string) float64 {
+		_, runs := digitRuns(l)
+		return float64(runs)
+	}},
+}
+
+// digitRuns returns the length of the longest run of digits in l and the
+// number of runs: generated names interleave letters and digits (x7k2q9),
+// real ones tend to have a single number (3m, 7-eleven, immobilienscout24).
+func digitRuns(l string) (longest, runs int) {
+	run := 0
+	for i := 0; i < len(l); i++ {
+		if l[i] >= '0' && l[i] <= '9' {
+			if run++; run == 1 {
+				runs++
+			}
+			longest = max(longest, run)
+		} else {
+			run = 0
+		}
+	}
+	return longest, runs
+}
+
+// labelEntropy is the Shannon entropy of the label's characters in bits.
+func labelEntropy(l string) float64 {
+	var counts [256]int
+	for i := 0; i < len(l); i++ {
+		counts[l[i]]++
+	}
+	h := 0.0
+	for _, n := range counts {
+		if n > 0 {
+			p := float64(n) / float64(len(l))
+			h -= p * math.Log2(p)
+		}
+	}
+	return h
+}
+
+func countBytes(s string, fn func(byte) bool) int {
+	n := 0
+	for i := 0; i < len(s); i++ {
+		if fn(s[i]) {
+			n++
+		}
+	}
+	return n
+}
+
+// Character bigrams are taken over "^label$" with letters, digits, '-' and
+// one symbol for anything else.
+const lexicalSymbols = 26 + 10 + 1 + 1 + 1 // + other, boundary
+
+func lexicalSymbol(c byte) int {
+	switch {
+	case c >= 'a' && c <= 'z':
+		return int(c - 'a')
+	case c >= '0' && c <= '9':
+		return 26 + int(c-'0')
+	case c == '-':
+		return 36
+	default:
+		return 37
+	}
+}
+
+func eachBigram(label string, fn func(i int)) {
+	prev := lexicalSymbols - 1
+	for j := 0; j < len(label); j++ {
+		s := lexicalSymbol(label[j])
+		fn(prev*lexicalSymbols + s)
+		prev = s
+	}
+	fn(prev*lexicalSymbols + lexicalSymbols - 1)
+}
+
+// lexicalModel is a naive Bayes classifier over label features, trained on
+// the blocklist (positive) and the allowlist plus lexicalReference
+// (negative). Every table holds log-likelihood ratios.
+type lexicalModel struct {
+	bigram   [lexicalSymbols * lexicalSymbols]float64
+	bins     [][]float64 // per lexicalBins entry
+	tld      map[string]float64
+	tldOther float64 // TLDs seen in neither class
+}
+
+// lexicalClass accumulates the feature counts of one class.
+type lexicalClass struct {
+	n       int
+	bigram  [lexicalSymbols * lexicalSymbols]int
+	bigrams int
+	bins    [][]int
+	tld     map[string]int
+}
+
+func newLexicalClass() *lexicalClass {
+	c := &lexicalClass{tld: make(map[string]int)}
+	for _, f := range lexicalBins {
+		c.bins = append(c.bins, make([]int, len(f.edges)+1))
+	}
+	return c
+}
+
+// splitLexical splits a list entry into its first label and last label
+// (TLD); entries are registrable domains, so the first label stands for the
+// registrable label.
+func splitLexical(d string) (label, tld string, ok bool) {
+	dot := strings.IndexByte(d, '.')
+	if dot <= 0 || strings.HasPrefix(d, "xn--") {
+		return "", "", false
+	}
+	return d[:dot], d[strings.LastIndexByte(d, '.')+1:], true
+}
+
+func (c *lexicalClass) add(d string) {
+	label, tld, ok := splitLexical(d)
+	if !ok {
+		return
+	}
+	c.n++
+	eachBigram(label, func(i int) { c.bigram[i]++; c.bigrams++ })
+	for i, f := range lexicalBins {
+		b, _ := f.bin(label)
+		c.bins[i][b]++
+	}
+	c.tld[tld]++
+}
+
+// llr is the add-one smoothed log-likelihood ratio of an outcome seen p times
+// among np positive and n times among nn negative observations over k
+// possible outcomes.
+func llr(p, np, n, nn, k int) float64 {
+	return math.Log(float64(p+1)/float64(np+k)) - math.Log(float64(n+1)/float64(nn+k))
+}
+
+// trainLexical builds the classifier; entries of the negative set are left
+// out of the positive one.
+func trainLexical(block, allow listIndex) *lexicalModel {
+	neg := newLexicalClass()
+	seen := make(map[string]bool)
+	addNeg := func(d string) {
+		if !seen[d] {
+			seen[d] = true
+			neg.add(d)
+		}
+	}
+	for _, line := range strings.Split(lexicalReference, "\n") {
+		if line = strings.TrimSpace(line); line != "" && !strings.HasPrefix(line, "#") {
+			addNeg(line)
+		}
+	}
+	allow.each(func(d string, _ int) { addNeg(d) })
+	pos := newLexicalClass()
+	block.each(func(d string, _ int) {
+		if !seen[d] {
+			pos.add(d)
+		}
+	})
+	if pos.n == 0 {
+		return nil
+	}
+
+	m := &lexicalModel{tld: make(map[string]float64)}
+	k := lexicalSymbols * lexicalSymbols
+	for i := range m.bigram {
+		m.bigram[i] = llr(pos.bigram[i], pos.bigrams, neg.bigram[i], neg.bigrams, k)
+	}
+	for i := range lexicalBins {
+		nb := len(pos.bins[i])
+		row := make([]float64, nb)
+		for b := range row {
+			row[b] = llr(pos.bins[i][b], pos.n, neg.bins[i][b], neg.n, nb)
+		}
+		m.bins = append(m.bins, row)
+	}
+	tlds := make(map[string]bool)
+	for t := range pos.tld {
+		tlds[t] = true
+	}
+	for t := range neg.tld {
+		tlds[t] = true
+	}
+	k = len(tlds) + 1
+	for t := range tlds {
+		m.tld[t] = llr(pos.tld[t], pos.n, neg.tld[t], neg.n, k)
+	}
+	m.tldOther = llr(0, pos.n, 0, neg.n, k)
+	return m
+}
+
+// assess classifies a registrable label under tld and reports whether it
+// reaches threshold.
+func (m *lexicalModel) assess(label, tld string, threshold float64) (*SuspectDetail, bool) {
+	var feats []SuspectFeature
+	total := 0.0
+	add := func(name, value string, v float64) {
+		total += v
+		feats = append(feats, SuspectFeature{Feature: name, Value: value, Contribution: math.Round(v*100) / 100})
+	}
+	// Bigram evidence is averaged: the bigrams of one label are far from
+	// independent, and long labels would otherwise dominate.
+	sum, n := 0.0, 0
+	eachBigram(label, func(i int) { sum += m.bigram[i]; n++ })
+	add("ngrams", strconv.Itoa(n)+" bigrams", sum/float64(n))
+	for i, f := range lexicalBins {
+		b, v := f.bin(label)
+		add(f.name, strconv.FormatFloat(math.Round(v*100)/100, 'f', -1, 64), m.bins[i][b])
+	}
+	t, ok := m.tld[tld]
+	if !ok {
+		t = m.tldOther
+	}
+	add("tld", tld, t)
+	slices.SortStableFunc(feats, func(a, b SuspectFeature) int {
+		switch {
+		case a.Contribution > b.Contribution:
+			return -1
+		case a.Contribution < b.Contribution:
+			return 1
+		}
+		return 0
+	})
+	p := 1 / (1 + math.Exp(-total))
+	return &SuspectDetail{Probability: math.Round(p*1000) / 1000, Threshold: threshold, Features: feats}, p >= threshold
+}
+
+// summary names the features that pushed most towards disposable.
+func (d *SuspectDetail) summary() string {
+	var parts []string
+	for _, f := range d.Features {
+		if f.Contribution <= 0 || len(parts) == 3 {
+			break
+		}
+		parts = append(parts, f.Feature+"="+f.Value)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// suspectThreshold holds the float64 bits of the threshold; zero means
+// DefaultSuspectThreshold.
+type suspectThreshold struct{ bits atomic.Uint64 }
+
+func (s *suspectThreshold) load() float64 {
+	if b := s.bits.Load(); b != 0 {
+		return math.Float64frombits(b)
+	}
+	return DefaultSuspectThreshold
+}
+
+// SetSuspectThreshold sets the classifier probability (0-1] at or above
+// which neutral domains are reported as suspect.
+func (c *Checker) SetSuspectThreshold(t float64) {
+	c.suspect.bits.Store(math.Float64bits(t))
+}
@@ -0,0 +1,85 @@
+package domain
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+)
+
+func TestSuspectClassifier(t *testing.T) {
+	dir := t.TempDir()
+	allowPath := filepath.Join(dir, "allowlist.conf")
+	blockPath := filepath.Join(dir, "blocklist.conf")
+	writeTempList(t, allowPath, []string{"bakery.com", "gardening.org"})
+	var block []string
+	for i := 0; i < 300; i++ {
+		block = append(block, fmt.Sprintf("q%dx%dz%d.tk", i, i*7, i*13), fmt.Sprintf("mail%d.xyz", i))
+	}
+	block = append(block, "bakery.com") // legitimate set wins
+	writeTempList(t, blockPath, block)
+	c := NewChecker(allowPath, blockPath)
+	if err := c.Load(); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+
+	for _, d := range []string{"k9x42z17.tk", "inbox77.xyz", "x7k2q9z1.tk"} {
+		res := c.Check(d)
+		if !res.Suspect || res.SuspectDetail == nil {
+			t.Fatalf("%s: expected suspect, got %+v", d, res.SuspectDetail)
+		}
+		if f := res.SuspectDetail.Features[0]; f.Contribution <= 0 {
+			t.Fatalf("%s: expected features ordered by contribution, got %+v", d, res.SuspectDetail.Features)
+		}
+	}
+	for _, d := range []string{"wikipedia.org", "spiegel.de", "bbc.co.uk"} {
+		if res := c.Check(d); res.Suspect {
+			t.Fatalf("%s: unexpected suspect %+v", d, res.SuspectDetail)
+		}
+	}
+
+	// The verdict raises the lexical signal instead of stacking on it.
+	res := c.Check("k9x42z17.tk")
+	if len(res.ScoreSignals) != 1 || res.ScoreSignals[0].Signal != SignalLexical || res.ScoreSignals[0].Strength != 1 || res.ScoreSignals[0].Detail != res.SuspectDetail.summary() {
+		t.Fatalf("expected one full-strength lexical signal, got %+v", res.ScoreSignals)
+	}
+	// Listed domains are not classified.
+	if res := c.Check("mail1.xyz"); res.SuspectDetail != nil || res.Suspect {
+		t.Fatalf("blocklisted domain classified: %+v", res.SuspectDetail)
+	}
+
+	c.SetSuspectThreshold(1)
+	if res := c.Check("inbox77.xyz"); res.Suspect || res.SuspectDetail.Threshold != 1 {
+		t.Fatalf("threshold not applied: %+v", res.SuspectDetail)
+	}
+
+	// Derived checkers reuse the model instead of training their own.
+	oldBlock := filepath.Join(dir, "old-blocklist.conf")
+	writeTempList(t, oldBlock, []string{"mail1.xyz"})
+	d, err := c.Derive(allowPath, oldBlock)
+	if err != nil {
+		t.Fatalf("derive: %v", err)
+	}
+	if d.current().lexical != c.current().lexical {
+		t.Fatal("derived checker retrained the lexical model")
+	}
+}
+
+// TestSuspectRealBrands trains on the shipped lists: short and hyphenated
+// brand names must stay below the default threshold while generated names
+// with similar traits do not.
+func TestSuspectRealBrands(t *testing.T) {
+	c := NewChecker("../../allowlist.conf", "../../blocklist.conf")
+	if err := c.Load(); err != nil {
+		t.Skipf("shipped lists unavailable: %v", err)
+	}
+	for _, d := range []string{"3m.com", "7-eleven.com", "hp.com", "o2.co.uk", "t-mobile.com", "coca-cola.com", "mercedes-benz.com", "harley-davidson.com", "9gag.com", "stackoverflow.com"} {
+		if res := c.Check(d); res.Status != "neutral" || res.Suspect {
+			t.Errorf("%s: status %s, unexpected suspect %+v", d, res.Status, res.SuspectDetail)
+		}
+	}
+	for _, d := range []string{"k9x42z17.tk", "qz8xv3m1.net", "0-31-25.com", "mail4839201.com", "tmp-99231.com", "a1b2c3d4e5.com"} {
+		if res := c.Check(d); res.Status == "neutral" && !res.Suspect {
+			t.Errorf("%s: expected suspect, got %+v", d, res.SuspectDetail)
+		}
+	}
+}
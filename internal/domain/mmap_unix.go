@@ -0,0 +1,30 @@
+//go:build linux || darwin || freebsd || netbsd || openbsd
+
+package domain
+
+import (
+	"os"
+	"syscall"
+)
+
+// mmapFile maps the file at path read-only. The mapping stays valid when the
+// file is replaced by rename, which is how index files are rewritten.
+func mmapFile(path string) ([]byte, func() error, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer f.Close()
+	fi, err := f.Stat()
+	if err != nil {
+		return nil, nil, err
+	}
+	if fi.Size() == 0 {
+		return nil, func() error { return nil }, nil
+	}
+	data, err := syscall.Mmap(int(f.Fd()), 0, int(fi.Size()), syscall.PROT_READ, syscall.MAP_SHARED)
+	if err != nil {
+		return nil, nil, err
+	}
+	return data, func() error { return syscall.Munmap(data) }, nil
+}
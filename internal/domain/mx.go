@@ -0,0 +1,364 @@
+package domain
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"log"
+	"net"
+	"net/netip"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"disposable-email-domains/internal/metrics"
+)
+
+// Resolver is the subset of *net.Resolver used by the MX detector, so tests
+// and deployments can plug in their own DNS source.
+type Resolver interface {
+	LookupMX(ctx context.Context, name string) ([]*net.MX, error)
+	LookupIPAddr(ctx context.Context, host string) ([]net.IPAddr, error)
+}
+
+// MXInfo describes the mail exchangers of a domain and whether they belong to
+// known disposable infrastructure.
+type MXInfo struct {
+	Hosts        []string `json:"hosts,omitempty"`
+	IPs          []string `json:"ips,omitempty"`
+	ImplicitMX   bool     `json:"implicit_mx,omitempty"` // no MX records; the domain's own A/AAAA records were used (RFC 5321 5.1)
+	NullMX       bool     `json:"null_mx,omitempty"`     // domain publishes "MX 0 ." and accepts no mail (RFC 7505)
+	Disposable   bool     `json:"disposable"`
+	MatchedHost  string   `json:"matched_host,omitempty"`
+	MatchedIP    string   `json:"matched_ip,omitempty"`
+	MatchedEntry string   `json:"matched_entry,omitempty"` // infrastructure list entry that matched
+	Error        string   `json:"error,omitempty"`
+}
+
+// maxMXHosts bounds the number of exchangers resolved per domain.
+const maxMXHosts = 5
+
+// InfraList is a parsed disposable infrastructure list. Hostname entries
+// match the host itself and all of its subdomains; IP and CIDR entries match
+// the resolved addresses of the exchangers.
+type InfraList struct {
+	hosts    map[string]struct{}
+	prefixes []netip.Prefix
+}
+
+// ParseInfraList reads one hostname, IP address or CIDR prefix per line.
+// Blank lines and lines starting with '#' are ignored.
+func ParseInfraList(r io.Reader) (*InfraList, error) {
+	l := &InfraList{hosts: make(map[string]struct{})}
+	s := bufio.NewScanner(r)
+	n := 0
+	for s.Scan() {
+		n++
+		line := strings.TrimSpace(s.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if p, err := netip.ParsePrefix(line); err == nil {
+			l.prefixes = append(l.prefixes, p.Masked())
+			continue
+		}
+		if ip, err := netip.ParseAddr(line); err == nil {
+			l.prefixes = append(l.prefixes, netip.PrefixFrom(ip, ip.BitLen()))
+			continue
+		}
+		host := normalizeListEntry(strings.TrimSuffix(line, "."))
+		if err := validRuleSuffix(host); err != nil {
+			return nil, errors.New("infrastructure list line " + strconv.Itoa(n) + ": " + line + ": " + err.Error())
+		}
+		l.hosts[host] = struct{}{}
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// LoadInfraList reads the infrastructure list at path. A missing file yields
+// an empty list.
+func LoadInfraList(path string) (*InfraList, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &InfraList{hosts: map[string]struct{}{}}, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+	return ParseInfraList(f)
+}
+
+// Len returns the number of entries in the list.
+func (l *InfraList) Len() int {
+	if l == nil {
+		return 0
+	}
+	return len(l.hosts) + len(l.prefixes)
+}
+
+func (l *InfraList) matchHost(host string) (string, bool) {
+	if l == nil {
+		return "", false
+	}
+	for s := host; s != ""; {
+		if _, ok := l.hosts[s]; ok {
+			return s, true
+		}
+		dot := strings.IndexByte(s, '.')
+		if dot == -1 {
+			break
+		}
+		s = s[dot+1:]
+	}
+	return "", false
+}
+
+func (l *InfraList) matchIP(ip netip.Addr) (string, bool) {
+	if l == nil {
+		return "", false
+	}
+	ip = ip.Unmap()
+	for _, p := range l.prefixes {
+		if p.Contains(ip) {
+			if p.IsSingleIP() {
+				return p.Addr().String(), true
+			}
+			return p.String(), true
+		}
+	}
+	return "", false
+}
+
+// MXDetector resolves the mail exchangers of a domain and matches them
+// against an InfraList. Results are cached per domain for CacheTTL; lookup
+// failures other than "no such host" are not cached. Concurrent lookups of
+// one domain share a single resolution. Without an infrastructure list the
+// detector is disabled and Check skips it.
+type MXDetector struct {
+	Resolver Resolver
+	Timeout  time.Duration
+	CacheTTL time.Duration
+	// Path is re-read by Reload (and by Checker.Load); empty disables reloads.
+	Path string
+	// Logger receives reload failures from Checker.Load; nil discards them.
+	Logger *log.Logger
+
+	infra atomic.Pointer[InfraList]
+
+	mu       sync.Mutex
+	cache    map[string]mxCacheEntry
+	inflight map[string]*mxCall
+}
+
+type mxCacheEntry struct {
+	info    MXInfo
+	expires time.Time
+}
+
+// mxCall is a lookup in progress; info is set before done is closed.
+type mxCall struct {
+	done chan struct{}
+	info MXInfo
+}
+
+type noMXKey struct{}
+
+// WithoutMX returns a context under which checks skip the MX lookup, e.g.
+// for batches where a DNS round trip per item would dominate.
+func WithoutMX(ctx context.Context) context.Context {
+	return context.WithValue(ctx, noMXKey{}, true)
+}
+
+func mxSkipped(ctx context.Context) bool {
+	skip, _ := ctx.Value(noMXKey{}).(bool)
+	return skip
+}
+
+// maxMXCacheEntries caps the cache; it is cleared when full.
+const maxMXCacheEntries = 50_000
+
+// NewMXDetector returns a detector using r (net.DefaultResolver when nil).
+func NewMXDetector(r Resolver, infra *InfraList) *MXDetector {
+	if r == nil {
+		r = net.DefaultResolver
+	}
+	d := &MXDetector{Resolver: r, Timeout: 2 * time.Second, CacheTTL: 10 * time.Minute, cache: make(map[string]mxCacheEntry), inflight: make(map[string]*mxCall)}
+	d.SetInfra(infra)
+	return d
+}
+
+// SetInfra swaps the infrastructure list and drops cached verdicts; nil
+// disables detection.
+func (d *MXDetector) SetInfra(l *InfraList) {
+	d.infra.Store(l)
+	d.mu.Lock()
+	d.cache = make(map[string]mxCacheEntry)
+	d.mu.Unlock()
+	metrics.MXInfraEntriesGauge.Set(float64(l.Len()))
+}
+
+// Reload re-reads the infrastructure list from Path. A malformed list
+// disables detection until a later Reload succeeds, since verdicts from a
+// partial list would be inconsistent.
+func (d *MXDetector) Reload() error {
+	if d.Path == "" {
+		return nil
+	}
+	l, err := LoadInfraList(d.Path)
+	if err != nil {
+		d.SetInfra(nil)
+		return err
+	}
+	d.SetInfra(l)
+	return nil
+}
+
+// Enabled reports whether an infrastructure list is loaded.
+func (d *MXDetector) Enabled() bool {
+	return d.infra.Load() != nil
+}
+
+// InfraCount returns the number of entries in the active infrastructure list.
+func (d *MXDetector) InfraCount() int {
+	return d.infra.Load().Len()
+}
+
+// Lookup resolves the exchangers of the normalized domain and reports whether
+// any host name or address belongs to disposable infrastructure. Domains
+// without MX records fall back to their own address records.
+func (d *MXDetector) Lookup(ctx context.Context, domain string) MXInfo {
+	now := time.Now()
+	d.mu.Lock()
+	if e, ok := d.cache[domain]; ok && now.Before(e.expires) {
+		d.mu.Unlock()
+		metrics.MXLookupsTotal.WithLabelValues("cached").Inc()
+		return e.info
+	}
+	if call, ok := d.inflight[domain]; ok {
+		d.mu.Unlock()
+		select {
+		case <-call.done:
+			metrics.MXLookupsTotal.WithLabelValues("cached").Inc()
+			return call.info
+		case <-ctx.Done():
+			return MXInfo{Error: ctx.Err().Error()}
+		}
+	}
+	if d.inflight == nil {
+		d.inflight = make(map[string]*mxCall)
+	}
+	call := &mxCall{done: make(chan struct{})}
+	d.inflight[domain] = call
+	d.mu.Unlock()
+	defer func() {
+		d.mu.Lock()
+		delete(d.inflight, domain)
+		d.mu.Unlock()
+		close(call.done)
+	}()
+
+	if d.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, d.Timeout)
+		defer cancel()
+	}
+	info, cacheable := d.lookup(ctx, domain)
+	switch {
+	case info.Error != "":
+		metrics.MXLookupsTotal.WithLabelValues("error").Inc()
+	case info.Disposable:
+		metrics.MXLookupsTotal.WithLabelValues("disposable").Inc()
+	default:
+		metrics.MXLookupsTotal.WithLabelValues("clean").Inc()
+	}
+	if cacheable && d.CacheTTL > 0 {
+		d.mu.Lock()
+		if len(d.cache) >= maxMXCacheEntries {
+			d.cache = make(map[string]mxCacheEntry)
+		}
+		d.cache[domain] = mxCacheEntry{info: info, expires: now.Add(d.CacheTTL)}
+		d.mu.Unlock()
+	}
+	call.info = info
+	return info
+}
+
+func (d *MXDetector) lookup(ctx context.Context, domain string) (MXInfo, bool) {
+	var info MXInfo
+	infra := d.infra.Load()
+	mxs, err := d.Resolver.LookupMX(ctx, domain)
+	if err != nil && !isNotFound(err) {
+		info.Error = err.Error()
+		return info, false
+	}
+	sort.SliceStable(mxs, func(i, j int) bool { return mxs[i].Pref < mxs[j].Pref })
+	for _, mx := range mxs {
+		h := strings.ToLower(strings.TrimSuffix(mx.Host, "."))
+		if h == "" {
+			if len(mxs) == 1 {
+				info.NullMX = true
+			}
+			continue
+		}
+		info.Hosts = append(info.Hosts, h)
+	}
+	if info.NullMX {
+		return info, true
+	}
+	if len(info.Hosts) == 0 {
+		info.ImplicitMX = true
+		info.Hosts = []string{domain}
+	}
+	if len(info.Hosts) > maxMXHosts {
+		info.Hosts = info.Hosts[:maxMXHosts]
+	}
+	for _, h := range info.Hosts {
+		if e, ok := infra.matchHost(h); ok && !info.Disposable {
+			info.Disposable, info.MatchedHost, info.MatchedEntry = true, h, e
+		}
+	}
+	cacheable := true
+	for _, h := range info.Hosts {
+		addrs, err := d.Resolver.LookupIPAddr(ctx, h)
+		if err != nil {
+			if !isNotFound(err) {
+				cacheable = false
+				if info.Error == "" {
+					info.Error = err.Error()
+				}
+			}
+			continue
+		}
+		for _, a := range addrs {
+			ip, ok := netip.AddrFromSlice(a.IP)
+			if !ok {
+				continue
+			}
+			ip = ip.Unmap()
+			info.IPs = append(info.IPs, ip.String())
+			if e, ok := infra.matchIP(ip); ok && !info.Disposable {
+				info.Disposable, info.MatchedHost, info.MatchedIP, info.MatchedEntry = true, h, ip.String(), e
+			}
+		}
+	}
+	// A positive match is conclusive even if some exchangers failed to resolve.
+	if info.Disposable {
+		info.Error = ""
+		cacheable = true
+	}
+	return info, cacheable
+}
+
+func isNotFound(err error) bool {
+	var dnsErr *net.DNSError
+	return errors.As(err, &dnsErr) && dnsErr.IsNotFound
+}
@@ -0,0 +1,128 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"disposable-email-domains/internal/dnstest"
+)
+
+func TestMXDetector(t *testing.T) {
+	srv, err := dnstest.NewServer()
+	if err != nil {
+		t.Fatalf("dns server: %v", err)
+	}
+	defer srv.Close()
+	srv.AddMX("fresh-burner.com", dnstest.MX{Host: "mx1.burner-infra.net", Pref: 10})
+	srv.AddIP("mx1.burner-infra.net", "192.0.2.10")
+	srv.AddMX("by-ip.com", dnstest.MX{Host: "mail.by-ip.com", Pref: 10})
+	srv.AddIP("mail.by-ip.com", "198.51.100.7")
+	srv.AddMX("clean.com", dnstest.MX{Host: "mx.clean.com", Pref: 10})
+	srv.AddIP("mx.clean.com", "203.0.113.5", "2001:db8::5")
+	srv.AddIP("a-only.com", "192.0.2.99")
+	srv.AddMX("nullmx.com", dnstest.MX{Host: ".", Pref: 0})
+
+	infra, err := ParseInfraList(strings.NewReader("# test\nburner-infra.net\n198.51.100.0/24\n192.0.2.99\n"))
+	if err != nil {
+		t.Fatalf("parse infra: %v", err)
+	}
+	if infra.Len() != 3 {
+		t.Fatalf("expected 3 entries, got %d", infra.Len())
+	}
+
+	dir := t.TempDir()
+	allowPath := filepath.Join(dir, "allowlist.conf")
+	blockPath := filepath.Join(dir, "blocklist.conf")
+	writeTempList(t, allowPath, []string{"good.com"})
+	writeTempList(t, blockPath, []string{"bad.com"})
+	c := NewChecker(allowPath, blockPath)
+	if err := c.Load(); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	c.SetMXDetector(NewMXDetector(srv.Resolver(), infra))
+
+	res := c.Check("user@fresh-burner.com")
+	if res.Status != "block" || !res.MXDisposable || res.Blocklisted || res.MX == nil || res.MX.MatchedEntry != "burner-infra.net" {
+		t.Fatalf("expected MX host match, got %+v mx=%+v", res, res.MX)
+	}
+	res = c.Check("by-ip.com")
+	if !res.MXDisposable || res.MX.MatchedIP != "198.51.100.7" || res.MX.MatchedEntry != "198.51.100.0/24" {
+		t.Fatalf("expected MX address match, got %+v", res.MX)
+	}
+	res = c.Check("a-only.com")
+	if !res.MXDisposable || !res.MX.ImplicitMX {
+		t.Fatalf("expected implicit MX match, got %+v", res.MX)
+	}
+	res = c.Check("clean.com")
+	if res.Status != "neutral" || res.MXDisposable || len(res.MX.IPs) != 2 || res.MX.Hosts[0] != "mx.clean.com" {
+		t.Fatalf("expected clean MX, got %+v mx=%+v", res, res.MX)
+	}
+	if res = c.Check("nullmx.com"); !res.MX.NullMX || res.MXDisposable {
+		t.Fatalf("expected null MX, got %+v", res.MX)
+	}
+	if res = c.Check("missing.com"); res.MX.Error != "" || res.MXDisposable {
+		t.Fatalf("expected NXDOMAIN to be a clean verdict, got %+v", res.MX)
+	}
+
+	// List decisions win and skip DNS entirely; repeated lookups are cached.
+	before := srv.Queries()
+	if res = c.Check("good.com"); res.MX != nil {
+		t.Fatalf("allowlisted domain should not be resolved")
+	}
+	if res = c.Check("bad.com"); res.MX != nil || !res.Blocklisted {
+		t.Fatalf("blocklisted domain should not be resolved")
+	}
+	c.Check("fresh-burner.com")
+	if srv.Queries() != before {
+		t.Fatalf("expected no DNS traffic, got %d queries", srv.Queries()-before)
+	}
+	// Batches may skip the lookup.
+	if res = c.CheckContext(WithoutMX(context.Background()), "uncached.com"); res.MX != nil || srv.Queries() != before {
+		t.Fatalf("expected no lookup under WithoutMX, got %+v", res.MX)
+	}
+
+	// Concurrent checks of one domain share a single resolution.
+	srv.AddMX("shared.com", dnstest.MX{Host: "mx.shared.com", Pref: 10})
+	srv.AddIP("mx.shared.com", "203.0.113.9")
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			c.Check("shared.com")
+		}()
+	}
+	wg.Wait()
+	if n := srv.Queries() - before; n > 3 { // MX, A and AAAA
+		t.Fatalf("expected a single resolution, got %d queries", n)
+	}
+
+	// A malformed infrastructure list disables detection instead of failing
+	// the load.
+	d := c.MXDetector()
+	d.Path = filepath.Join(dir, "mx_infrastructure.conf")
+	writeTempList(t, d.Path, []string{"not a host!"})
+	// Lists rejected by strict validation leave the detector alone.
+	writeTempList(t, blockPath, []string{"bad.com", "com"})
+	if err := c.Reload(true); !errors.Is(err, ErrValidation) || !d.Enabled() {
+		t.Fatalf("expected rejected reload with detection kept, got %v enabled=%v", err, d.Enabled())
+	}
+	writeTempList(t, blockPath, []string{"bad.com"})
+	if err := c.Load(); err != nil {
+		t.Fatalf("load with malformed infrastructure list: %v", err)
+	}
+	if res = c.Check("fresh-burner.com"); d.Enabled() || res.MX != nil || res.Status != "neutral" {
+		t.Fatalf("expected MX detection disabled, got %+v", res.MX)
+	}
+	writeTempList(t, d.Path, []string{"burner-infra.net"})
+	if err := c.Load(); err != nil {
+		t.Fatal(err)
+	}
+	if res = c.Check("fresh-burner.com"); !res.MXDisposable {
+		t.Fatal("expected detection back after the list was fixed")
+	}
+}
@@ -0,0 +1,122 @@
+package domain
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Provenance records where a list entry came from.
+type Provenance struct {
+	AddedAt time.Time `json:"added_at"`
+	Source  string    `json:"source"`          // "manual" or the URL the entry was imported from
+	Actor   string    `json:"actor,omitempty"` // fingerprint of the admin token that added it
+}
+
+// SourceManual marks entries submitted directly in a request body.
+const SourceManual = "manual"
+
+// provenanceRecord is one line of a list's metadata sidecar.
+type provenanceRecord struct {
+	Entry string `json:"entry"`
+	Provenance
+}
+
+// metaPath returns the sidecar file holding provenance for the list at path.
+// The sidecar is JSON lines, appended to as entries are added and rewritten
+// when entries are removed; the last record for an entry wins.
+func metaPath(path string) string { return path + ".meta" }
+
+// provenanceKey maps a list line to the key used in the metadata store:
+// the lookup form for domains and the raw text for patterns.
+func provenanceKey(entry string) string {
+	entry = strings.TrimSpace(entry)
+	if isRuleLine(entry) {
+		return entry
+	}
+	return normalizeListEntry(entry)
+}
+
+func lookupProvenance(meta map[string]Provenance, key string) *Provenance {
+	if p, ok := meta[key]; ok {
+		return &p
+	}
+	return nil
+}
+
+// readMetaFile loads a metadata sidecar. A missing file yields an empty map;
+// malformed lines are skipped.
+func readMetaFile(path string) (map[string]Provenance, error) {
+	out := make(map[string]Provenance)
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return out, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+	s := bufio.NewScanner(f)
+	s.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for s.Scan() {
+		line := bytes.TrimSpace(s.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		var rec provenanceRecord
+		if json.Unmarshal(line, &rec) != nil || rec.Entry == "" {
+			continue
+		}
+		out[provenanceKey(rec.Entry)] = rec.Provenance
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// appendMetaFile appends one record per entry to the sidecar at path.
+func appendMetaFile(path string, entries []string, p Provenance) error {
+	var b bytes.Buffer
+	enc := json.NewEncoder(&b)
+	for _, e := range entries {
+		if err := enc.Encode(provenanceRecord{Entry: e, Provenance: p}); err != nil {
+			return err
+		}
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(b.Bytes()); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// writeMetaFile replaces the sidecar at path with one record per entry of
+// meta, sorted by entry.
+func writeMetaFile(path string, meta map[string]Provenance) error {
+	data, err := encodeMeta(meta)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(path, data)
+}
+
+func encodeMeta(meta map[string]Provenance) ([]byte, error) {
+	var b bytes.Buffer
+	enc := json.NewEncoder(&b)
+	for _, e := range slices.Sorted(maps.Keys(meta)) {
+		if err := enc.Encode(provenanceRecord{Entry: e, Provenance: meta[e]}); err != nil {
+			return nil, err
+		}
+	}
+	return b.Bytes(), nil
+}
@@ -0,0 +1,69 @@
+package domain
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPatchBlockProvenance(t *testing.T) {
+	dir := t.TempDir()
+	allowPath := filepath.Join(dir, "allowlist.conf")
+	blockPath := filepath.Join(dir, "blocklist.conf")
+	writeTempList(t, allowPath, []string{"good.com"})
+	writeTempList(t, blockPath, []string{"bad.com"})
+	c := NewChecker(allowPath, blockPath)
+	if err := c.Load(); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+
+	at := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	imported := Provenance{AddedAt: at, Source: "https://lists.example/disposable.txt", Actor: "token:abc"}
+	// The caller appends to the list file first, then patches.
+	f, err := os.OpenFile(blockPath, os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	_, _ = f.WriteString("burner.io\n*.temp.net\n")
+	_ = f.Close()
+	if err := c.PatchBlockFrom([]string{"burner.io", "*.temp.net", "bad.com"}, imported); err != nil {
+		t.Fatalf("patch: %v", err)
+	}
+	meta, err := os.ReadFile(metaPath(blockPath))
+	if err != nil {
+		t.Fatalf("read sidecar: %v", err)
+	}
+	if n := strings.Count(string(meta), "\n"); n != 2 {
+		t.Fatalf("expected 2 sidecar records (duplicate skipped), got %d:\n%s", n, meta)
+	}
+
+	check := func(label string) {
+		res := c.Check("x.burner.io")
+		if len(res.Matches) != 1 || res.Matches[0].Provenance == nil || *res.Matches[0].Provenance != imported {
+			t.Fatalf("%s: expected provenance on match, got %+v", label, res.Matches)
+		}
+		if res = c.Check("a.temp.net"); len(res.Matches) != 1 || res.Matches[0].Provenance == nil || res.Matches[0].Provenance.Source != imported.Source {
+			t.Fatalf("%s: expected provenance on pattern match, got %+v", label, res.Matches)
+		}
+		if res = c.Check("bad.com"); res.Matches[0].Provenance != nil {
+			t.Fatalf("%s: pre-existing entry should have no provenance", label)
+		}
+		if p, ok := c.BlockProvenance("Burner.IO"); !ok || p.Actor != "token:abc" {
+			t.Fatalf("%s: BlockProvenance = %+v, %v", label, p, ok)
+		}
+		// Patterns are not exact entries.
+		if got := c.BlockEntriesFrom(imported.Source); len(got) != 1 || got[0] != "burner.io" {
+			t.Fatalf("%s: BlockEntriesFrom = %v", label, got)
+		}
+	}
+	check("after patch")
+	if err := c.Load(); err != nil {
+		t.Fatalf("reload: %v", err)
+	}
+	check("after reload")
+	if steps := strings.Join(c.Check("burner.io").Explain(), "\n"); !strings.Contains(steps, "from https://lists.example/disposable.txt by token:abc") {
+		t.Fatalf("explanation lacks provenance:\n%s", steps)
+	}
+}
@@ -0,0 +1,216 @@
+package domain
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"golang.org/x/net/idna"
+	"golang.org/x/net/publicsuffix"
+)
+
+// PSLInfo identifies the public suffix list snapshot that produced a verdict.
+type PSLInfo struct {
+	Source   string    `json:"source"`            // "file" or "builtin"
+	Version  string    `json:"version,omitempty"` // VERSION header of the .dat file
+	Commit   string    `json:"commit,omitempty"`  // COMMIT header of the .dat file
+	SHA256   string    `json:"sha256,omitempty"`
+	Rules    int       `json:"rules,omitempty"`
+	LoadedAt time.Time `json:"loaded_at,omitempty"`
+}
+
+// Rule flags stored per PSL key.
+const (
+	pslNormal    uint8 = 1 << iota // "foo.bar"
+	pslWildcard                    // "*.foo.bar" (stored under "foo.bar")
+	pslException                   // "!foo.bar"
+	pslPrivate                     // rule declared in the PRIVATE section
+)
+
+// PSL is an immutable, parsed public suffix list. It implements the matching
+// algorithm from https://publicsuffix.org/list/ including wildcard and
+// exception rules, and remembers whether a rule came from the ICANN or the
+// PRIVATE section.
+type PSL struct {
+	rules map[string]uint8
+	info  PSLInfo
+}
+
+// ParsePSL parses the contents of a public_suffix_list.dat file. Rules are
+// lowercased and converted to their ASCII (punycode) form so lookups match
+// normalized domains.
+func ParsePSL(data []byte) (*PSL, error) {
+	p := &PSL{rules: make(map[string]uint8, 16384)}
+	sum := sha256.Sum256(data)
+	p.info = PSLInfo{Source: "file", SHA256: hex.EncodeToString(sum[:]), LoadedAt: time.Now().UTC()}
+
+	private := false
+	sawICANN := false
+	s := bufio.NewScanner(bytes.NewReader(data))
+	for s.Scan() {
+		line := strings.TrimSpace(s.Text())
+		if line == "" {
+			continue
+		}
+		if strings.HasPrefix(line, "//") {
+			c := strings.TrimSpace(strings.TrimPrefix(line, "//"))
+			switch {
+			case strings.HasPrefix(c, "VERSION:"):
+				p.info.Version = strings.TrimSpace(strings.TrimPrefix(c, "VERSION:"))
+			case strings.HasPrefix(c, "COMMIT:"):
+				p.info.Commit = strings.TrimSpace(strings.TrimPrefix(c, "COMMIT:"))
+			case strings.Contains(c, "===BEGIN ICANN DOMAINS==="):
+				sawICANN = true
+				private = false
+			case strings.Contains(c, "===BEGIN PRIVATE DOMAINS==="):
+				private = true
+			}
+			continue
+		}
+		// Rules end at the first whitespace per the PSL format.
+		if i := strings.IndexAny(line, " \t"); i != -1 {
+			line = line[:i]
+		}
+		var flag uint8
+		switch {
+		case strings.HasPrefix(line, "!"):
+			flag = pslException
+			line = line[1:]
+		case strings.HasPrefix(line, "*."):
+			flag = pslWildcard
+			line = line[2:]
+		default:
+			flag = pslNormal
+		}
+		key := pslKey(line)
+		if key == "" {
+			continue
+		}
+		if private {
+			flag |= pslPrivate
+		}
+		p.rules[key] |= flag
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+	if !sawICANN {
+		return nil, errors.New("psl: missing ICANN section")
+	}
+	p.info.Rules = len(p.rules)
+	return p, nil
+}
+
+// LoadPSLFile reads and parses a public_suffix_list.dat file from disk.
+func LoadPSLFile(path string) (*PSL, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParsePSL(data)
+}
+
+func pslKey(rule string) string {
+	rule = strings.ToLower(strings.Trim(rule, "."))
+	if rule == "" {
+		return ""
+	}
+	if ascii, err := idna.ToASCII(rule); err == nil {
+		return ascii
+	}
+	return rule
+}
+
+// Info returns metadata describing this snapshot.
+func (p *PSL) Info() PSLInfo { return p.info }
+
+// PublicSuffix returns the public suffix of domain and whether it is managed
+// by ICANN (false for PRIVATE section rules and for the implicit "*" rule).
+// Semantics match golang.org/x/net/publicsuffix.PublicSuffix.
+func (p *PSL) PublicSuffix(domain string) (suffix string, icann bool) {
+	// Walk candidate suffixes from the TLD towards the full name so that later
+	// matches are longer. Exception rules take priority over everything else.
+	best := ""
+	bestFlags := uint8(0)
+	parent := ""
+	for i := len(domain); i >= 0; {
+		j := strings.LastIndexByte(domain[:i], '.')
+		cand := domain[j+1:]
+		f := p.rules[cand]
+		if f&pslException != 0 {
+			return parent, f&pslPrivate == 0
+		}
+		if f&pslNormal != 0 {
+			best, bestFlags = cand, f
+		}
+		if parent != "" {
+			if pf := p.rules[parent]; pf&pslWildcard != 0 {
+				best, bestFlags = cand, pf
+			}
+		}
+		if j == -1 {
+			break
+		}
+		parent = cand
+		i = j
+	}
+	if best == "" {
+		// Implicit "*" rule: the last label is the public suffix.
+		return domain[strings.LastIndexByte(domain, '.')+1:], false
+	}
+	return best, bestFlags&pslPrivate == 0
+}
+
+// EffectiveTLDPlusOne returns the public suffix of domain plus one label.
+// Semantics match golang.org/x/net/publicsuffix.EffectiveTLDPlusOne.
+func (p *PSL) EffectiveTLDPlusOne(domain string) (string, error) {
+	return etldPlusOne(domain, p.PublicSuffix)
+}
+
+func etldPlusOne(domain string, ps func(string) (string, bool)) (string, error) {
+	suffix, _ := ps(domain)
+	return etld1FromSuffix(domain, suffix)
+}
+
+// etld1FromSuffix derives the eTLD+1 of domain from its already computed
+// public suffix, saving a second walk of the suffix list.
+func etld1FromSuffix(domain, suffix string) (string, error) {
+	if strings.HasPrefix(domain, ".") || strings.HasSuffix(domain, ".") || strings.Contains(domain, "..") {
+		return "", fmt.Errorf("publicsuffix: empty label in domain %q", domain)
+	}
+	if len(domain) <= len(suffix) {
+		return "", fmt.Errorf("publicsuffix: cannot derive eTLD+1 for domain %q", domain)
+	}
+	i := len(domain) - len(suffix) - 1
+	if domain[i] != '.' {
+		return "", fmt.Errorf("publicsuffix: invalid public suffix %q for domain %q", suffix, domain)
+	}
+	return domain[1+strings.LastIndexByte(domain[:i], '.'):], nil
+}
+
+// suffixList is satisfied by *PSL and by the compiled-in table wrapper.
+type suffixList interface {
+	PublicSuffix(domain string) (string, bool)
+	EffectiveTLDPlusOne(domain string) (string, error)
+	Info() PSLInfo
+}
+
+// builtinPSL delegates to the table compiled into golang.org/x/net/publicsuffix.
+// It is used until a snapshot file has been loaded.
+type builtinPSL struct{}
+
+func (builtinPSL) PublicSuffix(domain string) (string, bool) {
+	return publicsuffix.PublicSuffix(domain)
+}
+
+func (builtinPSL) EffectiveTLDPlusOne(domain string) (string, error) {
+	return publicsuffix.EffectiveTLDPlusOne(domain)
+}
+
+func (builtinPSL) Info() PSLInfo { return PSLInfo{Source: "builtin"} }
@@ -0,0 +1,89 @@
+package domain
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+const testPSL = `// VERSION: 2026-01-01_00-00-00_UTC
+// COMMIT: abc123
+
+// ===BEGIN ICANN DOMAINS===
+com
+uk
+co.uk
+jp
+*.kawasaki.jp
+!city.kawasaki.jp
+*.ck
+!www.ck
+// ===END ICANN DOMAINS===
+// ===BEGIN PRIVATE DOMAINS===
+github.io
+// ===END PRIVATE DOMAINS===
+`
+
+func TestPSLRules(t *testing.T) {
+	p, err := ParsePSL([]byte(testPSL))
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	cases := []struct {
+		in, suffix, etld1 string
+		icann             bool
+	}{
+		{"example.com", "com", "example.com", true},
+		{"a.b.example.co.uk", "co.uk", "example.co.uk", true},
+		{"foo.bar.kawasaki.jp", "bar.kawasaki.jp", "foo.bar.kawasaki.jp", true},
+		{"city.kawasaki.jp", "kawasaki.jp", "city.kawasaki.jp", true},
+		{"www.ck", "ck", "www.ck", true},
+		{"a.b.ck", "b.ck", "a.b.ck", true},
+		{"user.github.io", "github.io", "user.github.io", false},
+		{"example.zzz", "zzz", "example.zzz", false},
+	}
+	for _, c := range cases {
+		ps, icann := p.PublicSuffix(c.in)
+		if ps != c.suffix || icann != c.icann {
+			t.Errorf("PublicSuffix(%q) = %q,%v want %q,%v", c.in, ps, icann, c.suffix, c.icann)
+		}
+		etld1, err := p.EffectiveTLDPlusOne(c.in)
+		if err != nil || etld1 != c.etld1 {
+			t.Errorf("EffectiveTLDPlusOne(%q) = %q,%v want %q", c.in, etld1, err, c.etld1)
+		}
+	}
+	if info := p.Info(); info.Version != "2026-01-01_00-00-00_UTC" || info.Commit != "abc123" || info.SHA256 == "" {
+		t.Fatalf("unexpected info: %+v", info)
+	}
+	if _, err := ParsePSL([]byte("com\nnet\n")); err == nil {
+		t.Fatalf("expected error for list without ICANN section")
+	}
+}
+
+// TestCheckerSetPSL ensures Check switches from the builtin table to a
+// runtime snapshot once one is swapped in.
+func TestCheckerSetPSL(t *testing.T) {
+	dir := t.TempDir()
+	allowPath := filepath.Join(dir, "allowlist.conf")
+	blockPath := filepath.Join(dir, "blocklist.conf")
+	writeTempList(t, allowPath, []string{"good.com"})
+	writeTempList(t, blockPath, []string{"bar.github.io"})
+	c := NewChecker(allowPath, blockPath)
+	if err := c.Load(); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if got := c.Check("x.bar.github.io").PSL.Source; got != "builtin" {
+		t.Fatalf("expected builtin PSL before swap, got %q", got)
+	}
+	p, err := ParsePSL([]byte(testPSL))
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	c.SetPSL(p)
+	res := c.Check("x.bar.github.io")
+	if res.PSL.SHA256 != p.Info().SHA256 {
+		t.Fatalf("expected result to report swapped PSL, got %+v", res.PSL)
+	}
+	if res.RegistrableDomain != "bar.github.io" || !res.Blocklisted {
+		t.Fatalf("expected block via runtime eTLD+1, got %+v", res)
+	}
+}
@@ -0,0 +1,140 @@
+package domain
+
+import (
+	"os"
+	"slices"
+	"strings"
+)
+
+// roleList holds the role account entries of rolelist.conf.
+type roleList struct {
+	path     string
+	exact    map[string]int // folded entry -> 1-based line
+	prefixes []rolePrefix   // entries with a trailing *
+	raw      []string
+}
+
+type rolePrefix struct {
+	key  string
+	line int
+}
+
+// roleKey folds a local part (or role entry) to the form entries are compared
+// in: lowercase, without a +tag and without the separators '.', '-' and '_',
+// so no-reply, No.Reply+x and noreply are the same mailbox name.
+func roleKey(local string) string {
+	local = strings.ToLower(strings.Trim(local, `"`))
+	if i := strings.IndexByte(local, '+'); i > 0 {
+		local = local[:i]
+	}
+	return strings.Map(func(r rune) rune {
+		if r == '.' || r == '-' || r == '_' {
+			return -1
+		}
+		return r
+	}, local)
+}
+
+// readRoleFile parses a role list; a missing file has no entries. Invalid
+// and duplicate entries are skipped; Validate reports them.
+func readRoleFile(path string) (*roleList, error) {
+	rl := &roleList{path: path, exact: make(map[string]int)}
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return rl, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+	err = scanList(f, func(n int, line string) {
+		rl.raw = append(rl.raw, line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			return
+		}
+		if !validRoleEntry(line) {
+			return
+		}
+		entry, prefix := strings.CutSuffix(line, "*")
+		key := roleKey(entry)
+		switch {
+		case !prefix:
+			if rl.exact[key] == 0 {
+				rl.exact[key] = n
+			}
+		case !slices.ContainsFunc(rl.prefixes, func(p rolePrefix) bool { return p.key == key }):
+			rl.prefixes = append(rl.prefixes, rolePrefix{key, n})
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return rl, nil
+}
+
+func (rl *roleList) len() int {
+	if rl == nil {
+		return 0
+	}
+	return len(rl.exact) + len(rl.prefixes)
+}
+
+// match returns the entry (as written) covering a local part.
+func (rl *roleList) match(local string) (entry string, line int, ok bool) {
+	if rl == nil || local == "" {
+		return "", 0, false
+	}
+	key := roleKey(local)
+	if key == "" {
+		return "", 0, false
+	}
+	if line, ok := rl.exact[key]; ok {
+		return rl.raw[line-1], line, true
+	}
+	for _, p := range rl.prefixes {
+		if strings.HasPrefix(key, p.key) {
+			return rl.raw[p.line-1], p.line, true
+		}
+	}
+	return "", 0, false
+}
+
+// validRoleEntry reports whether a role list line is a dot-atom local part,
+// optionally followed by the prefix marker *.
+func validRoleEntry(line string) bool {
+	entry := strings.TrimSuffix(line, "*")
+	var smtputf8 bool
+	return roleKey(entry) != "" && !strings.Contains(entry, "*") && len(validateDotAtom(entry, &smtputf8)) == 0
+}
+
+// invalidEntries returns the entries that are not usable local parts.
+func (rl *roleList) invalidEntries() []string {
+	var out []string
+	for _, l := range rl.raw {
+		if l != "" && !strings.HasPrefix(l, "#") && !validRoleEntry(l) {
+			out = append(out, l)
+		}
+	}
+	return out
+}
+
+// SetRoleListPath sets the role account list (rolelist.conf) read by Load.
+// An empty path disables role account detection.
+func (c *Checker) SetRoleListPath(path string) {
+	c.writeMu.Lock()
+	c.rolePath = path
+	c.writeMu.Unlock()
+}
+
+// RoleListPath returns the role account list of the current snapshot.
+func (c *Checker) RoleListPath() string {
+	if rl := c.current().roles; rl != nil {
+		return rl.path
+	}
+	return ""
+}
+
+// RoleCount returns the number of role account entries currently loaded.
+func (c *Checker) RoleCount() int {
+	return c.current().roles.len()
+}
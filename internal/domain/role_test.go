@@ -0,0 +1,51 @@
+package domain
+
+import (
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestRoleAccounts(t *testing.T) {
+	dir := t.TempDir()
+	c := NewChecker(filepath.Join(dir, "allowlist.conf"), filepath.Join(dir, "blocklist.conf"))
+	rolePath := filepath.Join(dir, "rolelist.conf")
+	writeTempList(t, rolePath, []string{"# roles", "postmaster", "noreply*", "Admin", "no_reply*", "bad entry", "abuse"})
+	c.SetRoleListPath(rolePath)
+	if err := c.Load(); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	cases := map[string]string{
+		"postmaster@example.com":      "postmaster",
+		"Post.Master+x@example.com":   "postmaster",
+		"no-reply@example.com":        "noreply*",
+		"noreply-billing@example.com": "noreply*",
+		"admin@example.com":           "Admin",
+		"jane@example.com":            "",
+		"abuser@example.com":          "",
+		"abuse.example.com":           "", // domain checks have no local part
+	}
+	for in, want := range cases {
+		res := c.Check(in)
+		if res.RoleAccount != (want != "") || res.RoleEntry != want {
+			t.Errorf("%s: expected role entry %q, got %v %q", in, want, res.RoleAccount, res.RoleEntry)
+		}
+	}
+	if n := c.RoleCount(); n != 4 {
+		t.Errorf("expected 4 role entries, got %d", n)
+	}
+
+	rep := c.Validate()
+	if !rep.ErrorsFound || !slices.Equal(rep.NonLowercaseRole, []string{"Admin"}) || !slices.Equal(rep.DuplicatesRole, []string{"noreply*"}) || !slices.Equal(rep.InvalidRole, []string{"bad entry"}) {
+		t.Fatalf("unexpected rolelist findings: lower %v dupes %v invalid %v", rep.NonLowercaseRole, rep.DuplicatesRole, rep.InvalidRole)
+	}
+
+	sc, err := NewScoreConfig(map[string]float64{SignalRoleAccount: 40}, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.SetScoreConfig(sc)
+	if res := c.Check("abuse@example.com"); res.Score != 40 || res.Status != "neutral" {
+		t.Fatalf("expected configured role_account weight in the score only, got %d %s", res.Score, res.Status)
+	}
+}
@@ -0,0 +1,293 @@
+package domain
+
+import (
+	"errors"
+	"maps"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// RuleKind identifies the syntax of a pattern entry in a list file.
+type RuleKind string
+
+const (
+	// RuleSubdomains is written "*.example.com" and matches every strict
+	// subdomain of example.com, but not example.com itself.
+	RuleSubdomains RuleKind = "subdomains"
+	// RuleDomain is written "||example.com^" (adblock style) and matches
+	// example.com and all of its subdomains.
+	RuleDomain RuleKind = "domain"
+	// RuleRegex is written "/^...$/" and must be anchored at both ends. It is
+	// evaluated against the full normalized domain.
+	RuleRegex RuleKind = "regex"
+)
+
+const maxRegexRuleLen = 512
+
+// Rule is a compiled pattern entry from a list file.
+type Rule struct {
+	Raw    string   `json:"raw"`
+	Kind   RuleKind `json:"kind"`
+	Suffix string   `json:"suffix,omitempty"` // domain the rule is anchored on (suffix kinds)
+	Line   int      `json:"line,omitempty"`   // 1-based line in the source file
+	re     *regexp.Regexp
+}
+
+// IsPattern reports whether a list line is a pattern rather than a domain.
+func IsPattern(line string) bool { return isRuleLine(line) }
+
+// isRuleLine reports whether a trimmed, non-comment list line uses pattern
+// syntax instead of naming an exact domain.
+func isRuleLine(line string) bool {
+	return strings.HasPrefix(line, "*.") || strings.HasPrefix(line, "||") || strings.HasPrefix(line, "/")
+}
+
+// parseRule compiles a single pattern line. Suffixes are normalized like exact
+// entries (IDNA ASCII, lowercase), so *.bücher.example matches the punycode
+// domains checks see; regex bodies are kept verbatim so escapes like \D keep
+// their meaning.
+func parseRule(line string, lineNo int) (Rule, error) {
+	r := Rule{Raw: line, Line: lineNo}
+	switch {
+	case strings.HasPrefix(line, "*."):
+		r.Kind = RuleSubdomains
+		r.Suffix = normalizeListEntry(line[2:])
+	case strings.HasPrefix(line, "||"):
+		r.Kind = RuleDomain
+		r.Suffix = normalizeListEntry(strings.TrimSuffix(line[2:], "^"))
+	case strings.HasPrefix(line, "/"):
+		r.Kind = RuleRegex
+		if len(line) < 2 || !strings.HasSuffix(line, "/") {
+			return r, errors.New("regex must be enclosed in slashes")
+		}
+		body := line[1 : len(line)-1]
+		if len(body) > maxRegexRuleLen {
+			return r, errors.New("regex too long")
+		}
+		if !strings.HasPrefix(body, "^") || !strings.HasSuffix(body, "$") || strings.HasSuffix(body, `\$`) {
+			return r, errors.New("regex must be anchored with ^ and $")
+		}
+		// The textual check leaves alternations like ^a|b$ half anchored;
+		// the group anchors every branch.
+		re, err := regexp.Compile(`^(?:` + body + `)$`)
+		if err != nil {
+			return r, err
+		}
+		r.re = re
+		return r, nil
+	default:
+		return r, errors.New("not a pattern")
+	}
+	if err := validRuleSuffix(r.Suffix); err != nil {
+		return r, err
+	}
+	return r, nil
+}
+
+func validRuleSuffix(s string) error {
+	if s == "" {
+		return errors.New("empty suffix")
+	}
+	for _, label := range strings.Split(s, ".") {
+		if label == "" {
+			return errors.New("empty label")
+		}
+		if label[0] == '-' || label[len(label)-1] == '-' {
+			return errors.New("label starts or ends with '-'")
+		}
+		for i := 0; i < len(label); i++ {
+			c := label[i]
+			if (c >= 'a' && c <= 'z') || (c >= '0' && c <= '9') || c == '-' {
+				continue
+			}
+			return errors.New("invalid character in suffix")
+		}
+	}
+	return nil
+}
+
+// Matches reports whether the rule covers the normalized domain d.
+func (r Rule) Matches(d string) bool {
+	switch r.Kind {
+	case RuleSubdomains:
+		return strings.HasSuffix(d, "."+r.Suffix)
+	case RuleDomain:
+		return d == r.Suffix || strings.HasSuffix(d, "."+r.Suffix)
+	case RuleRegex:
+		return r.re != nil && r.re.MatchString(d)
+	}
+	return false
+}
+
+// ruleSet indexes pattern rules. Suffix rules are keyed by their anchor
+// domain so a lookup costs one map probe per label of the input, independent
+// of list size. Regex rules are pre-screened with a single combined
+// expression before individual rules are tried.
+type ruleSet struct {
+	rules    []Rule
+	bySuffix map[string][]int
+	regex    []int
+	combined *regexp.Regexp
+	seen     map[string]struct{}
+}
+
+func newRuleSet() *ruleSet {
+	return &ruleSet{bySuffix: make(map[string][]int), seen: make(map[string]struct{})}
+}
+
+// add inserts r unless an identical raw pattern is already present and
+// reports whether it was inserted. Callers must call finish after a batch of
+// regex additions.
+func (rs *ruleSet) add(r Rule) bool {
+	if _, ok := rs.seen[r.Raw]; ok {
+		return false
+	}
+	rs.seen[r.Raw] = struct{}{}
+	idx := len(rs.rules)
+	rs.rules = append(rs.rules, r)
+	if r.Kind == RuleRegex {
+		rs.regex = append(rs.regex, idx)
+	} else {
+		rs.bySuffix[r.Suffix] = append(rs.bySuffix[r.Suffix], idx)
+	}
+	return true
+}
+
+// has reports whether the raw pattern is present.
+func (rs *ruleSet) has(raw string) bool {
+	_, ok := rs.seen[raw]
+	return ok
+}
+
+// clone returns a copy that can take additions without affecting rs.
+func (rs *ruleSet) clone() *ruleSet {
+	out := &ruleSet{
+		rules:    slices.Clone(rs.rules),
+		bySuffix: make(map[string][]int, len(rs.bySuffix)),
+		regex:    slices.Clone(rs.regex),
+		combined: rs.combined,
+		seen:     maps.Clone(rs.seen),
+	}
+	for k, v := range rs.bySuffix {
+		out.bySuffix[k] = slices.Clone(v)
+	}
+	return out
+}
+
+// without returns rs minus the patterns in drop, with rule lines renumbered
+// for the deleted lines gone (sorted). It returns rs when nothing changes.
+func (rs *ruleSet) without(drop map[string]struct{}, gone []int) *ruleSet {
+	if rs.len() == 0 || len(gone) == 0 {
+		return rs
+	}
+	out := newRuleSet()
+	for _, r := range rs.rules {
+		if _, ok := drop[r.Raw]; ok {
+			continue
+		}
+		r.Line -= sort.SearchInts(gone, r.Line)
+		out.add(r)
+	}
+	out.finish()
+	return out
+}
+
+// finish rebuilds the combined regex pre-filter.
+func (rs *ruleSet) finish() {
+	rs.combined = nil
+	if len(rs.regex) < 2 {
+		return
+	}
+	parts := make([]string, 0, len(rs.regex))
+	for _, i := range rs.regex {
+		parts = append(parts, "(?:"+rs.rules[i].re.String()+")")
+	}
+	if re, err := regexp.Compile(strings.Join(parts, "|")); err == nil {
+		rs.combined = re
+	}
+}
+
+// regexLen returns the number of regex rules.
+func (rs *ruleSet) regexLen() int {
+	if rs == nil {
+		return 0
+	}
+	return len(rs.regex)
+}
+
+func (rs *ruleSet) len() int {
+	if rs == nil {
+		return 0
+	}
+	return len(rs.rules)
+}
+
+// match returns the first rule covering d. Suffix rules are tried from the
+// most specific anchor outwards, then regex rules in file order.
+func (rs *ruleSet) match(d string) (Rule, bool) {
+	if rs == nil || len(rs.rules) == 0 || d == "" {
+		return Rule{}, false
+	}
+	if len(rs.bySuffix) > 0 {
+		for s := d; ; {
+			for _, i := range rs.bySuffix[s] {
+				if rs.rules[i].Matches(d) {
+					return rs.rules[i], true
+				}
+			}
+			dot := strings.IndexByte(s, '.')
+			if dot == -1 {
+				break
+			}
+			s = s[dot+1:]
+		}
+	}
+	if len(rs.regex) == 0 || (rs.combined != nil && !rs.combined.MatchString(d)) {
+		return Rule{}, false
+	}
+	for _, i := range rs.regex {
+		if rs.rules[i].re.MatchString(d) {
+			return rs.rules[i], true
+		}
+	}
+	return Rule{}, false
+}
+
+// matchAll returns every rule covering d, in the same order match tries them.
+func (rs *ruleSet) matchAll(d string) []Rule {
+	if rs == nil || len(rs.rules) == 0 || d == "" {
+		return nil
+	}
+	var out []Rule
+	if len(rs.bySuffix) > 0 {
+		for s := d; ; {
+			for _, i := range rs.bySuffix[s] {
+				if rs.rules[i].Matches(d) {
+					out = append(out, rs.rules[i])
+				}
+			}
+			dot := strings.IndexByte(s, '.')
+			if dot == -1 {
+				break
+			}
+			s = s[dot+1:]
+		}
+	}
+	return append(out, rs.matchRegexAll(d)...)
+}
+
+// matchRegexAll returns the regex rules covering d.
+func (rs *ruleSet) matchRegexAll(d string) []Rule {
+	if rs == nil || len(rs.regex) == 0 || d == "" || (rs.combined != nil && !rs.combined.MatchString(d)) {
+		return nil
+	}
+	var out []Rule
+	for _, i := range rs.regex {
+		if rs.rules[i].re.MatchString(d) {
+			out = append(out, rs.rules[i])
+		}
+	}
+	return out
+}
@@ -0,0 +1,135 @@
+package domain
+
+import (
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestPatternRules(t *testing.T) {
+	dir := t.TempDir()
+	allowPath := filepath.Join(dir, "allowlist.conf")
+	blockPath := filepath.Join(dir, "blocklist.conf")
+	writeTempList(t, allowPath, []string{"good.com", "||ok.usa.cc^"})
+	writeTempList(t, blockPath, []string{
+		"*.usa.cc",
+		"||tempmail.net^",
+		`/^[0-9]+(-[0-9]+)+\.com$/`,
+		"*.bücher.example",
+		"||XN--Mnchen-3ya.example^",
+		`/^foo|bar.*\.net$/`, // each branch is anchored
+	})
+	c := NewChecker(allowPath, blockPath)
+	if err := c.Load(); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	cases := []struct {
+		in, status string
+	}{
+		{"user@foo.usa.cc", "block"},
+		{"usa.cc", "neutral"}, // *. rules match strict subdomains only
+		{"tempmail.net", "block"},
+		{"a.b.tempmail.net", "block"},
+		{"nottempmail.net", "neutral"},
+		{"0-180.com", "block"},
+		{"0-30-24.com", "block"},
+		{"180.com", "neutral"},
+		{"x.ok.usa.cc", "allow"}, // allow pattern wins
+		{"shop.bücher.example", "block"},
+		{"shop.xn--bcher-kva.example", "block"},
+		{"bücher.example", "neutral"},
+		{"münchen.example", "block"},
+		{"bar1.net", "block"},
+		{"foo.evil.com", "neutral"},
+		{"mail.bar1.net", "neutral"},
+	}
+	for _, tc := range cases {
+		if got := c.Check(tc.in).Status; got != tc.status {
+			t.Errorf("Check(%q) status = %s, want %s", tc.in, got, tc.status)
+		}
+	}
+	c.PatchBlock([]string{"||spam.io^", "||spam.io^"})
+	if got := c.Check("x.spam.io").Status; got != "block" {
+		t.Fatalf("expected patched pattern to block, got %s", got)
+	}
+	if n := c.BlockCount(); n != 7 {
+		t.Fatalf("expected 7 block entries after patch, got %d", n)
+	}
+}
+
+func TestValidatePatterns(t *testing.T) {
+	dir := t.TempDir()
+	allowPath := filepath.Join(dir, "allowlist.conf")
+	blockPath := filepath.Join(dir, "blocklist.conf")
+	writeTempList(t, allowPath, []string{"good.com"})
+	writeTempList(t, blockPath, []string{
+		"*.com",          // whole public suffix
+		"||co.uk^",       // whole public suffix
+		"/^.*$/",         // matches everything
+		"/[0-9]+\\.com/", // not anchored
+		"/^(unclosed$/",  // does not compile
+		"*.bad_label.cc", // invalid character
+		"||good.com^",    // swallows an allowlisted provider
+		"*.usa.cc",       // fine
+	})
+	c := NewChecker(allowPath, blockPath)
+	if err := c.Load(); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	rep := c.Validate()
+	if !rep.ErrorsFound {
+		t.Fatalf("expected errors for bad patterns")
+	}
+	if len(rep.MalformedPatterns) != 3 {
+		t.Errorf("expected 3 malformed patterns, got %v", rep.MalformedPatterns)
+	}
+	if len(rep.BroadPatterns) != 4 {
+		t.Errorf("expected 4 overly broad patterns, got %v", rep.BroadPatterns)
+	}
+	for _, s := range append(rep.MalformedPatterns, rep.BroadPatterns...) {
+		if strings.Contains(s, "usa.cc") {
+			t.Errorf("unexpected finding for valid pattern: %s", s)
+		}
+	}
+}
+
+// Allowlist patterns are meant to match providers; only a whole public
+// suffix is reported, and not as an error.
+func TestValidateAllowPatterns(t *testing.T) {
+	dir := t.TempDir()
+	allowPath := filepath.Join(dir, "allowlist.conf")
+	blockPath := filepath.Join(dir, "blocklist.conf")
+	writeTempList(t, allowPath, []string{"||gmail.com^", "*.example.com", "*.com"})
+	writeTempList(t, blockPath, []string{"bad.com"})
+	c := NewChecker(allowPath, blockPath)
+	if err := c.Reload(true); err != nil {
+		t.Fatalf("strict reload: %v", err)
+	}
+	rep := c.Validate()
+	if rep.ErrorsFound || len(rep.BroadPatterns) != 0 {
+		t.Fatalf("expected no errors, got %+v", rep.BroadPatterns)
+	}
+	if len(rep.BroadAllowPatterns) != 1 || !strings.Contains(rep.BroadAllowPatterns[0], "*.com") {
+		t.Fatalf("expected a warning for *.com only, got %v", rep.BroadAllowPatterns)
+	}
+}
+
+// BenchmarkRuleSetMatch measures suffix rule lookups with a list in the size
+// range of the production blocklist.
+func BenchmarkRuleSetMatch(b *testing.B) {
+	rs := newRuleSet()
+	for i := 0; i < 250_000; i++ {
+		r, err := parseRule("*.d"+strconv.Itoa(i)+".example", i+1)
+		if err != nil {
+			b.Fatal(err)
+		}
+		rs.add(r)
+	}
+	rs.finish()
+	inputs := []string{"mx.d123456.example", "a.b.c.gmail.com", "d99.example"}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		rs.match(inputs[i%len(inputs)])
+	}
+}
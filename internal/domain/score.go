@@ -0,0 +1,269 @@
+package domain
+
+import (
+	"errors"
+	"math"
+	"sort"
+	"strings"
+)
+
+// Score signals. Each firing signal contributes weight × strength points to
+// the 0–100 risk score, where strength is 1 unless noted.
+const (
+	SignalBlocklisted        = "blocklisted"          // exact blocklist entry
+	SignalSubdomainOfBlocked = "subdomain_of_blocked" // registrable domain is blocklisted
+	SignalBlockPattern       = "block_pattern"        // blocklist pattern entry
+	SignalAllowlisted        = "allowlisted"          // any allowlist match (negative weight)
+	SignalMXDisposable       = "mx_disposable"        // exchanger is disposable infrastructure
+	SignalNullMX             = "null_mx"              // domain accepts no mail (RFC 7505)
+	SignalNoMX               = "no_mx"                // no exchanger could be resolved
+	SignalPrivateSuffix      = "private_suffix"       // registered under a PRIVATE PSL suffix (e.g. github.io)
+	SignalPublicSuffixOnly   = "public_suffix_only"   // input is itself a public suffix
+	SignalInvalidFormat      = "invalid_format"       // syntax errors in the input
+	SignalConfusable         = "confusable"           // lookalike of an allowlisted domain
+	SignalMixedScript        = "mixed_script"         // label mixes scripts
+	SignalLexical            = "lexical"              // random-looking label; strength = share of lexical features present, 1 when the classifier rates it suspect
+	SignalRoleAccount        = "role_account"         // role or no-reply local part (weight 0 unless configured)
+)
+
+// DefaultScoreWeights are used for signals without a configured weight.
+var DefaultScoreWeights = map[string]float64{
+	SignalBlocklisted:        100,
+	SignalSubdomainOfBlocked: 90,
+	SignalBlockPattern:       85,
+	SignalAllowlisted:        -100,
+	SignalMXDisposable:       90,
+	SignalNullMX:             30,
+	SignalNoMX:               35,
+	SignalPrivateSuffix:      15,
+	SignalPublicSuffixOnly:   20,
+	SignalInvalidFormat:      30,
+	SignalConfusable:         40,
+	SignalMixedScript:        25,
+	SignalLexical:            35,
+	SignalRoleAccount:        0,
+}
+
+// Risk levels derived from the score.
+const (
+	RiskLow    = "low"
+	RiskMedium = "medium"
+	RiskHigh   = "high"
+)
+
+// ScoreSignal is one signal's share of a score.
+type ScoreSignal struct {
+	Signal       string  `json:"signal"`
+	Weight       float64 `json:"weight"`
+	Strength     float64 `json:"strength"`
+	Contribution int     `json:"contribution"`
+	Detail       string  `json:"detail,omitempty"`
+}
+
+// ScoreConfig holds signal weights and the risk level thresholds: scores at
+// or above Medium are "medium", at or above High are "high".
+type ScoreConfig struct {
+	Weights map[string]float64
+	Medium  int
+	High    int
+}
+
+// DefaultScoreConfig returns the built-in weights and thresholds.
+func DefaultScoreConfig() ScoreConfig {
+	w := make(map[string]float64, len(DefaultScoreWeights))
+	for k, v := range DefaultScoreWeights {
+		w[k] = v
+	}
+	return ScoreConfig{Weights: w, Medium: 30, High: 70}
+}
+
+// NewScoreConfig overlays weights onto the defaults and validates signal
+// names and thresholds. Zero thresholds keep their defaults.
+func NewScoreConfig(weights map[string]float64, medium, high int) (ScoreConfig, error) {
+	sc := DefaultScoreConfig()
+	var unknown []string
+	for k, v := range weights {
+		if _, ok := DefaultScoreWeights[k]; !ok {
+			unknown = append(unknown, k)
+			continue
+		}
+		sc.Weights[k] = v
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return DefaultScoreConfig(), errors.New("score: unknown signals: " + strings.Join(unknown, ", "))
+	}
+	if medium != 0 {
+		sc.Medium = medium
+	}
+	if high != 0 {
+		sc.High = high
+	}
+	if sc.Medium < 0 || sc.High > 100 || sc.Medium > sc.High {
+		return DefaultScoreConfig(), errors.New("score: thresholds must satisfy 0 <= medium <= high <= 100")
+	}
+	return sc, nil
+}
+
+// SetScoreConfig replaces the scoring weights and thresholds used by Check.
+func (c *Checker) SetScoreConfig(sc ScoreConfig) {
+	c.scoring.Store(&sc)
+}
+
+func (c *Checker) scoreConfig() *ScoreConfig {
+	if sc := c.scoring.Load(); sc != nil {
+		return sc
+	}
+	def := DefaultScoreConfig()
+	c.scoring.CompareAndSwap(nil, &def)
+	return c.scoring.Load()
+}
+
+// applyScore fills Score, RiskLevel and ScoreSignals from the other fields of
+// res. An allow verdict is final: positive signals do not count and the risk
+// level stays low.
+func (sc *ScoreConfig) applyScore(res *Result) {
+	allowed := res.Status == "allow"
+	var signals []ScoreSignal
+	add := func(name string, strength float64, detail string) {
+		w := sc.Weights[name]
+		if w == 0 || strength <= 0 || (allowed && w > 0) {
+			return
+		}
+		signals = append(signals, ScoreSignal{Signal: name, Weight: w, Strength: strength, Contribution: int(math.Round(w * strength)), Detail: detail})
+	}
+	for _, m := range res.Matches {
+		// Entries overridden by precedence (e.g. a shared allow entry under a
+		// tenant block) do not contribute.
+		if (res.Status == "block" && m.List == "allowlist") || (res.Status == "allow" && m.List == "blocklist") {
+			continue
+		}
+		switch {
+		case m.List == "allowlist":
+			add(SignalAllowlisted, 1, m.Entry)
+		case m.List == "blocklist" && m.Kind == MatchExact:
+			add(SignalBlocklisted, 1, m.Entry)
+		case m.List == "blocklist" && m.Kind == MatchETLD1:
+			add(SignalSubdomainOfBlocked, 1, m.Entry)
+		case m.List == "blocklist" && m.Kind == MatchPattern:
+			add(SignalBlockPattern, 1, m.Entry)
+		}
+	}
+	// Several entries of one kind count once.
+	signals = dedupeSignals(signals)
+	if res.MX != nil {
+		switch {
+		case res.MX.Disposable:
+			add(SignalMXDisposable, 1, res.MX.MatchedEntry)
+		case res.MX.NullMX:
+			add(SignalNullMX, 1, "")
+		case res.MX.Error == "" && len(res.MX.IPs) == 0:
+			add(SignalNoMX, 1, strings.Join(res.MX.Hosts, ", "))
+		}
+	}
+	if res.PrivateSuffix {
+		add(SignalPrivateSuffix, 1, res.PublicSuffix)
+	}
+	if res.IsPublicSuffixOnly {
+		add(SignalPublicSuffixOnly, 1, res.PublicSuffix)
+	}
+	if len(res.FormatErrors) > 0 {
+		add(SignalInvalidFormat, 1, strings.Join(res.FormatErrors, ", "))
+	}
+	if res.Confusable {
+		add(SignalConfusable, 1, res.ConfusableWith)
+	}
+	if res.MixedScript {
+		add(SignalMixedScript, 1, "")
+	}
+	if res.RoleAccount {
+		add(SignalRoleAccount, 1, res.RoleEntry)
+	}
+	// The heuristic features and the classifier read the same label, so they
+	// share one signal: a suspect verdict raises it to full strength.
+	if res.Suspect && res.SuspectDetail != nil {
+		add(SignalLexical, 1, res.SuspectDetail.summary())
+	} else if strength, features := lexicalFeatures(registrableLabel(res)); strength > 0 {
+		add(SignalLexical, strength, strings.Join(features, ", "))
+	}
+
+	total := 0
+	for _, s := range signals {
+		total += s.Contribution
+	}
+	res.Score = min(max(total, 0), 100)
+	res.ScoreSignals = signals
+	switch {
+	case allowed:
+		res.RiskLevel = RiskLow
+	case res.Score >= sc.High:
+		res.RiskLevel = RiskHigh
+	case res.Score >= sc.Medium:
+		res.RiskLevel = RiskMedium
+	default:
+		res.RiskLevel = RiskLow
+	}
+}
+
+func dedupeSignals(in []ScoreSignal) []ScoreSignal {
+	seen := make(map[string]bool, len(in))
+	out := in[:0]
+	for _, s := range in {
+		if seen[s.Signal] {
+			continue
+		}
+		seen[s.Signal] = true
+		out = append(out, s)
+	}
+	return out
+}
+
+// registrableLabel returns the label directly left of the public suffix,
+// e.g. "x7k2q9" for mail.x7k2q9.com.
+func registrableLabel(res *Result) string {
+	if res.RegistrableDomain == "" || res.PublicSuffix == "" {
+		return ""
+	}
+	return strings.TrimSuffix(res.RegistrableDomain, "."+res.PublicSuffix)
+}
+
+// lexicalFeatures inspects a registrable label for traits common to
+// generated throwaway domains and returns the share of features present
+// together with their names.
+func lexicalFeatures(label string) (float64, []string) {
+	if label == "" || strings.HasPrefix(label, "xn--") {
+		return 0, nil
+	}
+	const total = 4
+	var features []string
+	digits, hyphens, run, longestRun := 0, 0, 0, 0
+	for i := 0; i < len(label); i++ {
+		c := label[i]
+		switch {
+		case c >= '0' && c <= '9':
+			digits++
+			run = 0
+		case c == '-':
+			hyphens++
+			run = 0
+		case strings.IndexByte("aeiouy", c) == -1:
+			run++
+			longestRun = max(longestRun, run)
+		default:
+			run = 0
+		}
+	}
+	if len(label) >= 6 && digits*10 >= len(label)*3 {
+		features = append(features, "digit_heavy")
+	}
+	if len(label) >= 20 {
+		features = append(features, "long_label")
+	}
+	if longestRun >= 5 {
+		features = append(features, "consonant_run")
+	}
+	if hyphens >= 2 {
+		features = append(features, "many_hyphens")
+	}
+	return float64(len(features)) / total, features
+}
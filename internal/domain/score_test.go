@@ -0,0 +1,81 @@
+package domain
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestRiskScore(t *testing.T) {
+	dir := t.TempDir()
+	allowPath := filepath.Join(dir, "allowlist.conf")
+	blockPath := filepath.Join(dir, "blocklist.conf")
+	writeTempList(t, allowPath, []string{"good.com", "both.com"})
+	writeTempList(t, blockPath, []string{"bad.com", "both.com"})
+	c := NewChecker(allowPath, blockPath)
+	if err := c.Load(); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+
+	cases := []struct {
+		in    string
+		score int
+		level string
+	}{
+		{"bad.com", 100, RiskHigh},
+		{"mail.bad.com", 90, RiskHigh},
+		{"good.com", 0, RiskLow},
+		{"both.com", 0, RiskLow},              // allowlist cancels the blocklist
+		{"someone.github.io", 15, RiskMedium}, // private suffix only
+		{"plain.com", 0, RiskLow},
+		{"a@@plain.com", 30, RiskMedium},
+		{"xkqzbvt1234.com", 35, RiskMedium}, // classifier verdict
+	}
+	c.SetScoreConfig(mustScoreConfig(t, nil, 15, 70))
+	for _, tc := range cases {
+		res := c.Check(tc.in)
+		if res.Score != tc.score || res.RiskLevel != tc.level {
+			t.Errorf("%s: score=%d level=%s, want %d %s (signals %+v)", tc.in, res.Score, res.RiskLevel, tc.score, tc.level, res.ScoreSignals)
+		}
+	}
+
+	// Below the classifier threshold the heuristic features set the strength.
+	c.SetSuspectThreshold(1)
+	res := c.Check("xkqzbvt1234.com")
+	if len(res.ScoreSignals) != 1 || res.ScoreSignals[0].Signal != SignalLexical || res.ScoreSignals[0].Detail != "digit_heavy, consonant_run" || res.Score != 18 {
+		t.Fatalf("unexpected lexical signal: %d %+v", res.Score, res.ScoreSignals)
+	}
+
+	// Allow is final, whatever the weights.
+	writeTempList(t, allowPath, []string{"good.com", "both.com", "xkqzbvt1234.com"})
+	if err := c.Load(); err != nil {
+		t.Fatal(err)
+	}
+	c.SetScoreConfig(mustScoreConfig(t, map[string]float64{SignalAllowlisted: -10, SignalLexical: 100}, 0, 0))
+	if res := c.Check("xkqzbvt1234.com"); res.Status != "allow" || res.Score != 0 || res.RiskLevel != RiskLow || len(res.ScoreSignals) != 1 {
+		t.Fatalf("expected allowlisted domain to stay low risk, got %d %s %+v", res.Score, res.RiskLevel, res.ScoreSignals)
+	}
+	c.SetScoreConfig(mustScoreConfig(t, map[string]float64{SignalAllowlisted: 0}, 0, 0))
+	if res := c.Check("xkqzbvt1234.com"); res.Score != 0 || res.RiskLevel != RiskLow {
+		t.Fatalf("expected zero score without an allowlisted weight, got %d %s", res.Score, res.RiskLevel)
+	}
+
+	c.SetScoreConfig(mustScoreConfig(t, map[string]float64{SignalSubdomainOfBlocked: 50}, 0, 0))
+	if res := c.Check("mail.bad.com"); res.Score != 50 || res.RiskLevel != RiskMedium {
+		t.Fatalf("custom weight not applied: %d %s", res.Score, res.RiskLevel)
+	}
+	if _, err := NewScoreConfig(map[string]float64{"nope": 1}, 0, 0); err == nil {
+		t.Fatalf("expected error for unknown signal")
+	}
+	if _, err := NewScoreConfig(nil, 80, 40); err == nil {
+		t.Fatalf("expected error for inverted thresholds")
+	}
+}
+
+func mustScoreConfig(t *testing.T, w map[string]float64, medium, high int) ScoreConfig {
+	t.Helper()
+	sc, err := NewScoreConfig(w, medium, high)
+	if err != nil {
+		t.Fatalf("score config: %v", err)
+	}
+	return sc
+}
@@ -0,0 +1,210 @@
+package domain
+
+import (
+	"maps"
+	"slices"
+	"sort"
+	"time"
+
+	"disposable-email-domains/internal/metrics"
+)
+
+// snapshot is an immutable view of the loaded lists. Check reads exactly one
+// snapshot without locking; writers (Load, PatchBlock, tenant updates) build
+// a modified copy under Checker.writeMu and publish it with the next
+// generation number.
+type snapshot struct {
+	generation uint64
+	updatedAt  time.Time
+	loaded     bool
+	indexMode  string
+
+	allow    listIndex // exact entry -> 1-based line of first occurrence
+	block    listIndex
+	rawAllow []string // nil unless indexMode is IndexMap
+	rawBlock []string
+	// allowLines and blockLines count the lines of the list files so
+	// patched entries get their line number.
+	allowLines int
+	blockLines int
+	// pattern entries (*.suffix, ||domain^, /regex/) compiled from the lists
+	allowRules *ruleSet
+	blockRules *ruleSet
+	// Bloom filters over exact entries and suffix pattern anchors; a miss
+	// proves no exact, eTLD+1 or suffix pattern entry covers a domain
+	allowFilter *bloomFilter
+	blockFilter *bloomFilter
+	// skeleton (visual lookalike form) of each allowlist entry -> entries
+	allowSkeletons map[string][]string
+	// exact allowlist entries as typo suggestion targets
+	allowTypos []typoCandidate
+	// provenance per entry from the <list>.meta sidecars
+	allowMeta map[string]Provenance
+	blockMeta map[string]Provenance
+	// tombstones of blocklist entries removed on purpose
+	blockTombstones map[string]Tombstone
+	// lexical classifier trained on the lists at Load; nil without entries
+	lexical *lexicalModel
+	// role account local parts; nil when no role list is configured
+	roles *roleList
+	// tenant overlays keyed by tenant id
+	tenants map[string]*tenantOverlay
+}
+
+func emptySnapshot() *snapshot {
+	return &snapshot{
+		allow:      mapIndex{},
+		block:      mapIndex{},
+		allowRules: newRuleSet(),
+		blockRules: newRuleSet(),
+	}
+}
+
+// sharedList addresses the fields of one shared list in a snapshot, so
+// patches and removals work the same on both lists.
+type sharedList struct {
+	idx    *listIndex
+	raw    *[]string
+	rules  **ruleSet
+	filter **bloomFilter
+	meta   *map[string]Provenance
+	lines  *int
+}
+
+func (s *snapshot) list(allow bool) sharedList {
+	if allow {
+		return sharedList{&s.allow, &s.rawAllow, &s.allowRules, &s.allowFilter, &s.allowMeta, &s.allowLines}
+	}
+	return sharedList{&s.block, &s.rawBlock, &s.blockRules, &s.blockFilter, &s.blockMeta, &s.blockLines}
+}
+
+func (s *snapshot) keepsRaw() bool { return s.indexMode == "" || s.indexMode == IndexMap }
+
+// current returns the published snapshot.
+func (c *Checker) current() *snapshot {
+	if s := c.snap.Load(); s != nil {
+		return s
+	}
+	c.snap.CompareAndSwap(nil, emptySnapshot())
+	return c.snap.Load()
+}
+
+// publish makes s the current snapshot with the next generation number;
+// c.writeMu must be held.
+func (c *Checker) publish(s *snapshot) {
+	s.generation = c.current().generation + 1
+	c.snap.Store(s)
+	if !c.derived {
+		metrics.ListGenerationGauge.Set(float64(s.generation))
+	}
+}
+
+// Generation returns the generation of the current list state. It starts at
+// 0 before the first Load and increases with every Load, patch and tenant
+// overlay update.
+func (c *Checker) Generation() uint64 {
+	return c.current().generation
+}
+
+// deltaIndex layers entries patched in since the last Load over an immutable
+// base index. Every patch copies the delta, so once it grows past
+// deltaCompactAt entries or removed lines the next patch folds it into a
+// fresh base instead; Load does the same. Entries removed since the last
+// Load are hidden from the base and the lines of later entries renumbered.
+type deltaIndex struct {
+	base  listIndex
+	delta mapIndex
+	// removed hides deleted base entries; gone holds the base line numbers
+	// of all deleted lines, sorted.
+	removed map[string]struct{}
+	gone    []int
+}
+
+func (d *deltaIndex) lookup(key string) (int, bool) {
+	if line, ok := d.delta[key]; ok {
+		return line, true
+	}
+	if _, ok := d.removed[key]; ok {
+		return 0, false
+	}
+	line, ok := d.base.lookup(key)
+	if !ok {
+		return 0, false
+	}
+	return d.renumber(line), true
+}
+
+func (d *deltaIndex) len() int { return d.base.len() - len(d.removed) + len(d.delta) }
+
+func (d *deltaIndex) each(fn func(string, int)) {
+	d.base.each(func(k string, line int) {
+		if _, ok := d.removed[k]; !ok {
+			fn(k, d.renumber(line))
+		}
+	})
+	d.delta.each(fn)
+}
+
+// renumber maps a base line to the current file.
+func (d *deltaIndex) renumber(line int) int {
+	return line - sort.SearchInts(d.gone, line)
+}
+
+// removeLine records that line (in the current file) was deleted; key is the
+// entry on it, or "" for comments, blanks and patterns. Callers remove lines
+// from the bottom up so the numbers of the remaining ones stay valid.
+func (d *deltaIndex) removeLine(line int, key string) {
+	if l, ok := d.delta[key]; ok && l == line {
+		delete(d.delta, key)
+	} else {
+		// Map back to the base file: every earlier deletion at or before
+		// the position moves it one line down.
+		orig := line
+		for _, g := range d.gone {
+			if g > orig {
+				break
+			}
+			orig++
+		}
+		i, _ := slices.BinarySearch(d.gone, orig)
+		d.gone = slices.Insert(d.gone, i, orig)
+		if key != "" {
+			if _, ok := d.base.lookup(key); ok {
+				d.removed[key] = struct{}{}
+			}
+		}
+	}
+	for k, l := range d.delta {
+		if l > line {
+			d.delta[k] = l - 1
+		}
+	}
+}
+
+// deltaCompactAt bounds the delta copied by each patch. A steady stream of
+// single-entry patches then costs one O(n) fold per deltaCompactAt patches
+// instead of an ever larger copy per patch.
+const deltaCompactAt = 4096
+
+// withDelta returns a copy of idx that can take new entries and removals
+// without modifying idx.
+func withDelta(idx listIndex) *deltaIndex {
+	if d, ok := idx.(*deltaIndex); ok {
+		if len(d.delta)+len(d.gone) < deltaCompactAt {
+			return &deltaIndex{base: d.base, delta: maps.Clone(d.delta), removed: maps.Clone(d.removed), gone: slices.Clone(d.gone)}
+		}
+		idx = d.fold()
+	}
+	return &deltaIndex{base: idx, delta: mapIndex{}, removed: map[string]struct{}{}}
+}
+
+// fold flattens d into a standalone index of the same kind as its base: a
+// map in map mode, an in-memory table in the compact and mmap modes.
+func (d *deltaIndex) fold() listIndex {
+	if _, ok := d.base.(mapIndex); ok {
+		m := make(mapIndex, d.len())
+		d.each(func(k string, line int) { m[k] = line })
+		return m
+	}
+	return newTableIndex(d)
+}
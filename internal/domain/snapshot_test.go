@@ -0,0 +1,128 @@
+package domain
+
+import (
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+)
+
+// TestSnapshotConsistency patches entries while checks run and verifies that
+// every verdict agrees with the generation it reports.
+func TestSnapshotConsistency(t *testing.T) {
+	dir := t.TempDir()
+	allowPath := filepath.Join(dir, "allowlist.conf")
+	blockPath := filepath.Join(dir, "blocklist.conf")
+	writeTempList(t, allowPath, []string{"# allow"})
+	writeTempList(t, blockPath, []string{"# block"})
+	c := NewChecker(allowPath, blockPath)
+	if g := c.Generation(); g != 0 {
+		t.Fatalf("expected generation 0 before Load, got %d", g)
+	}
+	if err := c.Load(); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	base := c.Generation()
+	if base != 1 {
+		t.Fatalf("expected generation 1 after Load, got %d", base)
+	}
+
+	const patches = 200
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 1; i <= patches; i++ {
+			c.PatchBlock([]string{"e" + strconv.Itoa(i) + ".com"})
+		}
+	}()
+	for r := 0; r < 4; r++ {
+		wg.Add(1)
+		go func(r int) {
+			defer wg.Done()
+			var last uint64
+			for i := 0; i < 2000; i++ {
+				k := (i*7+r)%patches + 1
+				res := c.Check("e" + strconv.Itoa(k) + ".com")
+				if res.Generation < last {
+					t.Errorf("generation went backwards: %d after %d", res.Generation, last)
+					return
+				}
+				last = res.Generation
+				if want := res.Generation >= base+uint64(k); res.Blocklisted != want {
+					t.Errorf("e%d.com at generation %d: blocklisted=%v, want %v", k, res.Generation, res.Blocklisted, want)
+					return
+				}
+			}
+		}(r)
+	}
+	wg.Wait()
+
+	if g := c.Generation(); g != base+patches {
+		t.Fatalf("expected generation %d, got %d", base+patches, g)
+	}
+	// Duplicates do not publish a new snapshot.
+	c.PatchBlock([]string{"e1.com"})
+	if g := c.Generation(); g != base+patches {
+		t.Fatalf("duplicate patch bumped generation to %d", g)
+	}
+	// Load folds patched entries back into a fresh index.
+	if err := c.Load(); err != nil {
+		t.Fatalf("reload: %v", err)
+	}
+	if _, ok := c.current().block.(*deltaIndex); ok {
+		t.Fatal("expected Load to drop the patch delta")
+	}
+}
+
+// TestDeltaCompaction verifies that a long run of patches keeps the copied
+// delta bounded and that folding preserves entries, line numbers and the
+// index kind.
+func TestDeltaCompaction(t *testing.T) {
+	for _, mode := range []string{IndexMap, IndexCompact} {
+		t.Run(mode, func(t *testing.T) {
+			dir := t.TempDir()
+			allowPath := filepath.Join(dir, "allowlist.conf")
+			blockPath := filepath.Join(dir, "blocklist.conf")
+			writeTempList(t, allowPath, []string{"# allow"})
+			writeTempList(t, blockPath, []string{"# block", "a.com", "b.com"})
+			c := NewChecker(allowPath, blockPath)
+			if err := c.SetIndexMode(mode); err != nil {
+				t.Fatalf("index mode: %v", err)
+			}
+			if err := c.Load(); err != nil {
+				t.Fatalf("load: %v", err)
+			}
+			if _, _, err := c.RemoveBlock([]string{"a.com"}, nil); err != nil {
+				t.Fatalf("remove: %v", err)
+			}
+			const patches = deltaCompactAt + 100
+			for i := 1; i <= patches; i++ {
+				c.PatchBlock([]string{"e" + strconv.Itoa(i) + ".com"})
+				d := c.current().block.(*deltaIndex)
+				if len(d.delta)+len(d.gone) > deltaCompactAt {
+					t.Fatalf("patch %d: delta holds %d entries", i, len(d.delta)+len(d.gone))
+				}
+			}
+			d := c.current().block.(*deltaIndex)
+			if _, ok := d.base.(*deltaIndex); ok {
+				t.Fatal("folded base is itself a delta")
+			}
+			if _, isMap := d.base.(mapIndex); isMap != (mode == IndexMap) {
+				t.Fatalf("folded base is %T in %s mode", d.base, mode)
+			}
+			if n := d.len(); n != patches+1 {
+				t.Fatalf("expected %d entries, got %d", patches+1, n)
+			}
+			want := map[string]int{"b.com": 2, "e1.com": 3, "e4096.com": 4098, "e" + strconv.Itoa(patches) + ".com": patches + 2}
+			for k, line := range want {
+				if got, ok := d.lookup(k); !ok || got != line {
+					t.Errorf("lookup(%q) = %d, %v; want line %d", k, got, ok, line)
+				}
+			}
+			if _, ok := d.lookup("a.com"); ok {
+				t.Error("removed entry survived the fold")
+			}
+		})
+	}
+}
@@ -0,0 +1,305 @@
+package domain
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Tenant overlays add per-tenant allow and block entries on top of the shared
+// lists. They live next to the shared files as allowlist.<tenant>.conf and
+// blocklist.<tenant>.conf and use the same syntax. Check resolves
+// precedence as tenant allow > tenant block > global allow > global block.
+
+var tenantIDPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,62}$`)
+
+// Errors returned by the tenant overlay API.
+var (
+	ErrInvalidTenant = errors.New("invalid tenant id (lowercase letters, digits, '-' and '_', max 63)")
+	ErrUnknownTenant = errors.New("unknown tenant")
+	ErrUnknownList   = errors.New("unknown list (use allowlist or blocklist)")
+	ErrInvalidEntry  = errors.New("invalid list entry")
+)
+
+// ValidTenantID reports whether id can name a tenant overlay.
+func ValidTenantID(id string) bool { return tenantIDPattern.MatchString(id) }
+
+// tenantOverlay is the parsed pair of overlay files for one tenant.
+type tenantOverlay struct {
+	allowPath, blockPath   string
+	allow, block           map[string]int
+	rawAllow, rawBlock     []string
+	allowRules, blockRules *ruleSet
+}
+
+// TenantInfo summarizes a tenant overlay.
+type TenantInfo struct {
+	ID         string `json:"id"`
+	AllowCount int    `json:"allowlist_count"`
+	BlockCount int    `json:"blocklist_count"`
+}
+
+// tenantListPath maps a shared list path to the tenant's overlay path,
+// e.g. allowlist.conf -> allowlist.acme.conf.
+func tenantListPath(globalPath, tenant string) string {
+	ext := filepath.Ext(globalPath)
+	return strings.TrimSuffix(globalPath, ext) + "." + tenant + ext
+}
+
+// discoverTenants returns the ids of all overlay files next to the shared lists.
+func (c *Checker) discoverTenants() ([]string, error) {
+	seen := make(map[string]struct{})
+	for _, p := range []string{c.allowPath, c.blockPath} {
+		ext := filepath.Ext(p)
+		prefix := strings.TrimSuffix(p, ext) + "."
+		matches, err := filepath.Glob(globEscape(prefix) + "*" + globEscape(ext))
+		if err != nil {
+			return nil, err
+		}
+		for _, m := range matches {
+			id := strings.TrimSuffix(strings.TrimPrefix(m, prefix), ext)
+			if ValidTenantID(id) {
+				seen[id] = struct{}{}
+			}
+		}
+	}
+	ids := make([]string, 0, len(seen))
+	for id := range seen {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids, nil
+}
+
+func globEscape(s string) string {
+	r := strings.NewReplacer(`*`, `\*`, `?`, `\?`, `[`, `\[`, `\`, `\\`)
+	return r.Replace(s)
+}
+
+func (c *Checker) loadOverlay(id string) (*tenantOverlay, error) {
+	ov := &tenantOverlay{allowPath: tenantListPath(c.allowPath, id), blockPath: tenantListPath(c.blockPath, id)}
+	var err error
+	if ov.allow, ov.rawAllow, ov.allowRules, err = readListFile(ov.allowPath); err != nil {
+		return nil, err
+	}
+	if ov.block, ov.rawBlock, ov.blockRules, err = readListFile(ov.blockPath); err != nil {
+		return nil, err
+	}
+	return ov, nil
+}
+
+func (c *Checker) loadTenants() (map[string]*tenantOverlay, error) {
+	ids, err := c.discoverTenants()
+	if err != nil {
+		return nil, err
+	}
+	out := make(map[string]*tenantOverlay, len(ids))
+	for _, id := range ids {
+		ov, err := c.loadOverlay(id)
+		if err != nil {
+			return nil, err
+		}
+		out[id] = ov
+	}
+	return out, nil
+}
+
+// HasTenant reports whether an overlay exists for id.
+func (c *Checker) HasTenant(id string) bool {
+	_, ok := c.current().tenants[id]
+	return ok
+}
+
+// Tenants lists the loaded overlays sorted by id.
+func (c *Checker) Tenants() []TenantInfo {
+	tenants := c.current().tenants
+	out := make([]TenantInfo, 0, len(tenants))
+	for id, ov := range tenants {
+		out = append(out, TenantInfo{ID: id, AllowCount: len(ov.allow) + ov.allowRules.len(), BlockCount: len(ov.block) + ov.blockRules.len()})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+	return out
+}
+
+// TenantEntries returns the entries (non-empty, non-comment lines) of one of
+// a tenant's overlay lists.
+func (c *Checker) TenantEntries(id, list string) ([]string, error) {
+	ov, ok := c.current().tenants[id]
+	if !ok {
+		return nil, ErrUnknownTenant
+	}
+	var raw []string
+	switch list {
+	case "allowlist":
+		raw = ov.rawAllow
+	case "blocklist":
+		raw = ov.rawBlock
+	default:
+		return nil, ErrUnknownList
+	}
+	out := make([]string, 0, len(raw))
+	for _, l := range raw {
+		if l != "" && !strings.HasPrefix(l, "#") {
+			out = append(out, l)
+		}
+	}
+	return out, nil
+}
+
+// UpdateTenantList adds and removes entries of a tenant overlay list, creating
+// the tenant on first use. The file is rewritten atomically (comments are
+// kept) and the overlay is re-read. Pattern entries are validated; domain
+// entries are stored in their normalized form. It returns the entries that
+// were actually added and removed.
+func (c *Checker) UpdateTenantList(id, list string, add, remove []string) (added, removed []string, err error) {
+	if !ValidTenantID(id) {
+		return nil, nil, ErrInvalidTenant
+	}
+	var path string
+	switch list {
+	case "allowlist":
+		path = tenantListPath(c.allowPath, id)
+	case "blocklist":
+		path = tenantListPath(c.blockPath, id)
+	default:
+		return nil, nil, ErrUnknownList
+	}
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+
+	var lines []string
+	if data, err := os.ReadFile(path); err == nil {
+		lines = strings.Split(strings.TrimRight(string(data), "\n"), "\n")
+	} else if !os.IsNotExist(err) {
+		return nil, nil, err
+	}
+	hasHeader := len(lines) > 0 && strings.HasPrefix(strings.TrimSpace(lines[0]), "#")
+	drop := make(map[string]struct{}, len(remove))
+	for _, e := range remove {
+		if e = strings.TrimSpace(e); e != "" {
+			drop[provenanceKey(e)] = struct{}{}
+		}
+	}
+	present := make(map[string]struct{}, len(lines))
+	kept := lines[:0]
+	for _, l := range lines {
+		t := strings.TrimSpace(l)
+		if t != "" && !strings.HasPrefix(t, "#") {
+			k := provenanceKey(t)
+			if _, ok := drop[k]; ok {
+				removed = append(removed, t)
+				continue
+			}
+			present[k] = struct{}{}
+		}
+		kept = append(kept, l)
+	}
+	for _, e := range add {
+		e = strings.TrimSpace(e)
+		if e == "" || strings.HasPrefix(e, "#") {
+			continue
+		}
+		if isRuleLine(e) {
+			if _, err := parseRule(e, 0); err != nil {
+				return nil, nil, fmt.Errorf("%w %s: %v", ErrInvalidEntry, e, err)
+			}
+		} else {
+			e = normalizeListEntry(e)
+		}
+		k := provenanceKey(e)
+		if _, ok := present[k]; ok {
+			continue
+		}
+		present[k] = struct{}{}
+		kept = append(kept, e)
+		added = append(added, e)
+	}
+	if len(added) == 0 && len(removed) == 0 {
+		return nil, nil, nil
+	}
+	var b bytes.Buffer
+	if !hasHeader {
+		b.WriteString("# " + list + " overlay for tenant " + id + "\n")
+	}
+	for _, l := range kept {
+		b.WriteString(l)
+		b.WriteByte('\n')
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, b.Bytes(), 0o644); err != nil {
+		return nil, nil, err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return nil, nil, err
+	}
+	ov, err := c.loadOverlay(id)
+	if err != nil {
+		return nil, nil, err
+	}
+	cur := c.current()
+	next := *cur
+	next.tenants = maps.Clone(next.tenants)
+	if next.tenants == nil {
+		next.tenants = make(map[string]*tenantOverlay)
+	}
+	next.tenants[id] = ov
+	next.updatedAt = time.Now().UTC()
+	c.publish(&next)
+	c.advanceValidation(cur, &next, nil)
+	return added, removed, nil
+}
+
+// DeleteTenant removes both overlay files of a tenant. The files are removed
+// before the overlay is unpublished, so a failed removal never serves a state
+// a restart would not load: if only the first file went, the overlay is
+// republished from what is left on disk.
+func (c *Checker) DeleteTenant(id string) error {
+	if !ValidTenantID(id) {
+		return ErrInvalidTenant
+	}
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	cur := c.current()
+	if _, ok := cur.tenants[id]; !ok {
+		return ErrUnknownTenant
+	}
+	var err error
+	removedAny := false
+	for _, p := range []string{tenantListPath(c.allowPath, id), tenantListPath(c.blockPath, id)} {
+		err = os.Remove(p)
+		if err == nil {
+			removedAny = true
+			continue
+		}
+		if os.IsNotExist(err) {
+			err = nil
+			continue
+		}
+		break
+	}
+	if err != nil && !removedAny {
+		return err
+	}
+	next := *cur
+	next.tenants = maps.Clone(next.tenants)
+	if err != nil {
+		ov, lerr := c.loadOverlay(id)
+		if lerr != nil {
+			return errors.Join(err, lerr)
+		}
+		next.tenants[id] = ov
+	} else {
+		delete(next.tenants, id)
+	}
+	next.updatedAt = time.Now().UTC()
+	c.publish(&next)
+	c.advanceValidation(cur, &next, nil)
+	return err
+}
@@ -0,0 +1,119 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestTenantOverlays(t *testing.T) {
+	dir := t.TempDir()
+	allowPath := filepath.Join(dir, "allowlist.conf")
+	blockPath := filepath.Join(dir, "blocklist.conf")
+	writeTempList(t, allowPath, []string{"shared-allow.com", "contested.com"})
+	writeTempList(t, blockPath, []string{"shared-block.com", "tenant-allows.com"})
+	writeTempList(t, tenantListPath(allowPath, "acme"), []string{"# acme", "tenant-allows.com"})
+	writeTempList(t, tenantListPath(blockPath, "acme"), []string{"contested.com", "*.acme-burner.net"})
+	c := NewChecker(allowPath, blockPath)
+	if err := c.Load(); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if !c.HasTenant("acme") || c.HasTenant("other") {
+		t.Fatalf("expected only tenant acme, got %+v", c.Tenants())
+	}
+
+	ctx := context.Background()
+	cases := []struct {
+		tenant, input, status string
+	}{
+		{"acme", "tenant-allows.com", "allow"}, // tenant allow > global block
+		{"acme", "contested.com", "block"},     // tenant block > global allow
+		{"acme", "x.acme-burner.net", "block"},
+		{"acme", "shared-allow.com", "allow"},
+		{"acme", "shared-block.com", "block"},
+		{"", "tenant-allows.com", "block"},
+		{"", "contested.com", "allow"},
+		{"", "x.acme-burner.net", "neutral"},
+	}
+	for _, tc := range cases {
+		res := c.CheckTenant(ctx, tc.tenant, tc.input)
+		if res.Status != tc.status {
+			t.Errorf("tenant %q, %s: expected %s, got %s (matches %+v)", tc.tenant, tc.input, tc.status, res.Status, res.Matches)
+		}
+		if res.Tenant != tc.tenant {
+			t.Errorf("tenant %q, %s: result tenant %q", tc.tenant, tc.input, res.Tenant)
+		}
+	}
+	res := c.CheckTenant(ctx, "acme", "contested.com")
+	if len(res.Matches) != 2 || res.Matches[0].Tenant != "acme" || res.Matches[0].List != "blocklist" {
+		t.Fatalf("expected tenant block match first, got %+v", res.Matches)
+	}
+	if res.Score < 70 {
+		t.Fatalf("overridden shared allow entry should not lower the score, got %d", res.Score)
+	}
+
+	// Admin updates create tenants, normalize entries and survive a reload.
+	added, _, err := c.UpdateTenantList("beta", "blocklist", []string{"Beta-Burner.IO", "beta-burner.io", "||spam.example^"}, nil)
+	if err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	if len(added) != 2 || added[0] != "beta-burner.io" {
+		t.Fatalf("unexpected added entries %v", added)
+	}
+	if _, _, err := c.UpdateTenantList("beta", "blocklist", []string{"/unanchored/"}, nil); !errors.Is(err, ErrInvalidEntry) {
+		t.Fatalf("expected ErrInvalidEntry, got %v", err)
+	}
+	if _, _, err := c.UpdateTenantList("Bad/ID", "blocklist", []string{"x.com"}, nil); !errors.Is(err, ErrInvalidTenant) {
+		t.Fatalf("expected ErrInvalidTenant, got %v", err)
+	}
+	if got := c.CheckTenant(ctx, "beta", "mail.spam.example"); got.Status != "block" {
+		t.Fatalf("expected pattern from update to apply, got %s", got.Status)
+	}
+	if err := c.Load(); err != nil {
+		t.Fatalf("reload: %v", err)
+	}
+	if got := c.Tenants(); len(got) != 2 || got[1].ID != "beta" || got[1].BlockCount != 2 {
+		t.Fatalf("expected tenants acme and beta after reload, got %+v", got)
+	}
+	_, removed, err := c.UpdateTenantList("beta", "blocklist", nil, []string{"BETA-BURNER.IO"})
+	if err != nil || len(removed) != 1 {
+		t.Fatalf("remove: %v %v", removed, err)
+	}
+	if entries, _ := c.TenantEntries("beta", "blocklist"); len(entries) != 1 || entries[0] != "||spam.example^" {
+		t.Fatalf("unexpected entries after removal %v", entries)
+	}
+
+	if err := c.DeleteTenant("beta"); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if _, err := os.Stat(tenantListPath(blockPath, "beta")); !os.IsNotExist(err) {
+		t.Fatalf("expected overlay file removed, stat err %v", err)
+	}
+	if err := c.DeleteTenant("beta"); !errors.Is(err, ErrUnknownTenant) {
+		t.Fatalf("expected ErrUnknownTenant, got %v", err)
+	}
+
+	// A failed removal leaves the overlay published: the files are removed
+	// before the tenant is dropped.
+	acmeAllow := tenantListPath(allowPath, "acme")
+	// A non-empty directory in place of the file cannot be removed.
+	if err := os.Remove(acmeAllow); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Mkdir(acmeAllow, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	writeTempList(t, filepath.Join(acmeAllow, "keep"), nil)
+	gen := c.Generation()
+	if err := c.DeleteTenant("acme"); err == nil {
+		t.Fatal("expected the removal to fail")
+	}
+	if !c.HasTenant("acme") || c.Generation() != gen {
+		t.Fatalf("failed delete unpublished the tenant (generation %d -> %d)", gen, c.Generation())
+	}
+	if _, err := os.Stat(tenantListPath(blockPath, "acme")); err != nil {
+		t.Fatalf("expected the block overlay to stay: %v", err)
+	}
+}
@@ -0,0 +1,156 @@
+package domain
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"maps"
+	"os"
+	"slices"
+	"time"
+)
+
+// Tombstone records that a blocklist entry was removed on purpose. Imports
+// skip tombstoned entries; adding the entry manually lifts the tombstone.
+type Tombstone struct {
+	RemovedAt time.Time `json:"removed_at,omitzero"`
+	Actor     string    `json:"actor,omitempty"` // fingerprint of the admin token that removed it
+	Reason    string    `json:"reason,omitempty"`
+}
+
+// TombstoneEntry is a tombstone with the entry it covers.
+type TombstoneEntry struct {
+	Entry string `json:"entry"`
+	Tombstone
+}
+
+// tombstoneRecord is one line of a tombstone sidecar.
+type tombstoneRecord struct {
+	TombstoneEntry
+	Lifted bool `json:"lifted,omitempty"` // the entry was revived
+}
+
+// tombstonePath returns the sidecar holding the tombstones of the list at
+// path. Like the metadata sidecar it is append-only JSON lines; the last
+// record for an entry wins.
+func tombstonePath(path string) string { return path + ".tombstones" }
+
+// readTombstoneFile loads a tombstone sidecar. A missing file yields an empty
+// map; malformed lines are skipped.
+func readTombstoneFile(path string) (map[string]Tombstone, error) {
+	out := make(map[string]Tombstone)
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return out, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+	s := bufio.NewScanner(f)
+	s.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for s.Scan() {
+		line := bytes.TrimSpace(s.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		var rec tombstoneRecord
+		if json.Unmarshal(line, &rec) != nil || rec.Entry == "" {
+			continue
+		}
+		if k := provenanceKey(rec.Entry); rec.Lifted {
+			delete(out, k)
+		} else {
+			out[k] = rec.Tombstone
+		}
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// appendTombstoneFile appends one record per entry to the sidecar at path.
+func appendTombstoneFile(path string, entries []string, t Tombstone, lifted bool) error {
+	var b bytes.Buffer
+	enc := json.NewEncoder(&b)
+	for _, e := range entries {
+		if err := enc.Encode(tombstoneRecord{TombstoneEntry{e, t}, lifted}); err != nil {
+			return err
+		}
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(b.Bytes()); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// RemoveBlock deletes entries from the blocklist file and the in-memory
+// indexes (see removeList). With t set, the removed entries are tombstoned so
+// imports do not re-add them.
+func (c *Checker) RemoveBlock(entries []string, t *Tombstone) (removed, missing []string, err error) {
+	return c.removeList(false, entries, t)
+}
+
+// BlockTombstone returns the tombstone of a removed blocklist entry.
+func (c *Checker) BlockTombstone(entry string) (Tombstone, bool) {
+	t, ok := c.current().blockTombstones[provenanceKey(entry)]
+	return t, ok
+}
+
+// BlockTombstones returns all blocklist tombstones sorted by entry.
+func (c *Checker) BlockTombstones() []TombstoneEntry {
+	ts := c.current().blockTombstones
+	out := make([]TombstoneEntry, 0, len(ts))
+	for _, e := range slices.Sorted(maps.Keys(ts)) {
+		out = append(out, TombstoneEntry{e, ts[e]})
+	}
+	return out
+}
+
+// LiftBlockTombstones removes the tombstones of entries without adding them
+// back, so later imports may add them again. It returns the lifted entries.
+// The sidecar is written first: when that fails nothing is lifted.
+func (c *Checker) LiftBlockTombstones(entries []string) ([]string, error) {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	cur := c.current()
+	lifted := liftable(cur.blockTombstones, entries)
+	if len(lifted) == 0 {
+		return nil, nil
+	}
+	if err := appendTombstoneFile(tombstonePath(c.blockPath), lifted, Tombstone{}, true); err != nil {
+		return nil, err
+	}
+	next := *cur
+	next.blockTombstones = withoutKeys(cur.blockTombstones, lifted)
+	c.publish(&next)
+	c.advanceValidation(cur, &next, nil)
+	return lifted, nil
+}
+
+// liftable returns the entries of list (in stored form, deduplicated) that
+// have a tombstone.
+func liftable(ts map[string]Tombstone, list []string) []string {
+	var out []string
+	for _, e := range list {
+		k := provenanceKey(e)
+		if _, ok := ts[k]; ok && !slices.Contains(out, k) {
+			out = append(out, k)
+		}
+	}
+	return out
+}
+
+func withoutKeys(ts map[string]Tombstone, keys []string) map[string]Tombstone {
+	out := maps.Clone(ts)
+	for _, k := range keys {
+		delete(out, k)
+	}
+	return out
+}
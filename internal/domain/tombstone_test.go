@@ -0,0 +1,143 @@
+package domain
+
+import (
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+// lineOf returns the line the blocklist entry or pattern is reported on.
+func lineOf(c *Checker, domain string) int {
+	for _, m := range c.Check(domain).Matches {
+		if m.List == "blocklist" {
+			return m.Line
+		}
+	}
+	return 0
+}
+
+func TestRemoveBlock(t *testing.T) {
+	for _, mode := range []string{IndexMap, IndexCompact} {
+		t.Run(mode, func(t *testing.T) {
+			dir := t.TempDir()
+			allowPath := filepath.Join(dir, "allowlist.conf")
+			blockPath := filepath.Join(dir, "blocklist.conf")
+			writeTempList(t, allowPath, []string{"good.com"})
+			writeTempList(t, blockPath, []string{"# blocklist", "a.com", "b.com", "*.pat.net", "c.com", "B.com", "d.com"})
+			c := NewChecker(allowPath, blockPath)
+			if err := c.SetIndexMode(mode); err != nil {
+				t.Fatal(err)
+			}
+			if err := c.Load(); err != nil {
+				t.Fatalf("load: %v", err)
+			}
+			f, _ := os.OpenFile(blockPath, os.O_APPEND|os.O_WRONLY, 0o644)
+			_, _ = f.WriteString("e.com\nf.com\n")
+			_ = f.Close()
+			if err := c.PatchBlockFrom([]string{"e.com", "f.com"}, Provenance{Source: "https://lists.example/a.txt"}); err != nil {
+				t.Fatal(err)
+			}
+
+			removed, missing, err := c.RemoveBlock([]string{"B.com", "*.pat.net", "e.com", "nope.com"}, &Tombstone{Reason: "false positive"})
+			if err != nil {
+				t.Fatalf("remove: %v", err)
+			}
+			if !slices.Equal(removed, []string{"b.com", "*.pat.net", "e.com"}) || !slices.Equal(missing, []string{"nope.com"}) {
+				t.Fatalf("unexpected result: removed %v, missing %v", removed, missing)
+			}
+			data, _ := os.ReadFile(blockPath)
+			if got := string(data); got != "# blocklist\na.com\nc.com\nd.com\nf.com\n" {
+				t.Fatalf("unexpected file:\n%s", got)
+			}
+			for _, d := range []string{"b.com", "x.pat.net", "e.com"} {
+				if c.Check(d).Blocklisted {
+					t.Errorf("%s still blocklisted", d)
+				}
+			}
+			if n := c.BlockCount(); n != 4 {
+				t.Errorf("expected 4 block entries, got %d", n)
+			}
+			lines := make(map[string]int)
+			for _, d := range []string{"a.com", "c.com", "d.com", "f.com"} {
+				lines[d] = lineOf(c, d)
+			}
+			if want := map[string]int{"a.com": 2, "c.com": 3, "d.com": 4, "f.com": 5}; !maps.Equal(lines, want) {
+				t.Fatalf("unexpected lines after removal: %v", lines)
+			}
+			if ts, ok := c.BlockTombstone("B.com"); !ok || ts.Reason != "false positive" || ts.RemovedAt.IsZero() {
+				t.Fatalf("expected tombstone for b.com, got %+v %v", ts, ok)
+			}
+
+			// Entries patched after a removal are numbered in the new file.
+			f, _ = os.OpenFile(blockPath, os.O_APPEND|os.O_WRONLY, 0o644)
+			_, _ = f.WriteString("g.com\n")
+			_ = f.Close()
+			c.PatchBlock([]string{"g.com"})
+			if l := lineOf(c, "g.com"); l != 6 {
+				t.Fatalf("expected g.com on line 6, got %d", l)
+			}
+
+			// Tombstones survive a reload; the in-memory state matches it.
+			if err := c.Load(); err != nil {
+				t.Fatalf("reload: %v", err)
+			}
+			for d, l := range lines {
+				if got := lineOf(c, d); got != l {
+					t.Errorf("%s: line %d after reload, %d before", d, got, l)
+				}
+			}
+			if len(c.BlockTombstones()) != 3 {
+				t.Fatalf("expected 3 tombstones after reload, got %+v", c.BlockTombstones())
+			}
+			// So does the provenance of removed entries: none.
+			if _, ok := c.BlockProvenance("e.com"); ok {
+				t.Fatal("removed entry kept its provenance across a reload")
+			}
+			if p, ok := c.BlockProvenance("f.com"); !ok || p.Source != "https://lists.example/a.txt" {
+				t.Fatalf("expected f.com provenance kept, got %+v %v", p, ok)
+			}
+
+			// Adding an entry manually lifts its tombstone; imports do not.
+			f, _ = os.OpenFile(blockPath, os.O_APPEND|os.O_WRONLY, 0o644)
+			_, _ = f.WriteString("b.com\n")
+			_ = f.Close()
+			if err := c.PatchBlockFrom([]string{"b.com"}, Provenance{Source: SourceManual}); err != nil {
+				t.Fatal(err)
+			}
+			if _, ok := c.BlockTombstone("b.com"); ok || !c.Check("b.com").Blocklisted {
+				t.Fatal("expected b.com re-added without tombstone")
+			}
+			if lifted, err := c.LiftBlockTombstones([]string{"e.com", "e.com", "a.com"}); err != nil || !slices.Equal(lifted, []string{"e.com"}) {
+				t.Fatalf("unexpected lift: %v %v", lifted, err)
+			}
+			if err := c.Load(); err != nil {
+				t.Fatalf("reload: %v", err)
+			}
+			if ts := c.BlockTombstones(); len(ts) != 1 || ts[0].Entry != "*.pat.net" {
+				t.Fatalf("expected only the pattern tombstoned, got %+v", ts)
+			}
+
+			// A lift that cannot be persisted is not applied.
+			_ = os.Remove(tombstonePath(blockPath))
+			_ = os.Mkdir(tombstonePath(blockPath), 0o755)
+			if lifted, err := c.LiftBlockTombstones([]string{"*.pat.net"}); err == nil || lifted != nil {
+				t.Fatalf("expected lift failure, got %v %v", lifted, err)
+			}
+			if _, ok := c.BlockTombstone("*.pat.net"); !ok {
+				t.Fatal("tombstone lifted in memory although the sidecar was not written")
+			}
+			// Neither is one by a manual re-add; the entry itself is added.
+			f, _ = os.OpenFile(blockPath, os.O_APPEND|os.O_WRONLY, 0o644)
+			_, _ = f.WriteString("*.pat.net\n")
+			_ = f.Close()
+			if err := c.PatchBlockFrom([]string{"*.pat.net"}, Provenance{Source: SourceManual}); err == nil {
+				t.Fatal("expected an error for the unwritable tombstone sidecar")
+			}
+			if _, ok := c.BlockTombstone("*.pat.net"); !ok || !c.Check("x.pat.net").Blocklisted {
+				t.Fatal("expected the pattern re-added with its tombstone kept")
+			}
+		})
+	}
+}
@@ -0,0 +1,230 @@
+package domain
+
+import (
+	"strings"
+)
+
+// DefaultTypoProviders are the major mailbox providers typo suggestions point
+// to in addition to the exact allowlist entries.
+var DefaultTypoProviders = []string{
+	"gmail.com", "googlemail.com", "yahoo.com", "yahoo.co.uk", "yahoo.fr",
+	"hotmail.com", "hotmail.co.uk", "hotmail.fr", "outlook.com", "live.com",
+	"msn.com", "icloud.com", "me.com", "aol.com", "protonmail.com",
+	"proton.me", "gmx.com", "gmx.de", "gmx.net", "web.de", "yandex.ru",
+	"mail.ru", "zoho.com", "comcast.net", "verizon.net", "att.net",
+}
+
+// typoCandidate is a suggestion target split at its first dot, e.g. "yahoo"
+// and "co.uk".
+type typoCandidate struct {
+	domain, name, tld string
+}
+
+func newTypoCandidate(d string) (typoCandidate, bool) {
+	name, tld, ok := strings.Cut(d, ".")
+	if !ok || name == "" || tld == "" {
+		return typoCandidate{}, false
+	}
+	return typoCandidate{domain: d, name: name, tld: tld}, true
+}
+
+func buildTypoCandidates(domains []string) []typoCandidate {
+	out := make([]typoCandidate, 0, len(domains))
+	for _, d := range domains {
+		if tc, ok := newTypoCandidate(normalizeListEntry(d)); ok {
+			out = append(out, tc)
+		}
+	}
+	return out
+}
+
+// buildAllowTypos collects the exact allowlist entries as suggestion targets.
+func buildAllowTypos(allow listIndex) []typoCandidate {
+	var out []typoCandidate
+	allow.each(func(d string, _ int) {
+		if tc, ok := newTypoCandidate(d); ok {
+			out = append(out, tc)
+		}
+	})
+	return out
+}
+
+// typoProviders holds the configured provider candidates; off disables
+// suggestions altogether.
+type typoProviders struct {
+	off        bool
+	candidates []typoCandidate
+}
+
+// SetTypoProviders replaces the provider list used for typo suggestions
+// (DefaultTypoProviders until called). The allowlist is always considered as
+// well; a nil list disables suggestions.
+func (c *Checker) SetTypoProviders(providers []string) {
+	if providers == nil {
+		c.typos.Store(&typoProviders{off: true})
+		return
+	}
+	c.typos.Store(&typoProviders{candidates: buildTypoCandidates(providers)})
+}
+
+var defaultTypoProviders = &typoProviders{candidates: buildTypoCandidates(DefaultTypoProviders)}
+
+func (c *Checker) typoProviders() *typoProviders {
+	if p := c.typos.Load(); p != nil {
+		return p
+	}
+	return defaultTypoProviders
+}
+
+// suggestDomain returns the provider or allowlist domain d most likely is a
+// typo of, or "" when there is none. Two kinds of typos are recognized:
+//   - TLD confusion: same name, suffix within one keyboard edit (gmail.co,
+//     gmail.cmo, yahoo.con)
+//   - name typos: same suffix, name within the keyboard-aware edit budget of
+//     typoBudget (gmial.com, hotmial.com, yahooo.com)
+//
+// Exact candidates never get a suggestion.
+func suggestDomain(d string, lists ...[]typoCandidate) string {
+	in, ok := newTypoCandidate(d)
+	if !ok {
+		return ""
+	}
+	best, bestCost := "", 0.0
+	for _, list := range lists {
+		for _, tc := range list {
+			if tc.domain == d {
+				return ""
+			}
+			var cost float64
+			switch {
+			case tc.name == in.name:
+				// Two-letter suffixes are mostly real country codes; only
+				// neighbouring keys count as typos there (web.dk is not web.de).
+				budget := 1.0
+				if len(tc.tld) < 3 {
+					budget = 0.5
+				}
+				cost = typoDistance(in.tld, tc.tld, budget)
+				if cost > budget {
+					continue
+				}
+			case tc.tld == in.tld:
+				budget := typoBudget(tc.name)
+				if budget == 0 || abs(len(tc.name)-len(in.name)) > 2 {
+					continue
+				}
+				cost = typoDistance(in.name, tc.name, budget)
+				if cost > budget {
+					continue
+				}
+			default:
+				continue
+			}
+			if best == "" || cost < bestCost {
+				best, bestCost = tc.domain, cost
+			}
+		}
+	}
+	return best
+}
+
+// typoBudget is the edit cost allowed for a provider name. Names shorter than
+// four characters are too close to unrelated domains to correct.
+func typoBudget(name string) float64 {
+	switch {
+	case len(name) < 4:
+		return 0
+	case len(name) < 6:
+		return 1
+	default:
+		return 1.5
+	}
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// typoDistance is an optimal string alignment distance weighted for typing
+// errors: substituting a neighbouring key or doubling/dropping a repeated
+// letter costs 0.5, any other substitution, insertion, deletion or swap of
+// adjacent letters costs 1. Results above limit are only reported as such.
+func typoDistance(a, b string, limit float64) float64 {
+	prev2 := make([]float64, len(b)+1)
+	prev := make([]float64, len(b)+1)
+	cur := make([]float64, len(b)+1)
+	for j := range prev {
+		prev[j] = float64(j)
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = float64(i)
+		rowMin := cur[0]
+		for j := 1; j <= len(b); j++ {
+			sub := 0.0
+			if a[i-1] != b[j-1] {
+				sub = 1
+				if keysAdjacent(a[i-1], b[j-1]) {
+					sub = 0.5
+				}
+			}
+			// a[i-1] is an extra letter; cheaper when it repeats the one before.
+			del := 1.0
+			if i > 1 && a[i-1] == a[i-2] {
+				del = 0.5
+			}
+			// b[j-1] is a missing letter; cheaper when it is half of a double.
+			ins := 1.0
+			if j > 1 && b[j-1] == b[j-2] {
+				ins = 0.5
+			}
+			v := min(prev[j-1]+sub, prev[j]+del, cur[j-1]+ins)
+			if i > 1 && j > 1 && a[i-1] == b[j-2] && a[i-2] == b[j-1] {
+				v = min(v, prev2[j-2]+1)
+			}
+			cur[j] = v
+			rowMin = min(rowMin, v)
+		}
+		if rowMin > limit {
+			return rowMin
+		}
+		prev2, prev, cur = prev, cur, prev2
+	}
+	return prev[len(b)]
+}
+
+// keyboardRows is the QWERTY layout; each row is shifted right by a fraction
+// of a key relative to the one above.
+var keyboardRows = [...]struct {
+	keys   string
+	offset float64
+}{
+	{"1234567890", 0},
+	{"qwertyuiop", 0.5},
+	{"asdfghjkl", 0.75},
+	{"zxcvbnm", 1.25},
+}
+
+// keyPos maps a key to its (row, column) position on the keyboard.
+var keyPos = func() map[byte][2]float64 {
+	m := make(map[byte][2]float64)
+	for r, row := range keyboardRows {
+		for i := 0; i < len(row.keys); i++ {
+			m[row.keys[i]] = [2]float64{float64(r), float64(i) + row.offset}
+		}
+	}
+	return m
+}()
+
+// keysAdjacent reports whether two keys touch on a QWERTY keyboard.
+func keysAdjacent(x, y byte) bool {
+	p, ok1 := keyPos[x]
+	q, ok2 := keyPos[y]
+	if !ok1 || !ok2 || x == y {
+		return false
+	}
+	dr, dc := p[0]-q[0], p[1]-q[1]
+	return dr >= -1 && dr <= 1 && dc >= -1 && dc <= 1
+}
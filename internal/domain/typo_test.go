@@ -0,0 +1,69 @@
+package domain
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestSuggestDomain(t *testing.T) {
+	providers := buildTypoCandidates(DefaultTypoProviders)
+	allow := buildTypoCandidates([]string{"company-mail.example"})
+	cases := map[string]string{
+		"gmial.com":            "gmail.com",
+		"gmaill.com":           "gmail.com",
+		"gnail.com":            "gmail.com",
+		"hotmial.com":          "hotmail.com",
+		"yahooo.com":           "yahoo.com",
+		"outlok.com":           "outlook.com",
+		"yahoo.co":             "yahoo.com",
+		"gmail.con":            "gmail.com",
+		"gmail.cmo":            "gmail.com",
+		"hotmail.co.uk":        "",
+		"company-mial.example": "company-mail.example",
+		"gmail.com":            "",
+		"web.dk":               "",
+		"aok.com":              "", // names under four characters are not corrected
+		"example.com":          "",
+		"mail.gmial.com":       "",
+	}
+	for in, want := range cases {
+		if got := suggestDomain(in, providers, allow); got != want {
+			t.Errorf("%s: expected suggestion %q, got %q", in, want, got)
+		}
+	}
+}
+
+func TestCheckSuggestion(t *testing.T) {
+	dir := t.TempDir()
+	allowPath := filepath.Join(dir, "allowlist.conf")
+	blockPath := filepath.Join(dir, "blocklist.conf")
+	writeTempList(t, allowPath, []string{"corp.example"})
+	writeTempList(t, blockPath, []string{"gmial.com"})
+	c := NewChecker(allowPath, blockPath)
+	if err := c.Load(); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if res := c.Check("Jane@GMIAL.com"); res.Suggestion != "Jane@gmail.com" || res.Status != "block" {
+		t.Fatalf("expected email suggestion for blocked typo domain, got %q (%s)", res.Suggestion, res.Status)
+	}
+	if got := c.Check("corp.net").Suggestion; got != "" {
+		t.Fatalf("unexpected suggestion across suffixes %q", got)
+	}
+	if got := c.Check("corpp.example").Suggestion; got != "corp.example" {
+		t.Fatalf("expected allowlist suggestion, got %q", got)
+	}
+	if got := c.Check("corp.example").Suggestion; got != "" {
+		t.Fatalf("allowlisted domains get no suggestion, got %q", got)
+	}
+	c.SetTypoProviders([]string{"mailbox.example"})
+	if got := c.Check("gmial.com").Suggestion; got != "" {
+		t.Fatalf("expected default providers replaced, got %q", got)
+	}
+	if got := c.Check("mailbx.example").Suggestion; got != "mailbox.example" {
+		t.Fatalf("expected configured provider suggestion, got %q", got)
+	}
+	c.SetTypoProviders(nil)
+	if got := c.Check("corpp.example").Suggestion; got != "" {
+		t.Fatalf("expected suggestions disabled, got %q", got)
+	}
+}
@@ -0,0 +1,117 @@
+package domain
+
+import (
+	"slices"
+	"strconv"
+	"time"
+)
+
+// validation is the Validate report of one list generation under one public
+// suffix list.
+type validation struct {
+	generation uint64
+	psl        *PSL
+	report     Report
+}
+
+// Validate reports consistency problems in the lists. The report is cached
+// per list generation: Load invalidates it, blocklist patches update it
+// incrementally and tenant overlay changes carry it over, so repeated calls
+// (probes, dashboards) cost a copy. Swapping the public suffix list forces a
+// full validation.
+func (c *Checker) Validate() Report {
+	snap := c.current()
+	if rep, ok := c.cachedValidation(snap); ok {
+		return rep
+	}
+	c.validateMu.Lock()
+	defer c.validateMu.Unlock()
+	if rep, ok := c.cachedValidation(snap); ok {
+		return rep
+	}
+	rep := c.validate(snap)
+	c.storeValidation(snap, rep)
+	return rep
+}
+
+func (c *Checker) cachedValidation(snap *snapshot) (Report, bool) {
+	v := c.validation.Load()
+	if v == nil || v.generation != snap.generation || v.psl != c.psl.Load() {
+		return Report{}, false
+	}
+	rep := v.report
+	rep.Cached = true
+	rep.AgeSeconds = time.Since(rep.CheckedAt).Seconds()
+	return rep, true
+}
+
+func (c *Checker) storeValidation(snap *snapshot, rep Report) {
+	c.validation.Store(&validation{generation: snap.generation, psl: c.psl.Load(), report: rep})
+}
+
+// advanceValidation moves a report cached for prev to next, which differs
+// from prev by the blocklist entries inserted (in stored form) or only in its
+// tenant overlays. c.writeMu must be held.
+func (c *Checker) advanceValidation(prev, next *snapshot, inserted []string) {
+	v := c.validation.Load()
+	if v == nil || v.generation != prev.generation || v.psl != c.psl.Load() {
+		return
+	}
+	rep := v.report
+	if len(inserted) > 0 {
+		sl := c.suffixes()
+		// The cached report is shared with earlier callers: clipped slices
+		// reallocate on append.
+		rep.PublicSuffixInBlock = slices.Clip(rep.PublicSuffixInBlock)
+		rep.ThirdLevelInBlock = slices.Clip(rep.ThirdLevelInBlock)
+		rep.Intersection = slices.Clone(rep.Intersection)
+		rep.BroadPatterns = slices.Clip(rep.BroadPatterns)
+		var probes []string
+		resort := false
+		line := prev.blockLines
+		for _, e := range inserted {
+			line++
+			// Patched entries are lowercase and new, so they add neither
+			// case nor duplicate findings.
+			if isRuleLine(e) {
+				if probes == nil {
+					probes = blockRuleProbes(next)
+				}
+				if r, err := parseRule(e, line); err == nil {
+					if why := ruleTooBroad(r, sl, probes); why != "" {
+						rep.BroadPatterns = append(rep.BroadPatterns, "blocklist line "+strconv.Itoa(line)+": "+e+": "+why)
+					}
+				}
+			} else {
+				psOnly, third := blockEntryFindings(sl, e)
+				if psOnly {
+					rep.PublicSuffixInBlock = append(rep.PublicSuffixInBlock, e)
+				}
+				if third {
+					rep.ThirdLevelInBlock = append(rep.ThirdLevelInBlock, e)
+				}
+				if _, ok := next.allow.lookup(e); ok {
+					if i, found := slices.BinarySearch(rep.Intersection, e); !found {
+						rep.Intersection = slices.Insert(rep.Intersection, i, e)
+					}
+				}
+			}
+			// Entries at or after the last line leave the first
+			// out-of-order line where it was.
+			resort = resort || e < rep.lastBlock
+			rep.lastBlock = e
+		}
+		if resort {
+			raw := next.rawBlock
+			if !next.keepsRaw() {
+				raw, _ = readListLines(c.blockPath)
+			}
+			rep.UnsortedBlockHint = sortHint(raw)
+		}
+		rep.IncrementalUpdates++
+		rep.CheckedAt = time.Now().UTC()
+		rep.setErrorsFound()
+	}
+	rep.Generation = next.generation
+	c.validation.Store(&validation{generation: next.generation, psl: v.psl, report: rep})
+}
@@ -0,0 +1,51 @@
+package domain
+
+import (
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestValidateCachedPerGeneration(t *testing.T) {
+	dir := t.TempDir()
+	allowPath := filepath.Join(dir, "allowlist.conf")
+	blockPath := filepath.Join(dir, "blocklist.conf")
+	writeTempList(t, allowPath, []string{"good.com", "ok.net"})
+	writeTempList(t, blockPath, []string{"bad.com", "spam.net"})
+	c := NewChecker(allowPath, blockPath)
+	if err := c.Load(); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+
+	if rep := c.Validate(); rep.Cached || rep.ErrorsFound {
+		t.Fatalf("expected a fresh clean report: %+v", rep)
+	}
+	rep := c.Validate()
+	if !rep.Cached || rep.Generation != c.Generation() {
+		t.Fatalf("expected the report served from cache: %+v", rep)
+	}
+
+	c.PatchBlock([]string{"sub.zzz.com", "ok.net", "aaa.org"})
+	rep = c.Validate()
+	if rep.IncrementalUpdates != 1 || rep.Generation != c.Generation() {
+		t.Fatalf("expected an incremental update: %+v", rep)
+	}
+	if !slices.Equal(rep.ThirdLevelInBlock, []string{"sub.zzz.com"}) || !slices.Equal(rep.Intersection, []string{"ok.net"}) || !rep.ErrorsFound {
+		t.Fatalf("unexpected incremental findings: %+v", rep)
+	}
+	full := c.validate(c.current())
+	if !slices.Equal(full.ThirdLevelInBlock, rep.ThirdLevelInBlock) || !slices.Equal(full.Intersection, rep.Intersection) ||
+		full.UnsortedBlockHint != rep.UnsortedBlockHint || full.ErrorsFound != rep.ErrorsFound {
+		t.Fatalf("incremental report differs from a full validation:\n%+v\n%+v", rep, full)
+	}
+
+	// A reload replaces the incrementally maintained report.
+	writeTempList(t, blockPath, []string{"bad.com"})
+	if err := c.Load(); err != nil {
+		t.Fatalf("reload: %v", err)
+	}
+	rep = c.Validate()
+	if rep.IncrementalUpdates != 0 || len(rep.ThirdLevelInBlock)+len(rep.Intersection) > 0 || rep.Generation != c.Generation() {
+		t.Fatalf("expected a fresh report after reload: %+v", rep)
+	}
+}
@@ -3,7 +3,10 @@ package handlers
 import (
 	"bufio"
 	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"io"
 	"net"
 	"net/http"
@@ -14,7 +17,10 @@ import (
 	"strings"
 	"time"
 
+	"disposable-email-domains/internal/domain"
+	"disposable-email-domains/internal/history"
 	"disposable-email-domains/internal/metrics"
+	"disposable-email-domains/internal/middleware"
 
 	"golang.org/x/net/publicsuffix"
 )
@@ -28,19 +34,13 @@ func (a *API) Blocklist(w http.ResponseWriter, r *http.Request) {
 	case http.MethodGet:
 		q := r.URL.Query()
 		summary := q.Get("summary") == "true"
-		offset := 0
-		limit := 0
-		if s := strings.TrimSpace(q.Get("offset")); s != "" {
-			if v, err := strconv.Atoi(s); err == nil && v >= 0 {
-				offset = v
-			}
-		}
-		if s := strings.TrimSpace(q.Get("limit")); s != "" {
-			if v, err := strconv.Atoi(s); err == nil && v >= 0 {
-				limit = v
-			}
+		offset, limit := pageParams(q)
+		source := strings.TrimSpace(q.Get("source"))
+		if source != "" && !middleware.IsAdmin(r) {
+			writeAPIError(w, http.StatusForbidden, "admin_token_required", "source filter requires the admin token", nil)
+			return
 		}
-		entries, total, err := readBlocklistEntriesPaged("blocklist.conf", offset, limit)
+		entries, total, err := readListEntriesPaged("blocklist.conf", offset, limit, a.blockProvenance, source, middleware.IsAdmin(r))
 		if err != nil {
 			respondError(w, http.StatusInternalServerError, err.Error())
 			return
@@ -49,7 +49,11 @@ func (a *API) Blocklist(w http.ResponseWriter, r *http.Request) {
 			respondJSON(w, http.StatusOK, map[string]any{"count": total})
 			return
 		}
-		respondJSON(w, http.StatusOK, map[string]any{"entries": entries, "count": total, "offset": offset, "limit": limit})
+		resp := map[string]any{"entries": entries, "count": total, "offset": offset, "limit": limit}
+		if source != "" {
+			resp["source"] = source
+		}
+		respondJSON(w, http.StatusOK, resp)
 	case http.MethodPost:
 		var payload struct {
 			Entries []string `json:"entries"`
@@ -69,13 +73,21 @@ func (a *API) Blocklist(w http.ResponseWriter, r *http.Request) {
 		candidates := make([]string, 0, len(payload.Entries))
 		// Track unique incoming candidates across all sources (before comparing to existing file)
 		incomingSet := make(map[string]struct{})
+		// First source (domain.SourceManual or URL) each candidate was seen in, for provenance.
+		sourceOf := make(map[string]string)
 		for _, e := range payload.Entries {
-			e = strings.ToLower(strings.TrimSpace(e))
+			e = strings.TrimSpace(e)
 			if e == "" || strings.HasPrefix(e, "#") {
 				continue
 			}
+			if !strings.HasPrefix(e, "/") { // regex patterns keep their case
+				e = strings.ToLower(e)
+			}
 			candidates = append(candidates, e)
 			incomingSet[e] = struct{}{}
+			if _, ok := sourceOf[e]; !ok {
+				sourceOf[e] = domain.SourceManual
+			}
 		}
 		// Collect URLs to fetch (deduplicated)
 		urlSet := make(map[string]struct{})
@@ -89,11 +101,37 @@ func (a *API) Blocklist(w http.ResponseWriter, r *http.Request) {
 			}
 			urlSet[u] = struct{}{}
 		}
-		const (
-			maxPerFetchEntries = 200_000 // safety upper bound
-			maxLineLen         = 256
-		)
+		const maxListedRejects = 1000 // dry runs list at most this many rejected entries
 		totalCandidateLimitTriggered := false
+		// Fetched entries that are dropped, reported by dry runs.
+		rejectedCounts := make(map[string]int)
+		rejected := []map[string]any{}
+		// addFetched reduces a fetched entry to its eTLD+1 and queues it. It
+		// returns false once the candidate cap is reached.
+		addFetched := func(v, src string) bool {
+			v = strings.ToLower(strings.TrimSpace(v))
+			if v == "" || strings.HasPrefix(v, "#") {
+				return true
+			}
+			etld1, reason := a.registrableEntry(v)
+			if reason != "" {
+				rejectedCounts[reason]++
+				if len(rejected) < maxListedRejects {
+					rejected = append(rejected, map[string]any{"entry": v[:min(len(v), maxFetchedLineLen)], "source": src, "reason": reason})
+				}
+				return true
+			}
+			if len(candidates) >= maxFetchedEntries {
+				totalCandidateLimitTriggered = true
+				return false
+			}
+			candidates = append(candidates, etld1)
+			incomingSet[etld1] = struct{}{}
+			if _, ok := sourceOf[etld1]; !ok {
+				sourceOf[etld1] = src
+			}
+			return true
+		}
 		if len(urlSet) > 0 {
 			client := &http.Client{Timeout: 12 * time.Second}
 			// cumulative limit across all fetched bodies (32MB)
@@ -154,79 +192,19 @@ func (a *API) Blocklist(w http.ResponseWriter, r *http.Request) {
 					return
 				}
 
-				trimmed := bytes.TrimSpace(data)
-
-				// Try to parse as JSON array of strings first
-				var arr []string
-				if len(trimmed) > 0 && trimmed[0] == '[' && json.Unmarshal(trimmed, &arr) == nil {
-					for _, v := range arr {
-						v = strings.ToLower(strings.TrimSpace(v))
-						if v == "" || strings.HasPrefix(v, "#") {
-							continue
-						}
-						if !isLikelyDomain(v) {
-							continue
-						}
-						if len(v) > maxLineLen {
-							continue
-						}
-						// Enforce eTLD+1
-						if etld1, err := publicsuffix.EffectiveTLDPlusOne(v); err == nil && etld1 != "" {
-							v = etld1
-						} else {
-							continue // Skip if not a valid registrable domain (e.g. is a TLD)
-						}
-						if len(candidates) < maxPerFetchEntries {
-							candidates = append(candidates, v)
-							incomingSet[v] = struct{}{}
-						} else {
-							totalCandidateLimitTriggered = true
-							break
-						}
-					}
-					continue
-				}
-
-				// If content looks like a JSON object, reject to avoid accidentally ingesting documents like wildcard maps.
-				if len(trimmed) > 0 && trimmed[0] == '{' {
-					respondError(w, http.StatusBadRequest, "unsupported JSON document; expected array of strings")
+				err = parseFetchedList(data, func(v string) bool { return addFetched(v, u) })
+				if errors.Is(err, errUnsupportedJSON) {
+					respondError(w, http.StatusBadRequest, err.Error())
 					return
 				}
-
-				// Fallback to plaintext, one domain per non-empty, non-comment line
-				s := bufio.NewScanner(bytes.NewReader(data))
-				for s.Scan() {
-					line := strings.ToLower(strings.TrimSpace(s.Text()))
-					if line == "" || strings.HasPrefix(line, "#") {
-						continue
-					}
-					if !isLikelyDomain(line) {
-						continue
-					}
-					if len(line) > maxLineLen {
-						continue
-					}
-					// Enforce eTLD+1
-					if etld1, err := publicsuffix.EffectiveTLDPlusOne(line); err == nil && etld1 != "" {
-						line = etld1
-					} else {
-						continue // Skip if not a valid registrable domain (e.g. is a TLD)
-					}
-					if len(candidates) < maxPerFetchEntries {
-						candidates = append(candidates, line)
-						incomingSet[line] = struct{}{}
-					} else {
-						totalCandidateLimitTriggered = true
-						break
-					}
-				}
-				if err := s.Err(); err != nil {
+				if err != nil {
 					respondError(w, http.StatusBadRequest, "failed reading url body: "+err.Error())
 					return
 				}
 			}
 		}
-		if len(candidates) == 0 {
+		dryRun := r.URL.Query().Get("dry_run") == "true"
+		if len(candidates) == 0 && !dryRun {
 			respondError(w, http.StatusBadRequest, "no valid entries to add")
 			return
 		}
@@ -243,44 +221,99 @@ func (a *API) Blocklist(w http.ResponseWriter, r *http.Request) {
 		}
 		existingBefore := len(existingSet)
 
-		// Filter new unique entries
+		// Filter new unique entries. Imported entries that were removed on
+		// purpose stay out; submitting them manually lifts their tombstone.
 		unique := make([]string, 0, len(candidates))
+		tombstoned := []string{}
+		tombstonedSet := make(map[string]struct{})
+		tombstonedSkips := 0
 		for _, c := range candidates {
 			if _, ok := existingSet[c]; ok {
 				continue
 			}
+			if _, ok := tombstonedSet[c]; ok {
+				tombstonedSkips++
+				continue
+			}
+			if a.Check != nil && sourceOf[c] != domain.SourceManual {
+				if _, ok := a.Check.BlockTombstone(c); ok {
+					tombstonedSet[c] = struct{}{}
+					tombstoned = append(tombstoned, c)
+					tombstonedSkips++
+					continue
+				}
+			}
 			existingSet[c] = struct{}{}
 			unique = append(unique, c)
 		}
 
+		// Compute ids for appended entries
+		added := make([]map[string]any, 0, len(unique))
+		for i, v := range unique {
+			added = append(added, map[string]any{"id": totalLines + i + 1, "domain": v, "source": sourceOf[v]})
+		}
+		meta := map[string]any{
+			"incoming_total":         len(candidates),
+			"incoming_unique":        len(incomingSet),
+			"existing_unique_before": existingBefore,
+			"existing_unique_after":  len(existingSet),
+		}
+		skipped := len(candidates) - len(unique) - tombstonedSkips
+
+		// Dry run: report what the import would do without writing anything.
+		if dryRun {
+			conflicts := []domain.AllowConflict{}
+			if a.Check != nil && len(unique) > 0 {
+				if c := a.Check.BlockConflicts(unique); c != nil {
+					conflicts = c
+				}
+			}
+			respondJSON(w, http.StatusOK, map[string]any{
+				"dry_run":               true,
+				"would_append":          len(unique),
+				"would_add":             added,
+				"skipped_duplicates":    skipped,
+				"skipped_tombstoned":    tombstonedSkips,
+				"tombstoned":            tombstoned,
+				"allowlist_conflicts":   conflicts,
+				"rejected":              rejected,
+				"rejected_counts":       rejectedCounts,
+				"candidate_cap_reached": totalCandidateLimitTriggered,
+				"resulting_size":        len(existingSet),
+				"meta":                  meta,
+			})
+			return
+		}
+
 		// Atomic append: serialize and write via temp file rename
 		if len(unique) > 0 {
 			a.blMu.Lock()
-			// read existing full file (including comments) to preserve content
-			orig, _ := os.ReadFile("blocklist.conf")
-			var b bytes.Buffer
-			b.Write(orig)
-			if len(orig) > 0 && !bytes.HasSuffix(orig, []byte{'\n'}) {
-				b.WriteByte('\n')
-			}
-			for _, v := range unique {
-				b.WriteString(v)
-				b.WriteByte('\n')
-			}
-			tmpName := filepath.Join(filepath.Dir("blocklist.conf"), "blocklist.conf.tmp")
-			if err := os.WriteFile(tmpName, b.Bytes(), 0o644); err != nil {
-				a.blMu.Unlock()
-				respondError(w, http.StatusInternalServerError, "write temp: "+err.Error())
-				return
-			}
-			if err := os.Rename(tmpName, "blocklist.conf"); err != nil {
+			if err := a.appendListFile("blocklist.conf", unique); err != nil {
 				a.blMu.Unlock()
-				respondError(w, http.StatusInternalServerError, "rename: "+err.Error())
+				respondError(w, http.StatusInternalServerError, err.Error())
 				return
 			}
-			// Ensure in-memory checker view reflects appended domains immediately.
+			// Ensure in-memory checker view reflects appended domains immediately,
+			// recording per-source provenance in the metadata sidecar.
 			if a.Check != nil {
-				a.Check.PatchBlock(unique)
+				now := time.Now().UTC()
+				actor := adminActor(r)
+				var order []string
+				bySource := make(map[string][]string)
+				for _, v := range unique {
+					src := sourceOf[v]
+					if _, ok := bySource[src]; !ok {
+						order = append(order, src)
+					}
+					bySource[src] = append(bySource[src], v)
+				}
+				for _, src := range order {
+					p := domain.Provenance{AddedAt: now, Source: src, Actor: actor}
+					if err := a.Check.PatchBlockFrom(bySource[src], p); err != nil {
+						a.Logger.Printf("blocklist: record provenance: %v", err)
+					}
+				}
+				a.recordChange(history.Change{Op: history.OpAppend, Actor: actor, Sources: order, Lists: map[string]history.ListDiff{"blocklist": {Added: unique}}})
 			}
 			// Update status snapshot
 			if a.Check != nil {
@@ -307,37 +340,134 @@ func (a *API) Blocklist(w http.ResponseWriter, r *http.Request) {
 			}
 		}
 
-		// Compute ids for appended entries
-		added := make([]map[string]any, 0, len(unique))
-		for i, v := range unique {
-			added = append(added, map[string]any{"id": totalLines + i + 1, "domain": v})
-		}
-
 		appended := len(unique)
-		skipped := len(candidates) - len(unique)
-		incomingTotal := len(candidates)
-		incomingUnique := len(incomingSet)
-		existingAfter := len(existingSet)
 		if appended > 0 {
 			metrics.BlocklistAppendsTotal.Add(float64(appended))
 		}
 		if skipped > 0 {
 			metrics.BlocklistDuplicatesSkippedTotal.Add(float64(skipped))
 		}
+		if tombstonedSkips > 0 {
+			metrics.BlocklistTombstonedSkippedTotal.Add(float64(tombstonedSkips))
+		}
 		respondJSON(w, http.StatusOK, map[string]any{
 			"appended":           appended,
 			"skipped_duplicates": skipped,
+			"skipped_tombstoned": tombstonedSkips,
+			"tombstoned":         tombstoned,
 			"added":              added,
 			"reloaded":           reloaded,
-			"meta": map[string]any{
-				"incoming_total":         incomingTotal,
-				"incoming_unique":        incomingUnique,
-				"existing_unique_before": existingBefore,
-				"existing_unique_after":  existingAfter,
-			},
+			"meta":               meta,
 		})
+	case http.MethodDelete:
+		a.deleteBlocklist(w, r)
 	default:
-		respondMethodNotAllowed(w, http.MethodGet, http.MethodPost)
+		respondMethodNotAllowed(w, http.MethodGet, http.MethodPost, http.MethodDelete)
+	}
+}
+
+// deleteBlocklist serves DELETE /blocklist: ?entry= removes a single entry,
+// a {"entries":[...],"reason":"..."} body several. Removed entries are
+// tombstoned unless ?tombstone=false.
+func (a *API) deleteBlocklist(w http.ResponseWriter, r *http.Request) {
+	if a.Check == nil {
+		respondError(w, http.StatusServiceUnavailable, "checker not initialized")
+		return
+	}
+	var payload struct {
+		Entries []string `json:"entries"`
+		Reason  string   `json:"reason"`
+	}
+	q := r.URL.Query()
+	if e := strings.TrimSpace(q.Get("entry")); e != "" {
+		payload.Entries = []string{e}
+		payload.Reason = q.Get("reason")
+	} else if err := decodeJSON(w, r, &payload, 5<<20); err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if len(payload.Entries) == 0 {
+		respondError(w, http.StatusBadRequest, "provide entries or ?entry=")
+		return
+	}
+	var tomb *domain.Tombstone
+	if q.Get("tombstone") != "false" {
+		tomb = &domain.Tombstone{Actor: adminActor(r), Reason: strings.TrimSpace(payload.Reason)}
+	}
+	a.blMu.Lock()
+	removed, missing, err := a.Check.RemoveBlock(payload.Entries, tomb)
+	if len(removed) > 0 {
+		a.recordChange(history.Change{Op: history.OpRemove, Actor: adminActor(r), Lists: map[string]history.ListDiff{"blocklist": {Removed: removed}}})
+	}
+	a.blMu.Unlock()
+	if err != nil && len(removed) == 0 {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if err != nil {
+		a.Logger.Printf("blocklist: record tombstones: %v", err)
+	}
+	if len(removed) > 0 {
+		metrics.BlocklistRemovalsTotal.Add(float64(len(removed)))
+		a.RefreshListStatus()
+		a.Logger.Printf("blocklist: %d entries removed", len(removed))
+	}
+	if removed == nil {
+		removed = []string{}
+	}
+	if missing == nil {
+		missing = []string{}
+	}
+	respondJSON(w, http.StatusOK, map[string]any{
+		"removed":    removed,
+		"not_found":  missing,
+		"tombstoned": tomb != nil && len(removed) > 0,
+	})
+}
+
+// BlocklistTombstones serves /blocklist/tombstones: GET lists the entries
+// removed on purpose, DELETE {"entries":[...]} lifts tombstones so imports
+// may add the entries again.
+func (a *API) BlocklistTombstones(w http.ResponseWriter, r *http.Request) {
+	if a.Check == nil {
+		respondError(w, http.StatusServiceUnavailable, "checker not initialized")
+		return
+	}
+	switch r.Method {
+	case http.MethodGet:
+		ts := a.Check.BlockTombstones()
+		respondJSON(w, http.StatusOK, map[string]any{"tombstones": ts, "count": len(ts)})
+	case http.MethodDelete:
+		var payload struct {
+			Entries []string `json:"entries"`
+		}
+		if err := decodeJSON(w, r, &payload, 5<<20); err != nil {
+			respondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if len(payload.Entries) == 0 {
+			respondError(w, http.StatusBadRequest, "provide entries")
+			return
+		}
+		a.blMu.Lock()
+		lifted, err := a.Check.LiftBlockTombstones(payload.Entries)
+		if len(lifted) > 0 {
+			a.recordChange(history.Change{Op: history.OpLift, Actor: adminActor(r), Lists: map[string]history.ListDiff{}})
+		}
+		a.blMu.Unlock()
+		if err != nil && len(lifted) == 0 {
+			respondError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		if err != nil {
+			a.Logger.Printf("blocklist: record lifted tombstones: %v", err)
+		}
+		if lifted == nil {
+			lifted = []string{}
+		}
+		respondJSON(w, http.StatusOK, map[string]any{"lifted": lifted})
+	default:
+		respondMethodNotAllowed(w, http.MethodGet, http.MethodDelete)
 	}
 }
 
@@ -351,23 +481,81 @@ func (a *API) ReloadHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	strict := r.URL.Query().Get("strict") == "true"
-	if err := a.Check.Reload(strict); err != nil {
+	if err := a.reloadLists(strict, adminActor(r)); err != nil {
 		respondError(w, http.StatusBadRequest, err.Error())
 		return
 	}
 	// Update counts after full reload
-	if a.Check != nil {
-		a.statusMu.Lock()
-		a.status.BlocklistCount = a.Check.BlockCount()
-		a.status.AllowlistCount = a.Check.AllowCount()
-		a.status.LastListUpdate = time.Now().UTC()
-		a.statusMu.Unlock()
-	}
+	a.RefreshListStatus()
 	respondJSON(w, http.StatusOK, map[string]any{"reloaded": true, "strict": strict})
 }
 
-// readBlocklistEntriesPaged streams the file and returns up to 'limit' entries after skipping 'offset' entries.
-func readBlocklistEntriesPaged(path string, offset, limit int) ([]map[string]any, int, error) {
+// blockProvenance looks up an entry's provenance; nil-safe for tests without a checker.
+func (a *API) blockProvenance(entry string) (domain.Provenance, bool) {
+	if a.Check == nil {
+		return domain.Provenance{}, false
+	}
+	return a.Check.BlockProvenance(entry)
+}
+
+// adminActor identifies the admin token of a request without revealing it:
+// "token:" followed by the first 12 hex digits of its SHA-256.
+func adminActor(r *http.Request) string {
+	tok := strings.TrimSpace(r.Header.Get("X-Admin-Token"))
+	if tok == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(tok))
+	return "token:" + hex.EncodeToString(sum[:])[:12]
+}
+
+// pageParams parses the ?offset= and ?limit= query parameters; invalid or
+// negative values count as 0 (no offset, no limit).
+func pageParams(q url.Values) (offset, limit int) {
+	if s := strings.TrimSpace(q.Get("offset")); s != "" {
+		if v, err := strconv.Atoi(s); err == nil && v >= 0 {
+			offset = v
+		}
+	}
+	if s := strings.TrimSpace(q.Get("limit")); s != "" {
+		if v, err := strconv.Atoi(s); err == nil && v >= 0 {
+			limit = v
+		}
+	}
+	return offset, limit
+}
+
+// appendListFile appends entries to a list file, preserving its content
+// (including comments), and replaces it atomically via a temp file rename.
+// The watcher is told the contents written, which callers patch in memory.
+func (a *API) appendListFile(path string, entries []string) error {
+	orig, _ := os.ReadFile(path)
+	var b bytes.Buffer
+	b.Write(orig)
+	if len(orig) > 0 && !bytes.HasSuffix(orig, []byte{'\n'}) {
+		b.WriteByte('\n')
+	}
+	for _, v := range entries {
+		b.WriteString(v)
+		b.WriteByte('\n')
+	}
+	tmpName := filepath.Join(filepath.Dir(path), filepath.Base(path)+".tmp")
+	if err := os.WriteFile(tmpName, b.Bytes(), 0o644); err != nil {
+		return errors.New("write temp: " + err.Error())
+	}
+	if err := os.Rename(tmpName, path); err != nil {
+		return errors.New("rename: " + err.Error())
+	}
+	if a.ListWatch != nil {
+		a.ListWatch.Sync(path, b.Bytes())
+	}
+	return nil
+}
+
+// readListEntriesPaged streams the file and returns up to 'limit' entries after skipping 'offset' entries.
+// Entries carry their recorded provenance; a non-empty source keeps only entries imported from it.
+// Without full only added_at is reported: sources may be private URLs and actors token fingerprints.
+func readListEntriesPaged(path string, offset, limit int, meta func(string) (domain.Provenance, bool), source string, full bool) ([]map[string]any, int, error) {
 	f, err := os.Open(path)
 	if err != nil {
 		if os.IsNotExist(err) {
@@ -387,6 +575,10 @@ func readBlocklistEntriesPaged(path string, offset, limit int) ([]map[string]any
 		if line == "" || strings.HasPrefix(line, "#") {
 			continue
 		}
+		p, hasMeta := meta(line)
+		if source != "" && (!hasMeta || p.Source != source) {
+			continue
+		}
 		entriesSeen++
 		if entriesSeen <= offset {
 			continue
@@ -394,7 +586,17 @@ func readBlocklistEntriesPaged(path string, offset, limit int) ([]map[string]any
 		if limit > 0 && len(out) >= limit {
 			continue
 		}
-		out = append(out, map[string]any{"id": fileLine, "domain": strings.ToLower(line)})
+		e := map[string]any{"id": fileLine, "domain": strings.ToLower(line)}
+		if hasMeta {
+			e["added_at"] = p.AddedAt
+			if full {
+				e["source"] = p.Source
+				if p.Actor != "" {
+					e["actor"] = p.Actor
+				}
+			}
+		}
+		out = append(out, e)
 	}
 	if err := s.Err(); err != nil {
 		return nil, entriesSeen, err
@@ -427,6 +629,64 @@ func buildExistingSet(path string, set map[string]struct{}) (int, error) {
 	return lineNo, nil
 }
 
+// Limits for remote lists imported via POST /blocklist and subscriptions.
+const (
+	maxFetchedEntries = 200_000 // safety upper bound per request or source
+	maxFetchedLineLen = 256
+)
+
+var errUnsupportedJSON = errors.New("unsupported JSON document; expected array of strings")
+
+// parseFetchedList calls add for each entry of a fetched list: a JSON array
+// of strings or one entry per line. add returns false to stop early. JSON
+// objects are rejected to avoid accidentally ingesting documents like
+// wildcard maps.
+func parseFetchedList(data []byte, add func(string) bool) error {
+	trimmed := bytes.TrimSpace(data)
+	var arr []string
+	if len(trimmed) > 0 && trimmed[0] == '[' && json.Unmarshal(trimmed, &arr) == nil {
+		for _, v := range arr {
+			if !add(v) {
+				break
+			}
+		}
+		return nil
+	}
+	if len(trimmed) > 0 && trimmed[0] == '{' {
+		return errUnsupportedJSON
+	}
+	s := bufio.NewScanner(bytes.NewReader(data))
+	for s.Scan() {
+		if !add(s.Text()) {
+			break
+		}
+	}
+	return s.Err()
+}
+
+// registrableEntry reduces a fetched entry (trimmed, lowercased) to its
+// eTLD+1, or returns why it cannot be imported: invalid_domain, too_long or
+// not_registrable. The eTLD+1 comes from the checker's runtime suffix list,
+// falling back to the compiled-in table when no checker is configured.
+func (a *API) registrableEntry(v string) (etld1, reason string) {
+	switch {
+	case !isLikelyDomain(v):
+		return "", "invalid_domain"
+	case len(v) > maxFetchedLineLen:
+		return "", "too_long"
+	}
+	var err error
+	if a.Check != nil {
+		etld1, err = a.Check.RegistrableDomain(v)
+	} else {
+		etld1, err = publicsuffix.EffectiveTLDPlusOne(v)
+	}
+	if err != nil || etld1 == "" { // e.g. a bare public suffix
+		return "", "not_registrable"
+	}
+	return etld1, ""
+}
+
 // isDisallowedIP returns true if the IP is within private, loopback, link-local,
 // multicast, unspecified or unique-local (IPv6) ranges. This reduces SSRF risk
 // when fetching remote blocklist sources.
@@ -0,0 +1,239 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"disposable-email-domains/internal/domain"
+	"disposable-email-domains/internal/history"
+	"disposable-email-domains/internal/metrics"
+	"disposable-email-domains/internal/middleware"
+)
+
+// Allowlist manages allowlist.conf like Blocklist manages blocklist.conf:
+//
+//	GET    /allowlist    entries with provenance (?summary=true, ?offset=&limit=, ?source=)
+//	POST   /allowlist    {"entries":[...]} appends new entries and reports the
+//	                     blocklist entries they override as conflicts
+//	                     (?dry_run=true reports without writing)
+//	DELETE /allowlist    ?entry= or {"entries":[...]} removes entries
+//
+// Remote imports are not supported; allowlisting is a deliberate, per-entry
+// decision.
+func (a *API) Allowlist(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/allowlist" {
+		http.NotFound(w, r)
+		return
+	}
+	switch r.Method {
+	case http.MethodGet:
+		q := r.URL.Query()
+		offset, limit := pageParams(q)
+		source := strings.TrimSpace(q.Get("source"))
+		if source != "" && !middleware.IsAdmin(r) {
+			writeAPIError(w, http.StatusForbidden, "admin_token_required", "source filter requires the admin token", nil)
+			return
+		}
+		entries, total, err := readListEntriesPaged("allowlist.conf", offset, limit, a.allowProvenance, source, middleware.IsAdmin(r))
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		if q.Get("summary") == "true" {
+			respondJSON(w, http.StatusOK, map[string]any{"count": total})
+			return
+		}
+		resp := map[string]any{"entries": entries, "count": total, "offset": offset, "limit": limit}
+		if source != "" {
+			resp["source"] = source
+		}
+		respondJSON(w, http.StatusOK, resp)
+	case http.MethodPost:
+		a.addAllowlist(w, r)
+	case http.MethodDelete:
+		a.deleteAllowlist(w, r)
+	default:
+		respondMethodNotAllowed(w, http.MethodGet, http.MethodPost, http.MethodDelete)
+	}
+}
+
+func (a *API) addAllowlist(w http.ResponseWriter, r *http.Request) {
+	var payload struct {
+		Entries []string `json:"entries"`
+	}
+	if err := decodeJSON(w, r, &payload, 1<<20); err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	dryRun := r.URL.Query().Get("dry_run") == "true"
+	candidates := make([]string, 0, len(payload.Entries))
+	rejected := []map[string]any{}
+	rejectedCounts := make(map[string]int)
+	for _, e := range payload.Entries {
+		e = strings.TrimSpace(e)
+		if e == "" || strings.HasPrefix(e, "#") {
+			continue
+		}
+		v, reason := a.allowlistEntry(e)
+		if reason != "" {
+			rejectedCounts[reason]++
+			rejected = append(rejected, map[string]any{"entry": e[:min(len(e), maxFetchedLineLen)], "reason": reason})
+			continue
+		}
+		candidates = append(candidates, v)
+	}
+	if len(candidates) == 0 && (len(rejected) == 0 || !dryRun) {
+		if len(rejected) == 0 {
+			respondError(w, http.StatusBadRequest, "provide entries")
+			return
+		}
+		writeAPIError(w, http.StatusBadRequest, "no_valid_entries", "no valid entries to add", map[string]any{"rejected": rejected})
+		return
+	}
+
+	a.blMu.Lock()
+	existingSet := make(map[string]struct{})
+	totalLines, err := buildExistingSet("allowlist.conf", existingSet)
+	if err != nil {
+		a.blMu.Unlock()
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	unique := make([]string, 0, len(candidates))
+	for _, c := range candidates {
+		if _, ok := existingSet[c]; ok {
+			continue
+		}
+		existingSet[c] = struct{}{}
+		unique = append(unique, c)
+	}
+	added := make([]map[string]any, 0, len(unique))
+	for i, v := range unique {
+		added = append(added, map[string]any{"id": totalLines + i + 1, "domain": v, "source": domain.SourceManual})
+	}
+	// Conflicts are computed against the blocklist the entries are added to,
+	// before the write.
+	conflicts := []domain.AllowConflict{}
+	if a.Check != nil && len(unique) > 0 {
+		if c := a.Check.AllowConflicts(unique); c != nil {
+			conflicts = c
+		}
+	}
+	skipped := len(candidates) - len(unique)
+
+	// Dry run: report what the append would do without writing anything.
+	if dryRun {
+		a.blMu.Unlock()
+		respondJSON(w, http.StatusOK, map[string]any{
+			"dry_run":            true,
+			"would_append":       len(unique),
+			"would_add":          added,
+			"skipped_duplicates": skipped,
+			"conflicts":          conflicts,
+			"rejected":           rejected,
+			"rejected_counts":    rejectedCounts,
+		})
+		return
+	}
+	if len(unique) > 0 {
+		if err := a.appendListFile("allowlist.conf", unique); err != nil {
+			a.blMu.Unlock()
+			respondError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		if a.Check != nil {
+			p := domain.Provenance{AddedAt: time.Now().UTC(), Source: domain.SourceManual, Actor: adminActor(r)}
+			if err := a.Check.PatchAllowFrom(unique, p); err != nil {
+				a.Logger.Printf("allowlist: record provenance: %v", err)
+			}
+			a.RefreshListStatus()
+			a.recordChange(history.Change{Op: history.OpAppend, Actor: p.Actor, Sources: []string{domain.SourceManual}, Lists: map[string]history.ListDiff{"allowlist": {Added: unique}}})
+		}
+	}
+	a.blMu.Unlock()
+
+	if len(unique) > 0 {
+		metrics.AllowlistAppendsTotal.Add(float64(len(unique)))
+		a.Logger.Printf("allowlist: %d entries added, %d blocklist conflicts", len(unique), len(conflicts))
+	}
+	respondJSON(w, http.StatusOK, map[string]any{
+		"appended":           len(unique),
+		"skipped_duplicates": skipped,
+		"added":              added,
+		"conflicts":          conflicts,
+		"rejected":           rejected,
+	})
+}
+
+// allowlistEntry validates a submitted allowlist entry and returns its stored
+// form, or why it is rejected: invalid_pattern, invalid_domain, too_long or
+// not_registrable. Domains are IDNA-mapped and checked like imported
+// blocklist entries, but not reduced to their registrable domain, which would
+// widen the allowance.
+func (a *API) allowlistEntry(e string) (entry, reason string) {
+	v, err := domain.NormalizeEntry(e)
+	if domain.IsPattern(e) {
+		if err != nil {
+			return "", "invalid_pattern"
+		}
+		return v, ""
+	}
+	if err != nil {
+		return "", "invalid_domain"
+	}
+	if _, reason := a.registrableEntry(v); reason != "" {
+		return "", reason
+	}
+	return v, ""
+}
+
+func (a *API) deleteAllowlist(w http.ResponseWriter, r *http.Request) {
+	if a.Check == nil {
+		respondError(w, http.StatusServiceUnavailable, "checker not initialized")
+		return
+	}
+	var payload struct {
+		Entries []string `json:"entries"`
+	}
+	if e := strings.TrimSpace(r.URL.Query().Get("entry")); e != "" {
+		payload.Entries = []string{e}
+	} else if err := decodeJSON(w, r, &payload, 1<<20); err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if len(payload.Entries) == 0 {
+		respondError(w, http.StatusBadRequest, "provide entries or ?entry=")
+		return
+	}
+	a.blMu.Lock()
+	removed, missing, err := a.Check.RemoveAllow(payload.Entries)
+	if len(removed) > 0 {
+		a.recordChange(history.Change{Op: history.OpRemove, Actor: adminActor(r), Lists: map[string]history.ListDiff{"allowlist": {Removed: removed}}})
+	}
+	a.blMu.Unlock()
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if len(removed) > 0 {
+		metrics.AllowlistRemovalsTotal.Add(float64(len(removed)))
+		a.RefreshListStatus()
+		a.Logger.Printf("allowlist: %d entries removed", len(removed))
+	}
+	if removed == nil {
+		removed = []string{}
+	}
+	if missing == nil {
+		missing = []string{}
+	}
+	respondJSON(w, http.StatusOK, map[string]any{"removed": removed, "not_found": missing})
+}
+
+// allowProvenance looks up an entry's provenance; nil-safe for tests without a checker.
+func (a *API) allowProvenance(entry string) (domain.Provenance, bool) {
+	if a.Check == nil {
+		return domain.Provenance{}, false
+	}
+	return a.Check.AllowProvenance(entry)
+}
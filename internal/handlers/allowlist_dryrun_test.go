@@ -0,0 +1,109 @@
+package handlers
+
+import (
+	"encoding/json"
+	"io"
+	"log"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"disposable-email-domains/internal/domain"
+)
+
+func TestAllowlistDryRun(t *testing.T) {
+	t.Chdir(t.TempDir())
+	_ = os.WriteFile("allowlist.conf", []byte("# allowlist\ngood.com\n"), 0o644)
+	_ = os.WriteFile("blocklist.conf", []byte("bad.com\nmail.shop.com\n"), 0o644)
+	chk := domain.NewChecker("allowlist.conf", "blocklist.conf")
+	if err := chk.Load(); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	api := &API{Check: chk, Logger: log.New(io.Discard, "", 0)}
+	post := func(target string, entries ...string) (int, []byte) {
+		if entries == nil {
+			entries = []string{"good.com", "Shop.com", "bad.com"}
+		}
+		body, _ := json.Marshal(map[string]any{"entries": entries})
+		req := httptest.NewRequest(http.MethodPost, target, strings.NewReader(string(body)))
+		req.Header.Set("Content-Type", "application/json")
+		rr := httptest.NewRecorder()
+		api.Allowlist(rr, req)
+		return rr.Code, rr.Body.Bytes()
+	}
+	var resp struct {
+		DryRun      bool                   `json:"dry_run"`
+		WouldAppend int                    `json:"would_append"`
+		Appended    int                    `json:"appended"`
+		Skipped     int                    `json:"skipped_duplicates"`
+		Conflicts   []domain.AllowConflict `json:"conflicts"`
+		WouldAdd    []struct {
+			Domain string `json:"domain"`
+		} `json:"would_add"`
+		Rejected []struct {
+			Entry  string `json:"entry"`
+			Reason string `json:"reason"`
+		} `json:"rejected"`
+	}
+
+	code, body := post("/allowlist?dry_run=true")
+	if err := json.Unmarshal(body, &resp); code != http.StatusOK || err != nil {
+		t.Fatalf("expected 200, got %d body=%s", code, body)
+	}
+	if !resp.DryRun || resp.WouldAppend != 2 || resp.Skipped != 1 || len(resp.Conflicts) != 2 {
+		t.Fatalf("unexpected dry run: %s", body)
+	}
+	if data, _ := os.ReadFile("allowlist.conf"); string(data) != "# allowlist\ngood.com\n" {
+		t.Fatalf("dry run modified allowlist.conf:\n%s", data)
+	}
+	if chk.Check("bad.com").Allowlisted {
+		t.Fatal("dry run patched the in-memory lists")
+	}
+
+	// The append reports the same conflicts.
+	resp.Conflicts = nil
+	code, body = post("/allowlist")
+	if err := json.Unmarshal(body, &resp); code != http.StatusOK || err != nil {
+		t.Fatalf("expected 200, got %d body=%s", code, body)
+	}
+	if resp.Appended != 2 || len(resp.Conflicts) != 2 || resp.Conflicts[1].Entry != "shop.com" || resp.Conflicts[1].Kind != domain.ConflictSubdomain {
+		t.Fatalf("unexpected append: %s", body)
+	}
+	if !chk.Check("bad.com").Allowlisted {
+		t.Fatal("expected bad.com allowlisted")
+	}
+
+	// Entries are validated and normalized like blocklist imports.
+	resp.Rejected = nil
+	code, body = post("/allowlist?dry_run=true", "Bücher.example", "not a domain", "co.uk", "*.[x", "/[/", "*.Mail.example")
+	if err := json.Unmarshal(body, &resp); code != http.StatusOK || err != nil {
+		t.Fatalf("expected 200, got %d body=%s", code, body)
+	}
+	if resp.WouldAppend != 2 || resp.WouldAdd[0].Domain != "xn--bcher-kva.example" || resp.WouldAdd[1].Domain != "*.mail.example" {
+		t.Fatalf("unexpected normalization: %s", body)
+	}
+	reasons := map[string]string{}
+	for _, r := range resp.Rejected {
+		reasons[r.Entry] = r.Reason
+	}
+	if want := map[string]string{"not a domain": "invalid_domain", "co.uk": "not_registrable", "*.[x": "invalid_pattern", "/[/": "invalid_pattern"}; !maps.Equal(reasons, want) {
+		t.Fatalf("unexpected rejects: %v", reasons)
+	}
+	if code, body = post("/allowlist", "co.uk"); code != http.StatusBadRequest || !strings.Contains(string(body), "not_registrable") {
+		t.Fatalf("expected 400 with the reject, got %d %s", code, body)
+	}
+
+	// Registrability follows the checker's runtime suffix list, not the
+	// compiled-in table.
+	psl, err := domain.ParsePSL([]byte("// ===BEGIN ICANN DOMAINS===\ncom\n// ===END ICANN DOMAINS===\n// ===BEGIN PRIVATE DOMAINS===\nshop.com\n// ===END PRIVATE DOMAINS===\n"))
+	if err != nil {
+		t.Fatalf("parse psl: %v", err)
+	}
+	chk.SetPSL(psl)
+	if code, body = post("/allowlist", "shop.com"); code != http.StatusBadRequest || !strings.Contains(string(body), "not_registrable") {
+		t.Fatalf("expected shop.com to be a public suffix after the PSL update, got %d %s", code, body)
+	}
+}
@@ -3,17 +3,23 @@ package handlers
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
 	"log"
 	"net/http"
+	"net/url"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
 
 	"disposable-email-domains/internal/config"
 	"disposable-email-domains/internal/domain"
+	"disposable-email-domains/internal/history"
+	"disposable-email-domains/internal/subscriptions"
+	"disposable-email-domains/internal/watcher"
 
-	"golang.org/x/net/publicsuffix"
+	"golang.org/x/time/rate"
 )
 
 type Item struct {
@@ -35,12 +41,23 @@ type API struct {
 	Store  Store
 	Logger *log.Logger
 	Check  *domain.Checker
-	// mutex to serialize blocklist mutation operations
+	// mutex to serialize list mutation operations
 	blMu     sync.Mutex
 	statusMu sync.RWMutex
 	status   ServiceStatus
 	// optional config for limits
 	cfg *config.Config
+	// ListWatch reloads the lists on file changes; nil when disabled.
+	ListWatch *watcher.Watcher
+	// History journals list mutations; nil when disabled.
+	History *history.Store
+	// Subscriptions syncs the blocklist with upstream lists; nil when none
+	// are configured.
+	Subscriptions *subscriptions.Subscriber
+	// historical checkers for as_of queries, least recently used first
+	asOfMu    sync.Mutex
+	asOf      []*asOfEntry
+	asOfLimit *rate.Limiter
 }
 
 // Attaches configuration for limits and options.
@@ -50,10 +67,14 @@ func (a *API) SetConfig(cfg *config.Config) {
 
 // Lightweight snapshot for diagnostics.
 type ServiceStatus struct {
-	BlocklistCount int       `json:"blocklist_count"`
-	AllowlistCount int       `json:"allowlist_count"`
-	LastListUpdate time.Time `json:"last_list_update"`
-	Ready          bool      `json:"ready"`
+	BlocklistCount int             `json:"blocklist_count"`
+	AllowlistCount int             `json:"allowlist_count"`
+	LastListUpdate time.Time       `json:"last_list_update"`
+	ListGeneration uint64          `json:"list_generation"`
+	Ready          bool            `json:"ready"`
+	ListWatch      *watcher.Status `json:"list_watch,omitempty"`
+	// Subscriptions is the refresh state of each upstream list.
+	Subscriptions []subscriptions.Status `json:"subscriptions,omitempty"`
 }
 
 func (a *API) InitStatus() {
@@ -71,6 +92,18 @@ func (a *API) InitStatus() {
 	a.statusMu.Unlock()
 }
 
+// RefreshListStatus updates the list counts after a full reload.
+func (a *API) RefreshListStatus() {
+	if a.Check == nil {
+		return
+	}
+	a.statusMu.Lock()
+	a.status.BlocklistCount = a.Check.BlockCount()
+	a.status.AllowlistCount = a.Check.AllowCount()
+	a.status.LastListUpdate = time.Now().UTC()
+	a.statusMu.Unlock()
+}
+
 // Helpers
 
 func respondJSON(w http.ResponseWriter, status int, v any) {
@@ -117,6 +150,19 @@ func respondMethodNotAllowed(w http.ResponseWriter, allowed ...string) {
 	respondError(w, http.StatusMethodNotAllowed, "method not allowed")
 }
 
+// queryBool parses an optional boolean query parameter; absent means false.
+func queryBool(q url.Values, name string) (bool, error) {
+	v := q.Get(name)
+	if v == "" {
+		return false, nil
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		return false, fmt.Errorf("%s must be a boolean", name)
+	}
+	return b, nil
+}
+
 func decodeJSON(w http.ResponseWriter, r *http.Request, v any, maxBytes int64) error {
 	if r.Method == http.MethodPost || r.Method == http.MethodPut || r.Method == http.MethodPatch {
 		ct := r.Header.Get("Content-Type")
@@ -145,7 +191,8 @@ func decodeJSON(w http.ResponseWriter, r *http.Request, v any, maxBytes int64) e
 	return nil
 }
 
-// Filters out URLs, emails, and obviously invalid labels, and ensures the string has a known public suffix.
+// Filters out URLs, emails, and obviously invalid labels. Whether the string
+// is registrable under the public suffix list is checked by registrableEntry.
 func isLikelyDomain(s string) bool {
 	if s == "" {
 		return false
@@ -181,16 +228,5 @@ func isLikelyDomain(s string) bool {
 			return false
 		}
 	}
-	// Check it has a known public suffix and an eTLD+1
-	if _, icann := publicsuffix.PublicSuffix(s); !icann {
-		// still allow private suffixes, but ensure EffectiveTLDPlusOne works
-		if _, err := publicsuffix.EffectiveTLDPlusOne(s); err != nil {
-			return false
-		}
-		return true
-	}
-	if _, err := publicsuffix.EffectiveTLDPlusOne(s); err != nil {
-		return false
-	}
 	return true
 }
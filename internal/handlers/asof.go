@@ -0,0 +1,149 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"os"
+	"slices"
+	"strconv"
+	"time"
+
+	"disposable-email-domains/internal/domain"
+	"disposable-email-domains/internal/history"
+
+	"golang.org/x/time/rate"
+)
+
+// defaultAsOfCacheSize bounds the historical checkers kept for as_of queries
+// unless AS_OF_CACHE_SIZE is set; each holds a full copy of the lists, and
+// as_of needs no token.
+const defaultAsOfCacheSize = 4
+
+// Versions missing from the cache are rebuilt at most asOfLoadRate times per
+// second, with bursts of asOfLoadBurst.
+const (
+	asOfLoadRate  = 1
+	asOfLoadBurst = 4
+)
+
+// errAsOfBusy is returned when a version would have to be rebuilt and the
+// load rate is exhausted.
+var errAsOfBusy = errors.New("too many historical list versions requested; retry shortly")
+
+// asOfChecker answers checks from the list version in effect at the as_of
+// time (RFC 3339 or Unix seconds), rebuilt from the history snapshots.
+func (a *API) asOfChecker(w http.ResponseWriter, r *http.Request, raw string) (checkFunc, bool) {
+	if a.History == nil {
+		writeAPIError(w, http.StatusServiceUnavailable, "history_disabled", "as_of requires the list history (HISTORY_DIR)", nil)
+		return nil, false
+	}
+	t, err := parseAsOf(raw)
+	if err != nil {
+		writeAPIError(w, http.StatusBadRequest, "invalid_as_of", "as_of must be an RFC 3339 time or Unix seconds", nil)
+		return nil, false
+	}
+	v, err := a.History.AsOf(t)
+	if err != nil {
+		writeAPIError(w, http.StatusNotFound, "no_version", err.Error(), map[string]any{"as_of": t})
+		return nil, false
+	}
+	c, err := a.versionChecker(v)
+	if errors.Is(err, errAsOfBusy) {
+		w.Header().Set("Retry-After", "1")
+		writeAPIError(w, http.StatusTooManyRequests, "as_of_busy", err.Error(), map[string]any{"version": v.ID})
+		return nil, false
+	}
+	if errors.Is(err, history.ErrNoSnapshot) {
+		writeAPIError(w, http.StatusGone, "snapshot_pruned", err.Error(), map[string]any{"version": v.ID})
+		return nil, false
+	}
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return nil, false
+	}
+	ctx := r.Context()
+	asOf := &domain.AsOf{Requested: t, Version: v.ID, RecordedAt: v.At}
+	return func(s string) domain.Result {
+		res := c.CheckContext(ctx, s)
+		// The generation counter is process-local; name the version instead.
+		res.Generation, res.UpdatedAt, res.AsOf = uint64(v.ID), v.At, asOf
+		if h := w.Header(); h.Get(generationHeader) == "" {
+			h.Set(generationHeader, strconv.FormatUint(res.Generation, 10))
+		}
+		return res
+	}, true
+}
+
+func parseAsOf(s string) (time.Time, error) {
+	if sec, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return time.Unix(sec, 0).UTC(), nil
+	}
+	t, err := time.Parse(time.RFC3339Nano, s)
+	return t.UTC(), err
+}
+
+// versionChecker returns a checker over the lists of version v, loading it
+// from the snapshot on first use. Concurrent requests for the same version
+// share one load, which runs outside asOfMu; loads are rate limited since
+// as_of is available without a token.
+func (a *API) versionChecker(v history.Version) (*domain.Checker, error) {
+	a.asOfMu.Lock()
+	if i := slices.IndexFunc(a.asOf, func(e *asOfEntry) bool { return e.version == v.ID }); i >= 0 {
+		e := a.asOf[i]
+		a.asOf = append(slices.Delete(a.asOf, i, i+1), e)
+		a.asOfMu.Unlock()
+		<-e.done
+		return e.check, e.err
+	}
+	if a.asOfLimit == nil {
+		a.asOfLimit = rate.NewLimiter(asOfLoadRate, asOfLoadBurst)
+	}
+	if !a.asOfLimit.Allow() {
+		a.asOfMu.Unlock()
+		return nil, errAsOfBusy
+	}
+	e := &asOfEntry{version: v.ID, done: make(chan struct{})}
+	if n := a.asOfCacheSize(); len(a.asOf) >= n {
+		a.asOf = slices.Delete(a.asOf, 0, len(a.asOf)-n+1)
+	}
+	a.asOf = append(a.asOf, e)
+	a.asOfMu.Unlock()
+
+	e.check, e.err = a.loadVersion(v.ID)
+	close(e.done)
+	if e.err != nil {
+		a.asOfMu.Lock()
+		a.asOf = slices.DeleteFunc(a.asOf, func(x *asOfEntry) bool { return x == e })
+		a.asOfMu.Unlock()
+	}
+	return e.check, e.err
+}
+
+func (a *API) loadVersion(id int) (*domain.Checker, error) {
+	dir, err := os.MkdirTemp("", "lists-as-of-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+	paths, err := a.History.Extract(id, dir)
+	if err != nil {
+		return nil, err
+	}
+	return a.Check.Derive(paths[a.History.Lists["allowlist"]], paths[a.History.Lists["blocklist"]])
+}
+
+func (a *API) asOfCacheSize() int {
+	if a.cfg != nil && a.cfg.AsOfCacheSize > 0 {
+		return a.cfg.AsOfCacheSize
+	}
+	return defaultAsOfCacheSize
+}
+
+// asOfEntry is a cached historical checker, see versionChecker. check and
+// err are set before done is closed.
+type asOfEntry struct {
+	version int
+	check   *domain.Checker
+	err     error
+	done    chan struct{}
+}
@@ -0,0 +1,130 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"disposable-email-domains/internal/domain"
+	"disposable-email-domains/internal/history"
+)
+
+func TestCheckAsOf(t *testing.T) {
+	dir := t.TempDir()
+	allowPath := filepath.Join(dir, "allowlist.conf")
+	blockPath := filepath.Join(dir, "blocklist.conf")
+	_ = os.WriteFile(allowPath, []byte("good.com\n"), 0o644)
+	_ = os.WriteFile(blockPath, []byte("a.com\n"), 0o644)
+	chk := domain.NewChecker(allowPath, blockPath)
+	if err := chk.Load(); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	logger := log.New(io.Discard, "", 0)
+	hist := history.New(logger, filepath.Join(dir, "history"), map[string]string{"allowlist": allowPath, "blocklist": blockPath}, chk.ListFiles()...)
+	hist.Generation = chk.Generation
+	if err := hist.Open(); err != nil {
+		t.Fatal(err)
+	}
+	v1, _, err := hist.Record(history.Change{Op: history.OpStartup})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = os.WriteFile(blockPath, []byte("a.com\nb.com\n"), 0o644)
+	if err := chk.Load(); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := hist.Record(history.Change{Op: history.OpReload}); err != nil {
+		t.Fatal(err)
+	}
+	api := &API{Check: chk, Logger: logger, History: hist}
+
+	check := func(asOf string) (int, domain.Result) {
+		req := httptest.NewRequest(http.MethodGet, "/check?q=b.com&as_of="+asOf, nil)
+		rr := httptest.NewRecorder()
+		api.CheckHandler(rr, req)
+		var res domain.Result
+		_ = json.Unmarshal(rr.Body.Bytes(), &res)
+		return rr.Code, res
+	}
+	code, res := check(v1.At.Add(time.Millisecond).Format(time.RFC3339Nano))
+	if code != http.StatusOK || res.Status != "neutral" || res.AsOf == nil || res.AsOf.Version != 1 || res.Generation != 1 {
+		t.Fatalf("expected b.com neutral as of version 1, got %d %+v %+v", code, res, res.AsOf)
+	}
+	if code, res = check(time.Now().Add(time.Hour).Format(time.RFC3339)); res.Status != "block" || res.AsOf.Version != 2 {
+		t.Fatalf("expected b.com blocked as of version 2, got %d %+v", code, res.AsOf)
+	}
+	if code, _ = check("1000"); code != http.StatusNotFound {
+		t.Fatalf("expected 404 before the first version, got %d", code)
+	}
+	if code, _ = check("yesterday"); code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for an invalid time, got %d", code)
+	}
+}
+
+func TestVersionCheckerSharesLoads(t *testing.T) {
+	dir := t.TempDir()
+	allowPath := filepath.Join(dir, "allowlist.conf")
+	blockPath := filepath.Join(dir, "blocklist.conf")
+	_ = os.WriteFile(allowPath, []byte("good.com\n"), 0o644)
+	_ = os.WriteFile(blockPath, []byte("a.com\n"), 0o644)
+	chk := domain.NewChecker(allowPath, blockPath)
+	if err := chk.Load(); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	logger := log.New(io.Discard, "", 0)
+	hist := history.New(logger, filepath.Join(dir, "history"), map[string]string{"allowlist": allowPath, "blocklist": blockPath})
+	if err := hist.Open(); err != nil {
+		t.Fatal(err)
+	}
+	var versions []history.Version
+	for i := range 8 {
+		_ = os.WriteFile(blockPath, []byte(fmt.Sprintf("a.com\nv%d.com\n", i)), 0o644)
+		v, _, err := hist.Record(history.Change{Op: history.OpReload})
+		if err != nil {
+			t.Fatal(err)
+		}
+		versions = append(versions, v)
+	}
+	api := &API{Check: chk, Logger: logger, History: hist}
+
+	// Concurrent requests for one version share a single load.
+	var wg sync.WaitGroup
+	got := make([]*domain.Checker, 16)
+	for i := range got {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			got[i], _ = api.versionChecker(versions[0])
+		}()
+	}
+	wg.Wait()
+	for _, c := range got {
+		if c == nil || c != got[0] {
+			t.Fatal("expected one shared checker")
+		}
+	}
+	// Loads of further versions are rate limited; cached ones are not.
+	busy := 0
+	for _, v := range versions[1:] {
+		if _, err := api.versionChecker(v); errors.Is(err, errAsOfBusy) {
+			busy++
+		} else if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if busy == 0 {
+		t.Fatal("expected loads beyond the burst to be rejected")
+	}
+	if c, err := api.versionChecker(versions[0]); err != nil || c != got[0] {
+		t.Fatalf("expected the cached checker, got %v", err)
+	}
+}
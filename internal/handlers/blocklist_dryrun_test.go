@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"disposable-email-domains/internal/domain"
+)
+
+func TestBlocklistDryRun(t *testing.T) {
+	t.Chdir(t.TempDir())
+	_ = os.WriteFile("allowlist.conf", []byte("good.com\n"), 0o644)
+	_ = os.WriteFile("blocklist.conf", []byte("# blocklist\nb.com\n"), 0o644)
+	chk := domain.NewChecker("allowlist.conf", "blocklist.conf")
+	if err := chk.Load(); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	api := &API{Check: chk, Logger: log.New(io.Discard, "", 0)}
+	body := `{"entries":["b.com","New.com","mail.good.com","new.com"]}`
+	req := httptest.NewRequest(http.MethodPost, "/blocklist?dry_run=true", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	rr := httptest.NewRecorder()
+	api.Blocklist(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d body=%s", rr.Code, rr.Body.String())
+	}
+	var resp struct {
+		DryRun      bool                   `json:"dry_run"`
+		WouldAppend int                    `json:"would_append"`
+		Skipped     int                    `json:"skipped_duplicates"`
+		Conflicts   []domain.AllowConflict `json:"allowlist_conflicts"`
+		Size        int                    `json:"resulting_size"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if !resp.DryRun || resp.WouldAppend != 2 || resp.Skipped != 2 || resp.Size != 3 {
+		t.Fatalf("unexpected dry run: %s", rr.Body.String())
+	}
+	if len(resp.Conflicts) != 1 || resp.Conflicts[0].Entry != "good.com" || resp.Conflicts[0].Blocked != "mail.good.com" || resp.Conflicts[0].Kind != domain.ConflictParent {
+		t.Fatalf("unexpected conflicts: %+v", resp.Conflicts)
+	}
+	if data, _ := os.ReadFile("blocklist.conf"); string(data) != "# blocklist\nb.com\n" {
+		t.Fatalf("dry run modified blocklist.conf:\n%s", data)
+	}
+	if chk.Check("new.com").Blocklisted {
+		t.Fatal("dry run patched the in-memory lists")
+	}
+}
@@ -6,8 +6,11 @@ import (
 	"errors"
 	"net/http"
 	"net/url"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 
 	"disposable-email-domains/internal/domain"
 )
@@ -24,20 +27,60 @@ func (a *API) CheckHandler(w http.ResponseWriter, r *http.Request) {
 			respondError(w, http.StatusBadRequest, "missing q")
 			return
 		}
-		res := a.Check.Check(q)
+		check, ok := a.checker(w, r)
+		if !ok {
+			return
+		}
+		res := check(q)
 		respondJSON(w, http.StatusOK, res)
 	default:
 		respondMethodNotAllowed(w, http.MethodGet)
 	}
 }
 
+// ExplainHandler handles GET /explain?q=: the check result together with a
+// step-by-step account of how its status was reached.
+func (a *API) ExplainHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		respondMethodNotAllowed(w, http.MethodGet)
+		return
+	}
+	if a.Check == nil {
+		respondError(w, http.StatusServiceUnavailable, "checker not initialized")
+		return
+	}
+	q := strings.TrimSpace(r.URL.Query().Get("q"))
+	if q == "" {
+		respondError(w, http.StatusBadRequest, "missing q")
+		return
+	}
+	check, ok := a.checker(w, r)
+	if !ok {
+		return
+	}
+	res := check(q)
+	matches := res.Matches
+	if matches == nil {
+		matches = []domain.Match{}
+	}
+	respondJSON(w, http.StatusOK, map[string]any{
+		"status":  res.Status,
+		"matches": matches,
+		"steps":   res.Explain(),
+		"result":  res,
+	})
+}
+
 // CheckEmailsBatch handles POST /check/emails
 // Accepts either:
 //   - Content-Type: application/json with body ["a@b.com", "c@d.com", ...]
 //     or {"items":["a@b.com", ...]} or {"emails":[...]} or {"values":[...]}
 //   - Content-Type: text/plain with newline-separated emails
 //
-// Returns JSON array of domain.Result objects in the same order as provided.
+// Returns JSON array of domain.Result objects in the same order as provided,
+// optionally narrowed by the filters described at parseBatchFilter. With
+// ?group=canonical the results are grouped by canonical_address instead (see
+// groupByCanonical).
 func (a *API) CheckEmailsBatch(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		respondMethodNotAllowed(w, http.MethodPost)
@@ -51,13 +94,26 @@ func (a *API) CheckEmailsBatch(w http.ResponseWriter, r *http.Request) {
 		respondError(w, http.StatusServiceUnavailable, "checker not initialized")
 		return
 	}
+	check, workers, ok := a.batchChecker(w, r)
+	if !ok {
+		return
+	}
 	items, err := parseBatchStrings(w, r)
 	if err != nil {
 		respondError(w, http.StatusBadRequest, err.Error())
 		return
 	}
+	group := r.URL.Query().Get("group")
+	if group != "" && group != "canonical" {
+		writeAPIError(w, http.StatusBadRequest, "invalid_group", "group must be canonical", nil)
+		return
+	}
 	if r.URL.Query().Get("format") == "ndjson" {
-		a.streamBatchNDJSON(w, r, items)
+		if group != "" {
+			writeAPIError(w, http.StatusBadRequest, "invalid_group", "group is not supported with format=ndjson", nil)
+			return
+		}
+		a.streamBatchNDJSON(w, r, check, workers, items)
 		return
 	}
 	max := 200000
@@ -68,13 +124,66 @@ func (a *API) CheckEmailsBatch(w http.ResponseWriter, r *http.Request) {
 		respondError(w, http.StatusRequestEntityTooLarge, "too many items (max "+strconv.Itoa(max)+")")
 		return
 	}
-	results := make([]domain.Result, len(items))
-	for i, s := range items {
-		results[i] = a.Check.Check(s)
+	filter := parseBatchFilter(r)
+	results := make([]domain.Result, 0, len(items))
+	checkEach(check, items, workers, func(res domain.Result) bool {
+		if filter.keep(res) {
+			results = append(results, res)
+		}
+		return true
+	})
+	if group != "" {
+		respondJSON(w, http.StatusOK, groupByCanonical(results))
+		return
 	}
 	respondJSON(w, http.StatusOK, results)
 }
 
+// canonicalGroup is one inbox identity of a grouped batch.
+type canonicalGroup struct {
+	CanonicalAddress string          `json:"canonical_address"`
+	Count            int             `json:"count"`
+	Inputs           []string        `json:"inputs"`
+	Results          []domain.Result `json:"results"`
+}
+
+// canonicalGroups is the response of POST /check/emails?group=canonical.
+type canonicalGroups struct {
+	Groups []canonicalGroup `json:"groups"`
+	// Duplicates counts the inputs that share their identity with an
+	// earlier input.
+	Duplicates int `json:"duplicates"`
+}
+
+// groupByCanonical groups results by canonical_address in order of first
+// appearance, largest groups first. Results without a canonical address
+// (invalid or domain-only inputs) form a group of their own, keyed by the
+// trimmed input.
+func groupByCanonical(results []domain.Result) canonicalGroups {
+	out := canonicalGroups{Groups: []canonicalGroup{}}
+	index := make(map[string]int)
+	for _, res := range results {
+		key := res.CanonicalAddress
+		if key == "" {
+			key = "\x00" + strings.TrimSpace(res.Input)
+		}
+		i, ok := index[key]
+		if !ok {
+			i = len(out.Groups)
+			index[key] = i
+			out.Groups = append(out.Groups, canonicalGroup{CanonicalAddress: res.CanonicalAddress})
+		} else {
+			out.Duplicates++
+		}
+		g := &out.Groups[i]
+		g.Count++
+		g.Inputs = append(g.Inputs, res.Input)
+		g.Results = append(g.Results, res)
+	}
+	sort.SliceStable(out.Groups, func(i, j int) bool { return out.Groups[i].Count > out.Groups[j].Count })
+	return out
+}
+
 // CheckDomainsBatch handles POST /check/domains with same formats as emails.
 func (a *API) CheckDomainsBatch(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
@@ -89,13 +198,17 @@ func (a *API) CheckDomainsBatch(w http.ResponseWriter, r *http.Request) {
 		respondError(w, http.StatusServiceUnavailable, "checker not initialized")
 		return
 	}
+	check, workers, ok := a.batchChecker(w, r)
+	if !ok {
+		return
+	}
 	items, err := parseBatchStrings(w, r)
 	if err != nil {
 		respondError(w, http.StatusBadRequest, err.Error())
 		return
 	}
 	if r.URL.Query().Get("format") == "ndjson" {
-		a.streamBatchNDJSON(w, r, items)
+		a.streamBatchNDJSON(w, r, check, workers, items)
 		return
 	}
 	max := 200000
@@ -106,15 +219,19 @@ func (a *API) CheckDomainsBatch(w http.ResponseWriter, r *http.Request) {
 		respondError(w, http.StatusRequestEntityTooLarge, "too many items (max "+strconv.Itoa(max)+")")
 		return
 	}
-	results := make([]domain.Result, len(items))
-	for i, s := range items {
-		results[i] = a.Check.Check(s)
-	}
+	filter := parseBatchFilter(r)
+	results := make([]domain.Result, 0, len(items))
+	checkEach(check, items, workers, func(res domain.Result) bool {
+		if filter.keep(res) {
+			results = append(results, res)
+		}
+		return true
+	})
 	respondJSON(w, http.StatusOK, results)
 }
 
 // streamBatchNDJSON writes one JSON object per line for each input, minimizing memory usage.
-func (a *API) streamBatchNDJSON(w http.ResponseWriter, r *http.Request, items []string) {
+func (a *API) streamBatchNDJSON(w http.ResponseWriter, r *http.Request, check checkFunc, workers int, items []string) {
 	max := 1_000_000
 	if a.cfg != nil && a.cfg.BatchStreamMaxItems > 0 {
 		max = a.cfg.BatchStreamMaxItems
@@ -126,17 +243,83 @@ func (a *API) streamBatchNDJSON(w http.ResponseWriter, r *http.Request, items []
 	w.Header().Set("Content-Type", "application/x-ndjson; charset=utf-8")
 	w.Header().Set("X-Content-Type-Options", "nosniff")
 	enc := json.NewEncoder(w)
+	filter := parseBatchFilter(r)
 	// Stream one by one; best-effort flush
 	flusher, _ := w.(http.Flusher)
-	for _, s := range items {
-		res := a.Check.Check(s)
+	checkEach(check, items, workers, func(res domain.Result) bool {
+		if !filter.keep(res) {
+			return true
+		}
 		if err := enc.Encode(res); err != nil {
 			// can't write JSON? abort
-			return
+			return false
 		}
 		if flusher != nil {
 			flusher.Flush()
 		}
+		return true
+	})
+}
+
+// batchMXWorkers bounds the items of a ?mx=true batch checked concurrently.
+const batchMXWorkers = 16
+
+// batchChecker is checker for the batch endpoints. A DNS round trip per item
+// would dominate large batches, so MX detection only runs with ?mx=true, and
+// then workers items are checked concurrently.
+func (a *API) batchChecker(w http.ResponseWriter, r *http.Request) (check checkFunc, workers int, ok bool) {
+	mx, err := queryBool(r.URL.Query(), "mx")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return nil, 0, false
+	}
+	workers = 1
+	if mx {
+		workers = batchMXWorkers
+	} else {
+		r = r.WithContext(domain.WithoutMX(r.Context()))
+	}
+	check, ok = a.checker(w, r)
+	return check, workers, ok
+}
+
+// checkEach checks items with up to workers goroutines and passes the
+// results to fn in input order until fn returns false.
+func checkEach(check checkFunc, items []string, workers int, fn func(domain.Result) bool) {
+	if workers <= 1 || len(items) <= 1 {
+		for _, s := range items {
+			if !fn(check(s)) {
+				return
+			}
+		}
+		return
+	}
+	// The first check sets the response headers; the rest only read them.
+	if !fn(check(items[0])) {
+		return
+	}
+	items = items[1:]
+	chunk := make([]domain.Result, workers*8)
+	for len(items) > 0 {
+		n := min(len(items), len(chunk))
+		var next atomic.Int64
+		var wg sync.WaitGroup
+		for range min(workers, n) {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for i := int(next.Add(1) - 1); i < n; i = int(next.Add(1) - 1) {
+					chunk[i] = check(items[i])
+				}
+			}()
+		}
+		wg.Wait()
+		for _, res := range chunk[:n] {
+			if !fn(res) {
+				return
+			}
+		}
+		items = items[n:]
 	}
 }
 
@@ -165,7 +348,11 @@ func (a *API) CheckEmailPath(w http.ResponseWriter, r *http.Request) {
 		respondError(w, http.StatusBadRequest, "invalid path encoding")
 		return
 	}
-	res := a.Check.Check(val)
+	check, ok := a.checker(w, r)
+	if !ok {
+		return
+	}
+	res := check(val)
 	respondJSON(w, http.StatusOK, res)
 }
 
@@ -194,7 +381,11 @@ func (a *API) CheckDomainPath(w http.ResponseWriter, r *http.Request) {
 		respondError(w, http.StatusBadRequest, "invalid path encoding")
 		return
 	}
-	res := a.Check.Check(val)
+	check, ok := a.checker(w, r)
+	if !ok {
+		return
+	}
+	res := check(val)
 	respondJSON(w, http.StatusOK, res)
 }
 
@@ -228,7 +419,11 @@ func (a *API) CheckEmailAliasPath(w http.ResponseWriter, r *http.Request) {
 		respondError(w, http.StatusBadRequest, "invalid path encoding")
 		return
 	}
-	res := a.Check.Check(val)
+	check, ok := a.checker(w, r)
+	if !ok {
+		return
+	}
+	res := check(val)
 	respondJSON(w, http.StatusOK, res)
 }
 
@@ -262,7 +457,11 @@ func (a *API) CheckDomainAliasPath(w http.ResponseWriter, r *http.Request) {
 		respondError(w, http.StatusBadRequest, "invalid path encoding")
 		return
 	}
-	res := a.Check.Check(val)
+	check, ok := a.checker(w, r)
+	if !ok {
+		return
+	}
+	res := check(val)
 	respondJSON(w, http.StatusOK, res)
 }
 
@@ -326,6 +525,68 @@ func parseBatchStrings(w http.ResponseWriter, r *http.Request) ([]string, error)
 	return sanitizeStrings(lines), nil
 }
 
+// batchFilter narrows batch results. The zero value keeps everything.
+type batchFilter struct {
+	reasons  map[string]struct{}
+	valid    *bool
+	role     *bool
+	minScore int
+}
+
+// parseBatchFilter reads the batch filter query parameters:
+//   - reason=<code>[,<code>...] keeps results reporting any of the given
+//     format_errors reason codes (e.g. local_part_too_long)
+//   - valid=true|false keeps results by valid_format
+//   - role=true|false keeps results by role_account
+//   - min_score=<0-100> keeps results scoring at least the given value
+func parseBatchFilter(r *http.Request) batchFilter {
+	var f batchFilter
+	q := r.URL.Query()
+	for _, v := range q["reason"] {
+		for _, code := range strings.Split(v, ",") {
+			code = strings.TrimSpace(code)
+			if code == "" {
+				continue
+			}
+			if f.reasons == nil {
+				f.reasons = make(map[string]struct{})
+			}
+			f.reasons[code] = struct{}{}
+		}
+	}
+	if v, err := strconv.ParseBool(q.Get("valid")); err == nil {
+		f.valid = &v
+	}
+	if v, err := strconv.ParseBool(q.Get("role")); err == nil {
+		f.role = &v
+	}
+	if v, err := strconv.Atoi(q.Get("min_score")); err == nil && v > 0 {
+		f.minScore = v
+	}
+	return f
+}
+
+func (f batchFilter) keep(res domain.Result) bool {
+	if f.valid != nil && res.ValidFormat != *f.valid {
+		return false
+	}
+	if f.role != nil && res.RoleAccount != *f.role {
+		return false
+	}
+	if res.Score < f.minScore {
+		return false
+	}
+	if f.reasons != nil {
+		for _, code := range res.FormatErrors {
+			if _, ok := f.reasons[code]; ok {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
 func sanitizeStrings(in []string) []string {
 	out := make([]string, 0, len(in))
 	for _, s := range in {
@@ -0,0 +1,122 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+
+	"disposable-email-domains/internal/domain"
+)
+
+func TestBatchReasonFilter(t *testing.T) {
+	_ = os.WriteFile("allowlist.conf", []byte("a.com\n"), 0o644)
+	_ = os.WriteFile("blocklist.conf", []byte("b.com\n"), 0o644)
+	chk := domain.NewChecker("allowlist.conf", "blocklist.conf")
+	if err := chk.Load(); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	api := &API{Check: chk, Logger: log.New(os.Stdout, "test", 0)}
+	body := `["ok@a.com","a@@b.com","a b@b.com","x@exa_mple.com"]`
+	req := httptest.NewRequest(http.MethodPost, "/check/emails?reason=multiple_at_signs,invalid_domain_label", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	rr := httptest.NewRecorder()
+	api.CheckEmailsBatch(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d body=%s", rr.Code, rr.Body.String())
+	}
+	var results []domain.Result
+	if err := json.Unmarshal(rr.Body.Bytes(), &results); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(results) != 2 || results[0].Input != "a@@b.com" || results[1].Input != "x@exa_mple.com" {
+		t.Fatalf("unexpected filtered results: %+v", results)
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/check/emails?valid=true", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	rr = httptest.NewRecorder()
+	api.CheckEmailsBatch(rr, req)
+	results = nil
+	if err := json.Unmarshal(rr.Body.Bytes(), &results); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(results) != 1 || results[0].Input != "ok@a.com" {
+		t.Fatalf("unexpected valid-only results: %+v", results)
+	}
+}
+
+func TestBatchGroupCanonical(t *testing.T) {
+	_ = os.WriteFile("allowlist.conf", []byte("a.com\n"), 0o644)
+	_ = os.WriteFile("blocklist.conf", []byte("b.com\n"), 0o644)
+	chk := domain.NewChecker("allowlist.conf", "blocklist.conf")
+	if err := chk.Load(); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	api := &API{Check: chk, Logger: log.New(os.Stdout, "test", 0)}
+	body := `["jane@a.com","J.A.N.E+promo@googlemail.com","a@@b.com","jane@gmail.com","a@@b.com"]`
+	req := httptest.NewRequest(http.MethodPost, "/check/emails?group=canonical", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	rr := httptest.NewRecorder()
+	api.CheckEmailsBatch(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d body=%s", rr.Code, rr.Body.String())
+	}
+	var got canonicalGroups
+	if err := json.Unmarshal(rr.Body.Bytes(), &got); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if got.Duplicates != 2 || len(got.Groups) != 3 {
+		t.Fatalf("expected 3 groups and 2 duplicates, got %+v", got)
+	}
+	if g := got.Groups[0]; g.CanonicalAddress != "jane@gmail.com" || g.Count != 2 || g.Inputs[0] != "J.A.N.E+promo@googlemail.com" {
+		t.Fatalf("unexpected largest group %+v", g)
+	}
+	if g := got.Groups[1]; g.CanonicalAddress != "" || g.Count != 2 {
+		t.Fatalf("expected identical invalid inputs grouped, got %+v", g)
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/check/emails?group=canonical&format=ndjson", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	rr = httptest.NewRecorder()
+	api.CheckEmailsBatch(rr, req)
+	if rr.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for grouped ndjson, got %d", rr.Code)
+	}
+}
+
+func TestCheckEachKeepsOrder(t *testing.T) {
+	items := make([]string, 1000)
+	for i := range items {
+		items[i] = strconv.Itoa(i)
+	}
+	check := func(s string) domain.Result { return domain.Result{Input: s} }
+	var got []string
+	checkEach(check, items, batchMXWorkers, func(res domain.Result) bool {
+		got = append(got, res.Input)
+		return len(got) < 700
+	})
+	if len(got) != 700 || !slices.Equal(got, items[:700]) {
+		t.Fatalf("expected the first 700 results in input order, got %d", len(got))
+	}
+
+	_ = os.WriteFile("allowlist.conf", []byte("a.com\n"), 0o644)
+	_ = os.WriteFile("blocklist.conf", []byte("b.com\n"), 0o644)
+	chk := domain.NewChecker("allowlist.conf", "blocklist.conf")
+	if err := chk.Load(); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	api := &API{Check: chk, Logger: log.New(os.Stdout, "test", 0)}
+	req := httptest.NewRequest(http.MethodPost, "/check/domains?mx=maybe", strings.NewReader(`["b.com"]`))
+	req.Header.Set("Content-Type", "application/json")
+	rr := httptest.NewRecorder()
+	api.CheckDomainsBatch(rr, req)
+	if rr.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for an invalid mx flag, got %d", rr.Code)
+	}
+}
@@ -0,0 +1,154 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"disposable-email-domains/internal/history"
+)
+
+// recordChange journals a list mutation. Callers hold blMu so the snapshot
+// matches the change. Tenant overlays are deliberately not journaled.
+func (a *API) recordChange(ch history.Change) {
+	if a.History == nil {
+		return
+	}
+	if _, _, err := a.History.Record(ch); err != nil {
+		a.Logger.Printf("history: record %s: %v", ch.Op, err)
+	}
+}
+
+// ReloadLists reloads the lists from disk and journals the files changed
+// outside the API. The watcher reloads through it: holding blMu like the
+// API's writers keeps a reload from interleaving with an append and patch.
+func (a *API) ReloadLists(strict bool) error {
+	return a.reloadLists(strict, "")
+}
+
+func (a *API) reloadLists(strict bool, actor string) error {
+	a.blMu.Lock()
+	defer a.blMu.Unlock()
+	if err := a.Check.Reload(strict); err != nil {
+		return err
+	}
+	a.recordChange(history.Change{Op: history.OpReload, Actor: actor})
+	return nil
+}
+
+// ListsReloaded refreshes the list status after the watcher reloaded the
+// lists.
+func (a *API) ListsReloaded() {
+	a.RefreshListStatus()
+}
+
+// HistoryHandler serves GET /admin/history: the journal of list versions, newest
+// first, paged with ?offset=&limit=.
+func (a *API) HistoryHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		respondMethodNotAllowed(w, http.MethodGet)
+		return
+	}
+	if a.History == nil {
+		respondError(w, http.StatusServiceUnavailable, "history disabled")
+		return
+	}
+	versions := a.History.Versions()
+	total := len(versions)
+	resp := map[string]any{"count": total}
+	if total > 0 {
+		resp["latest"] = versions[total-1].ID
+	}
+	slices.Reverse(versions)
+	offset, limit := pageParams(r.URL.Query())
+	versions = versions[min(offset, total):]
+	if limit > 0 {
+		versions = versions[:min(limit, len(versions))]
+	}
+	resp["versions"], resp["offset"], resp["limit"] = versions, offset, limit
+	respondJSON(w, http.StatusOK, resp)
+}
+
+// HistoryDiffHandler serves GET /admin/history/diff?from=&to=: the entries added to
+// and removed from each list between two versions. to defaults to the
+// latest version; ?limit= caps the entries listed per list and direction.
+func (a *API) HistoryDiffHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		respondMethodNotAllowed(w, http.MethodGet)
+		return
+	}
+	if a.History == nil {
+		respondError(w, http.StatusServiceUnavailable, "history disabled")
+		return
+	}
+	q := r.URL.Query()
+	from, err := strconv.Atoi(strings.TrimSpace(q.Get("from")))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "from must be a version number")
+		return
+	}
+	to := 0
+	if s := strings.TrimSpace(q.Get("to")); s != "" {
+		if to, err = strconv.Atoi(s); err != nil {
+			respondError(w, http.StatusBadRequest, "to must be a version number")
+			return
+		}
+	} else if vs := a.History.Versions(); len(vs) > 0 {
+		to = vs[len(vs)-1].ID
+	}
+	_, limit := pageParams(q)
+	lists, err := a.History.Diff(from, to, limit)
+	if err != nil {
+		respondHistoryError(w, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, map[string]any{"from": from, "to": to, "lists": lists, "limit": limit})
+}
+
+// HistoryRollbackHandler serves POST /admin/history/rollback {"version":N}: it
+// restores the list files of version N, reloads the lists and records the
+// restore as a new version.
+func (a *API) HistoryRollbackHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		respondMethodNotAllowed(w, http.MethodPost)
+		return
+	}
+	if a.History == nil {
+		respondError(w, http.StatusServiceUnavailable, "history disabled")
+		return
+	}
+	var payload struct {
+		Version int `json:"version"`
+	}
+	if err := decodeJSON(w, r, &payload, 1<<10); err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	a.blMu.Lock()
+	v, changed, err := a.History.Rollback(payload.Version, adminActor(r))
+	a.blMu.Unlock()
+	if err != nil {
+		respondHistoryError(w, err)
+		return
+	}
+	resp := map[string]any{"rolled_back_to": payload.Version, "changed": changed}
+	if changed {
+		a.RefreshListStatus()
+		a.Logger.Printf("history: rolled back to v%d as v%d", payload.Version, v.ID)
+		resp["version"] = v
+	}
+	respondJSON(w, http.StatusOK, resp)
+}
+
+func respondHistoryError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, history.ErrUnknownVersion):
+		respondError(w, http.StatusNotFound, err.Error())
+	case errors.Is(err, history.ErrNoSnapshot):
+		respondError(w, http.StatusGone, err.Error())
+	default:
+		respondError(w, http.StatusInternalServerError, err.Error())
+	}
+}
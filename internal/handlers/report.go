@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"fmt"
 	"io"
 	"net/http"
 	"net/url"
@@ -9,6 +10,7 @@ import (
 	"time"
 
 	"disposable-email-domains/internal/domain"
+	"disposable-email-domains/internal/history"
 )
 
 // HTML report: /report (validate findings)
@@ -39,7 +41,11 @@ func (a *API) ReportValidateHTML(w http.ResponseWriter, r *http.Request) {
 	} else {
 		b.WriteString("no errors")
 	}
-	b.WriteString(`</span></h2><div class="content"><div>Checked at: ` + time.Now().UTC().Format(time.RFC3339) + `</div></div></div>`)
+	b.WriteString(`</span></h2><div class="content"><div>Checked at: ` + rep.CheckedAt.Format(time.RFC3339) + ` (` + strconv.FormatFloat(rep.AgeSeconds, 'f', 0, 64) + `s ago, list generation ` + strconv.FormatUint(rep.Generation, 10))
+	if rep.IncrementalUpdates > 0 {
+		b.WriteString(`, ` + strconv.Itoa(rep.IncrementalUpdates) + ` incremental updates`)
+	}
+	b.WriteString(`)</div></div></div>`)
 
 	renderList := func(title string, items []string) {
 		b.WriteString(`<div class="card"><h2>` + title + ` <span class="pill">` + strconv.Itoa(len(items)) + `</span></h2><div class="content">`)
@@ -62,6 +68,9 @@ func (a *API) ReportValidateHTML(w http.ResponseWriter, r *http.Request) {
 	renderList("Duplicates in allowlist", rep.DuplicatesAllow)
 	renderList("Duplicates in blocklist", rep.DuplicatesBlock)
 	renderList("Intersection between allowlist and blocklist", rep.Intersection)
+	renderList("Non-lowercase in rolelist", rep.NonLowercaseRole)
+	renderList("Duplicates in rolelist", rep.DuplicatesRole)
+	renderList("Invalid rolelist entries", rep.InvalidRole)
 
 	if rep.UnsortedAllowHint != "" || rep.UnsortedBlockHint != "" {
 		b.WriteString(`<div class="card"><h2>Sorting hints</h2><div class="content">`)
@@ -104,6 +113,49 @@ func (a *API) GetBlocklistFile(w http.ResponseWriter, r *http.Request) {
 	http.ServeFile(w, r, "blocklist.conf")
 }
 
+func (a *API) GetRolelistFile(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		respondMethodNotAllowed(w, http.MethodGet)
+		return
+	}
+	path := a.Check.RoleListPath()
+	if r.URL.Path != "/rolelist.conf" || path == "" {
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	http.ServeFile(w, r, path)
+}
+
+// GetBlocklistBloom serves the Bloom filter of the current blocklist snapshot
+// so edge services can drop definite misses without calling the API. The ETag
+// is the list generation.
+func (a *API) GetBlocklistBloom(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		respondMethodNotAllowed(w, http.MethodGet)
+		return
+	}
+	if r.URL.Path != "/blocklist.bloom" {
+		http.NotFound(w, r)
+		return
+	}
+	buf, gen := a.Check.BlocklistBloom()
+	if buf == nil {
+		writeAPIError(w, http.StatusServiceUnavailable, "not_ready", "lists not loaded", nil)
+		return
+	}
+	etag := `"` + strconv.FormatUint(gen, 10) + `"`
+	w.Header().Set("ETag", etag)
+	w.Header().Set(generationHeader, strconv.FormatUint(gen, 10))
+	if r.Header.Get("If-None-Match") == etag {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Header().Set("Content-Length", strconv.Itoa(len(buf)))
+	_, _ = w.Write(buf)
+}
+
 func (a *API) GetPSLFile(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		respondMethodNotAllowed(w, http.MethodGet)
@@ -132,6 +184,47 @@ func (a *API) ValidateHandler(w http.ResponseWriter, r *http.Request) {
 	respondJSON(w, http.StatusOK, rep)
 }
 
+// ValidateFixHandler resolves the mechanical validation findings in the list
+// files: POST /validate/fix rewrites and reloads them, ?dry_run=true only
+// returns the changes and a diff preview. Blocklist entries are reduced to
+// their registrable domain only with ?reduce=true.
+func (a *API) ValidateFixHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		respondMethodNotAllowed(w, http.MethodPost)
+		return
+	}
+	if a.Check == nil {
+		respondError(w, http.StatusServiceUnavailable, "checker not initialized")
+		return
+	}
+	q := r.URL.Query()
+	dryRun, err := queryBool(q, "dry_run")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	reduce, err := queryBool(q, "reduce")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	// Appends to blocklist.conf must not interleave with the rewrite.
+	a.blMu.Lock()
+	rep, err := a.Check.FixLists(!dryRun, reduce)
+	if err == nil && rep.Applied {
+		a.recordChange(history.Change{Op: history.OpFix, Actor: adminActor(r)})
+	}
+	a.blMu.Unlock()
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "fix lists: "+err.Error())
+		return
+	}
+	if rep.Applied {
+		a.RefreshListStatus()
+	}
+	respondJSON(w, http.StatusOK, rep)
+}
+
 func htmlEscape(s string) string {
 	s = strings.ReplaceAll(s, "&", "&amp;")
 	s = strings.ReplaceAll(s, "<", "&lt;")
@@ -164,7 +257,11 @@ func (a *API) ReportCheckEmailHTML(w http.ResponseWriter, r *http.Request) {
 		respondError(w, http.StatusBadRequest, "invalid path encoding")
 		return
 	}
-	res := a.Check.Check(val)
+	check, ok := a.checker(w, r)
+	if !ok {
+		return
+	}
+	res := check(val)
 	writeCheckHTML(w, r, res)
 }
 
@@ -193,7 +290,11 @@ func (a *API) ReportCheckDomainHTML(w http.ResponseWriter, r *http.Request) {
 		respondError(w, http.StatusBadRequest, "invalid path encoding")
 		return
 	}
-	res := a.Check.Check(val)
+	check, ok := a.checker(w, r)
+	if !ok {
+		return
+	}
+	res := check(val)
 	writeCheckHTML(w, r, res)
 }
 
@@ -216,7 +317,11 @@ func (a *API) ReportCheckQueryHTML(w http.ResponseWriter, r *http.Request) {
 		respondError(w, http.StatusBadRequest, "missing input")
 		return
 	}
-	res := a.Check.Check(q)
+	check, ok := a.checker(w, r)
+	if !ok {
+		return
+	}
+	res := check(q)
 	writeCheckHTML(w, r, res)
 }
 
@@ -231,6 +336,15 @@ func writeCheckHTML(w http.ResponseWriter, r *http.Request, res domain.Result) {
 	b.WriteString(`<div class="key">input</div><div class="val">` + htmlEscape(res.Input) + `</div>`)
 	b.WriteString(`<div class="key">type</div><div class="val">` + res.Type + `</div>`)
 	b.WriteString(`<div class="key">valid_format</div><div class="val">` + boolStr(res.ValidFormat) + `</div>`)
+	if len(res.FormatErrors) > 0 {
+		b.WriteString(`<div class="key">format_errors</div><div class="val">` + htmlEscape(strings.Join(res.FormatErrors, ", ")) + `</div>`)
+	}
+	if res.DomainLiteral {
+		b.WriteString(`<div class="key">domain_literal</div><div class="val">true</div>`)
+	}
+	if res.SMTPUTF8 {
+		b.WriteString(`<div class="key">smtputf8</div><div class="val">true</div>`)
+	}
 	if res.LocalPart != "" {
 		b.WriteString(`<div class="key">local_part</div><div class="val">` + htmlEscape(res.LocalPart) + `</div>`)
 	}
@@ -239,10 +353,22 @@ func writeCheckHTML(w http.ResponseWriter, r *http.Request, res domain.Result) {
 	b.WriteString(`<div class="card"><h2>Domain</h2><div class="content kv">`)
 	b.WriteString(`<div class="key">domain</div><div class="val">` + htmlEscape(res.Domain) + `</div>`)
 	b.WriteString(`<div class="key">normalized_domain</div><div class="val">` + htmlEscape(res.NormalizedDomain) + `</div>`)
+	if res.UnicodeDomain != res.ASCIIDomain {
+		b.WriteString(`<div class="key">unicode_domain</div><div class="val">` + htmlEscape(res.UnicodeDomain) + `</div>`)
+	}
+	if res.IDNError != "" {
+		b.WriteString(`<div class="key">idn_error</div><div class="val">` + htmlEscape(res.IDNError) + `</div>`)
+	}
 	b.WriteString(`<div class="key">public_suffix</div><div class="val">` + htmlEscape(res.PublicSuffix) + `</div>`)
 	b.WriteString(`<div class="key">registrable_domain</div><div class="val">` + htmlEscape(res.RegistrableDomain) + `</div>`)
 	b.WriteString(`<div class="key">is_public_suffix_only</div><div class="val">` + boolStr(res.IsPublicSuffixOnly) + `</div>`)
 	b.WriteString(`<div class="key">is_subdomain</div><div class="val">` + boolStr(res.IsSubdomain) + `</div>`)
+	b.WriteString(`<div class="key">mixed_script</div><div class="val">` + boolStr(res.MixedScript) + `</div>`)
+	b.WriteString(`<div class="key">confusable</div><div class="val">` + boolStr(res.Confusable))
+	if res.ConfusableWith != "" {
+		b.WriteString(` (imitates ` + htmlEscape(res.ConfusableWith) + `)`)
+	}
+	b.WriteString(`</div>`)
 	b.WriteString(`</div></div>`)
 
 	b.WriteString(`<div class="card"><h2>Decision</h2><div class="content kv">`)
@@ -251,9 +377,57 @@ func writeCheckHTML(w http.ResponseWriter, r *http.Request, res domain.Result) {
 	b.WriteString(`<div class="key">status</div><div class="val">` + res.Status + `</div>`)
 	b.WriteString(`</div></div>`)
 
+	b.WriteString(`<div class="card"><h2>Score</h2><div class="content kv">`)
+	b.WriteString(`<div class="key">score</div><div class="val">` + strconv.Itoa(res.Score) + ` / 100 (` + res.RiskLevel + `)</div>`)
+	for _, sig := range res.ScoreSignals {
+		val := fmt.Sprintf("%+d (weight %g × %.2f)", sig.Contribution, sig.Weight, sig.Strength)
+		if sig.Detail != "" {
+			val += " — " + sig.Detail
+		}
+		b.WriteString(`<div class="key">` + htmlEscape(sig.Signal) + `</div><div class="val">` + htmlEscape(val) + `</div>`)
+	}
+	b.WriteString(`</div></div>`)
+
+	if res.MX != nil {
+		b.WriteString(`<div class="card"><h2>Mail exchangers</h2><div class="content kv">`)
+		b.WriteString(`<div class="key">hosts</div><div class="val">` + htmlEscape(strings.Join(res.MX.Hosts, ", ")) + `</div>`)
+		if len(res.MX.IPs) > 0 {
+			b.WriteString(`<div class="key">ips</div><div class="val">` + htmlEscape(strings.Join(res.MX.IPs, ", ")) + `</div>`)
+		}
+		if res.MX.ImplicitMX {
+			b.WriteString(`<div class="key">implicit_mx</div><div class="val">true</div>`)
+		}
+		if res.MX.NullMX {
+			b.WriteString(`<div class="key">null_mx</div><div class="val">true</div>`)
+		}
+		b.WriteString(`<div class="key">disposable</div><div class="val">` + boolStr(res.MX.Disposable))
+		if res.MX.MatchedEntry != "" {
+			b.WriteString(` (` + htmlEscape(res.MX.MatchedHost) + ` matches ` + htmlEscape(res.MX.MatchedEntry) + `)`)
+		}
+		b.WriteString(`</div>`)
+		if res.MX.Error != "" {
+			b.WriteString(`<div class="key">error</div><div class="val">` + htmlEscape(res.MX.Error) + `</div>`)
+		}
+		b.WriteString(`</div></div>`)
+	}
+
+	b.WriteString(`<div class="card"><h2>Explanation</h2><div class="content"><ol style="margin:0;padding-left:20px">`)
+	for _, step := range res.Explain() {
+		b.WriteString(`<li>` + htmlEscape(step) + `</li>`)
+	}
+	b.WriteString(`</ol></div></div>`)
+
 	b.WriteString(`<div class="card"><h2>Timestamps</h2><div class="content kv">`)
 	b.WriteString(`<div class="key">checked_at</div><div class="val">` + res.CheckedAt.Format(time.RFC3339) + `</div>`)
 	b.WriteString(`<div class="key">lists_updated_at</div><div class="val">` + res.UpdatedAt.Format(time.RFC3339) + `</div>`)
+	psl := res.PSL.Source
+	if res.PSL.Version != "" {
+		psl += " " + res.PSL.Version
+	}
+	if len(res.PSL.SHA256) >= 12 {
+		psl += " (sha256 " + res.PSL.SHA256[:12] + ")"
+	}
+	b.WriteString(`<div class="key">psl</div><div class="val">` + htmlEscape(psl) + `</div>`)
 	b.WriteString(`</div></div>`)
 
 	b.WriteString(`</div></body></html>`)
@@ -0,0 +1,198 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"disposable-email-domains/internal/domain"
+	"disposable-email-domains/internal/history"
+	"disposable-email-domains/internal/metrics"
+	"disposable-email-domains/internal/subscriptions"
+)
+
+// SyncSubscription applies a refreshed upstream list to the blocklist; it is
+// the subscriptions.Subscriber OnUpdate hook. New registrable domains are
+// appended with the source URL as provenance, skipping tombstoned entries
+// like any import. With pruning, entries the source contributed but no longer
+// lists are removed unless another source still lists them. Pruned entries
+// are not tombstoned, so the source may add them back. Once entries were
+// appended the update counts as applied: a failed prune is reported in
+// Result.PruneError instead.
+func (a *API) SyncSubscription(u subscriptions.Update) (subscriptions.Result, error) {
+	var res subscriptions.Result
+	if a.Check == nil {
+		return res, errors.New("checker not initialized")
+	}
+	listed, rejected, err := a.parseSubscription(u.Data)
+	if err != nil {
+		return res, err
+	}
+	if len(listed) == 0 {
+		return res, errors.New("no valid entries")
+	}
+	res.Entries, res.Rejected = len(listed), rejected
+	actor := "subscription:" + u.Source.Name
+
+	a.blMu.Lock()
+	defer a.blMu.Unlock()
+	var diff history.ListDiff
+	existing := make(map[string]struct{})
+	if _, err := buildExistingSet("blocklist.conf", existing); err != nil {
+		return res, err
+	}
+	var add []string
+	tombstonedSkips := 0
+	for _, d := range listed {
+		if _, ok := existing[d]; ok {
+			continue
+		}
+		if _, ok := a.Check.BlockTombstone(d); ok {
+			tombstonedSkips++
+			continue
+		}
+		existing[d] = struct{}{}
+		add = append(add, d)
+	}
+	if len(add) > 0 {
+		if err := a.appendListFile("blocklist.conf", add); err != nil {
+			return res, err
+		}
+		if err := a.Check.PatchBlockFrom(add, domain.Provenance{AddedAt: time.Now().UTC(), Source: u.Source.URL, Actor: actor}); err != nil {
+			a.Logger.Printf("subscription %s: record provenance: %v", u.Source.Name, err)
+		}
+		res.Added, diff.Added = len(add), add
+		metrics.BlocklistAppendsTotal.Add(float64(len(add)))
+	}
+	if tombstonedSkips > 0 {
+		metrics.BlocklistTombstonedSkippedTotal.Add(float64(tombstonedSkips))
+	}
+
+	if u.Source.Prune {
+		keep := make(map[string]struct{}, len(listed))
+		for _, d := range listed {
+			keep[d] = struct{}{}
+		}
+		for _, data := range u.Others {
+			others, _, _ := a.parseSubscription(data)
+			for _, d := range others {
+				keep[d] = struct{}{}
+			}
+		}
+		var drop []string
+		for _, e := range a.Check.BlockEntriesFrom(u.Source.URL) {
+			if _, ok := keep[e]; !ok {
+				drop = append(drop, e)
+			}
+		}
+		if len(drop) > 0 {
+			removed, _, err := a.Check.RemoveBlock(drop, nil)
+			if err != nil {
+				res.PruneError = err.Error()
+			}
+			res.Removed, diff.Removed = len(removed), removed
+			metrics.BlocklistRemovalsTotal.Add(float64(len(removed)))
+		}
+	}
+
+	if res.Added > 0 || res.Removed > 0 {
+		a.recordChange(history.Change{Op: history.OpSync, Actor: actor, Sources: []string{u.Source.URL}, Lists: map[string]history.ListDiff{"blocklist": diff}})
+		a.RefreshListStatus()
+	}
+	return res, nil
+}
+
+// parseSubscription returns the distinct registrable domains of a list body
+// in upstream order and the number of entries that are not registrable.
+func (a *API) parseSubscription(data []byte) (listed []string, rejected int, err error) {
+	seen := make(map[string]struct{})
+	tooMany := false
+	err = parseFetchedList(data, func(v string) bool {
+		v = strings.ToLower(strings.TrimSpace(v))
+		if v == "" || strings.HasPrefix(v, "#") {
+			return true
+		}
+		d, reason := a.registrableEntry(v)
+		if reason != "" {
+			rejected++
+			return true
+		}
+		if _, ok := seen[d]; ok {
+			return true
+		}
+		if len(listed) >= maxFetchedEntries {
+			tooMany = true
+			return false
+		}
+		seen[d] = struct{}{}
+		listed = append(listed, d)
+		return true
+	})
+	if err == nil && tooMany {
+		err = fmt.Errorf("more than %d entries", maxFetchedEntries)
+	}
+	return listed, rejected, err
+}
+
+// SubscriptionsHandler serves GET /admin/subscriptions: the configured
+// upstream lists with their refresh state and last sync result.
+func (a *API) SubscriptionsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		respondMethodNotAllowed(w, http.MethodGet)
+		return
+	}
+	if a.Subscriptions == nil {
+		respondJSON(w, http.StatusOK, map[string]any{"sources": []subscriptions.Status{}, "count": 0})
+		return
+	}
+	st := a.Subscriptions.Statuses()
+	respondJSON(w, http.StatusOK, map[string]any{"sources": st, "count": len(st), "interval": a.Subscriptions.Interval.String()})
+}
+
+// SubscriptionsRefreshHandler serves POST /admin/subscriptions/refresh?name=:
+// an immediate refresh of one source, or of all sources without name. With
+// force=true a body rejected for shrinking is applied. Failed refreshes answer
+// 502 with the source state.
+func (a *API) SubscriptionsRefreshHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		respondMethodNotAllowed(w, http.MethodPost)
+		return
+	}
+	if a.Subscriptions == nil {
+		respondError(w, http.StatusServiceUnavailable, "no subscriptions configured")
+		return
+	}
+	force, err := queryBool(r.URL.Query(), "force")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	var names []string
+	if name := strings.TrimSpace(r.URL.Query().Get("name")); name != "" {
+		names = []string{name}
+	} else {
+		for _, st := range a.Subscriptions.Statuses() {
+			names = append(names, st.Name)
+		}
+	}
+	refreshed := make([]subscriptions.Status, 0, len(names))
+	failed := 0
+	for _, name := range names {
+		st, err := a.Subscriptions.RefreshNow(name, force)
+		if errors.Is(err, subscriptions.ErrUnknownSource) {
+			respondError(w, http.StatusNotFound, err.Error())
+			return
+		}
+		if err != nil {
+			failed++
+		}
+		refreshed = append(refreshed, st)
+	}
+	status := http.StatusOK
+	if failed > 0 {
+		status = http.StatusBadGateway
+	}
+	respondJSON(w, status, map[string]any{"sources": refreshed, "failed": failed})
+}
@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"io"
+	"log"
+	"os"
+	"slices"
+	"testing"
+
+	"disposable-email-domains/internal/domain"
+	"disposable-email-domains/internal/subscriptions"
+)
+
+func TestSyncSubscription(t *testing.T) {
+	t.Chdir(t.TempDir())
+	_ = os.WriteFile("allowlist.conf", []byte("good.com\n"), 0o644)
+	_ = os.WriteFile("blocklist.conf", []byte("manual.com\n"), 0o644)
+	chk := domain.NewChecker("allowlist.conf", "blocklist.conf")
+	if err := chk.Load(); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if _, _, err := chk.RemoveBlock([]string{"manual.com"}, &domain.Tombstone{Reason: "false positive"}); err != nil {
+		t.Fatal(err)
+	}
+	api := &API{Check: chk, Logger: log.New(io.Discard, "", 0)}
+	src := subscriptions.Source{Name: "upstream", URL: "https://lists.example/upstream.txt", Prune: true}
+
+	res, err := api.SyncSubscription(subscriptions.Update{Source: src, Data: []byte("# list\na.com\nmail.b.com\nmanual.com\ncom\nc.com\n")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res != (subscriptions.Result{Entries: 4, Rejected: 1, Added: 3}) {
+		t.Fatalf("unexpected first sync: %+v", res)
+	}
+	if got := chk.BlockEntriesFrom(src.URL); !slices.Equal(got, []string{"a.com", "b.com", "c.com"}) {
+		t.Fatalf("unexpected contribution: %v", got)
+	}
+
+	// a.com and b.com are dropped upstream; another source still lists b.com.
+	others := map[string][]byte{"other": []byte(`["b.com"]`)}
+	res, err = api.SyncSubscription(subscriptions.Update{Source: src, Data: []byte("c.com\nd.com\n"), Others: others})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Added != 1 || res.Removed != 1 {
+		t.Fatalf("unexpected second sync: %+v", res)
+	}
+	if chk.Check("a.com").Blocklisted || !chk.Check("b.com").Blocklisted || !chk.Check("d.com").Blocklisted {
+		t.Fatal("expected a.com pruned, b.com kept and d.com added")
+	}
+	if _, ok := chk.BlockTombstone("a.com"); ok {
+		t.Fatal("pruned entries must not be tombstoned")
+	}
+	if _, err := api.SyncSubscription(subscriptions.Update{Source: src, Data: []byte(`{"a.com":true}`)}); err == nil {
+		t.Fatal("expected JSON objects to be rejected")
+	}
+}
@@ -3,6 +3,7 @@ package handlers
 import (
 	"net/http"
 	"os"
+	"strconv"
 	"time"
 )
 
@@ -47,6 +48,22 @@ func (a *API) Status(w http.ResponseWriter, r *http.Request) {
 	st := a.status
 	a.statusMu.RUnlock()
 	st.Ready = ready
+	if a.Check != nil {
+		st.ListGeneration = a.Check.Generation()
+		w.Header().Set(generationHeader, strconv.FormatUint(st.ListGeneration, 10))
+	}
+	if a.ListWatch != nil {
+		ws := a.ListWatch.Status()
+		st.ListWatch = &ws
+	}
+	if a.Subscriptions != nil {
+		// /status is public; the full URLs are under /admin/subscriptions.
+		subs := a.Subscriptions.Statuses()
+		for i := range subs {
+			subs[i] = subs[i].Redacted()
+		}
+		st.Subscriptions = subs
+	}
 	respondJSON(w, http.StatusOK, st)
 }
 
@@ -65,5 +82,13 @@ func (a *API) Ready(w http.ResponseWriter, r *http.Request) {
 		respondJSON(w, http.StatusServiceUnavailable, map[string]any{"ready": false, "error": "psl missing"})
 		return
 	}
-	respondJSON(w, http.StatusOK, map[string]any{"ready": true, "updated_at": a.Check.Validate().CheckedAt.Format(time.RFC3339)})
+	// Validate is cached per list generation, so probes do not re-scan the lists.
+	rep := a.Check.Validate()
+	respondJSON(w, http.StatusOK, map[string]any{
+		"ready":                  true,
+		"updated_at":             rep.CheckedAt.Format(time.RFC3339),
+		"validation_generation":  rep.Generation,
+		"validation_age_seconds": rep.AgeSeconds,
+		"validation_errors":      rep.ErrorsFound,
+	})
 }
@@ -0,0 +1,225 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"disposable-email-domains/internal/domain"
+	"disposable-email-domains/internal/middleware"
+)
+
+// generationHeader carries the list snapshot generation of check responses.
+const generationHeader = "X-List-Generation"
+
+// checkFunc runs a check in the context of the tenant selected for a request.
+type checkFunc func(string) domain.Result
+
+// checker resolves the tenant of a check request and returns the matching
+// check function; with ?as_of= it checks against the list version in effect
+// at that time instead. A configured X-API-Key takes precedence over the tenant
+// header. On failure the error response has been written and ok is false.
+// Match provenance (import URLs, token fingerprints) is only kept for
+// requests with the admin token.
+func (a *API) checker(w http.ResponseWriter, r *http.Request) (check checkFunc, ok bool) {
+	inner, ok := a.listChecker(w, r)
+	if !ok || middleware.IsAdmin(r) {
+		return inner, ok
+	}
+	return func(s string) domain.Result {
+		res := inner(s)
+		redactProvenance(&res)
+		return res
+	}, true
+}
+
+// redactProvenance drops the provenance of every match.
+func redactProvenance(res *domain.Result) {
+	for i := range res.Matches {
+		res.Matches[i].Provenance = nil
+	}
+}
+
+func (a *API) listChecker(w http.ResponseWriter, r *http.Request) (check checkFunc, ok bool) {
+	tenant := ""
+	if key := strings.TrimSpace(r.Header.Get("X-API-Key")); key != "" && a.cfg != nil && len(a.cfg.TenantAPIKeys) > 0 {
+		t, found := a.cfg.TenantAPIKeys[key]
+		if !found {
+			writeAPIError(w, http.StatusUnauthorized, "invalid_api_key", "unknown API key", nil)
+			return nil, false
+		}
+		tenant = t
+	} else if h := a.tenantHeader(); h != "" {
+		tenant = strings.ToLower(strings.TrimSpace(r.Header.Get(h)))
+	}
+	if tenant != "" {
+		if !domain.ValidTenantID(tenant) {
+			writeAPIError(w, http.StatusBadRequest, "invalid_tenant", domain.ErrInvalidTenant.Error(), nil)
+			return nil, false
+		}
+		if !a.Check.HasTenant(tenant) {
+			writeAPIError(w, http.StatusNotFound, "unknown_tenant", "unknown tenant", map[string]any{"tenant": tenant})
+			return nil, false
+		}
+	}
+	if asOf := strings.TrimSpace(r.URL.Query().Get("as_of")); asOf != "" {
+		if tenant != "" {
+			writeAPIError(w, http.StatusBadRequest, "invalid_as_of", "as_of does not apply to tenant overlays", nil)
+			return nil, false
+		}
+		return a.asOfChecker(w, r, asOf)
+	}
+	ctx := r.Context()
+	return func(s string) domain.Result {
+		res := a.Check.CheckTenant(ctx, tenant, s)
+		// Batches report the snapshot of their first item.
+		if h := w.Header(); h.Get(generationHeader) == "" {
+			h.Set(generationHeader, strconv.FormatUint(res.Generation, 10))
+		}
+		return res
+	}, true
+}
+
+func (a *API) tenantHeader() string {
+	if a.cfg == nil {
+		return "X-Tenant"
+	}
+	return a.cfg.TenantHeader
+}
+
+// Tenants manages tenant overlays:
+//
+//	GET    /tenants                       list tenants with entry counts
+//	GET    /tenants/{id}                  counts and entries of both overlays
+//	DELETE /tenants/{id}                  remove both overlay files
+//	GET    /tenants/{id}/{list}           entries of one overlay
+//	POST   /tenants/{id}/{list}           {"entries":[...]} adds entries (creates the tenant)
+//	DELETE /tenants/{id}/{list}           {"entries":[...]} removes entries
+//
+// where {list} is allowlist or blocklist. Overlay changes are logged but not
+// journaled: the list history (and so ?as_of= and rollbacks) covers the
+// shared lists only.
+func (a *API) Tenants(w http.ResponseWriter, r *http.Request) {
+	if a.Check == nil {
+		respondError(w, http.StatusServiceUnavailable, "checker not initialized")
+		return
+	}
+	if r.URL.Path == "/tenants" || r.URL.Path == "/tenants/" {
+		if r.Method != http.MethodGet {
+			respondMethodNotAllowed(w, http.MethodGet)
+			return
+		}
+		respondJSON(w, http.StatusOK, map[string]any{"tenants": a.Check.Tenants()})
+		return
+	}
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/tenants/"), "/")
+	if len(parts) > 2 || parts[0] == "" {
+		respondError(w, http.StatusNotFound, "not found")
+		return
+	}
+	id := parts[0]
+	if !domain.ValidTenantID(id) {
+		writeAPIError(w, http.StatusBadRequest, "invalid_tenant", domain.ErrInvalidTenant.Error(), nil)
+		return
+	}
+	if len(parts) == 1 {
+		a.tenant(w, r, id)
+		return
+	}
+	list := parts[1]
+	if list != "allowlist" && list != "blocklist" {
+		respondError(w, http.StatusNotFound, "not found")
+		return
+	}
+	switch r.Method {
+	case http.MethodGet:
+		entries, err := a.Check.TenantEntries(id, list)
+		if err != nil {
+			respondTenantError(w, id, err)
+			return
+		}
+		respondJSON(w, http.StatusOK, map[string]any{"tenant": id, "list": list, "entries": entries, "count": len(entries)})
+	case http.MethodPost, http.MethodDelete:
+		var payload struct {
+			Entries []string `json:"entries"`
+		}
+		if err := decodeJSON(w, r, &payload, 5<<20); err != nil {
+			respondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if len(payload.Entries) == 0 {
+			respondError(w, http.StatusBadRequest, "provide entries")
+			return
+		}
+		if r.Method == http.MethodDelete && !a.Check.HasTenant(id) {
+			respondTenantError(w, id, domain.ErrUnknownTenant)
+			return
+		}
+		var add, remove []string
+		if r.Method == http.MethodPost {
+			add = payload.Entries
+		} else {
+			remove = payload.Entries
+		}
+		added, removed, err := a.Check.UpdateTenantList(id, list, add, remove)
+		if err != nil {
+			respondTenantError(w, id, err)
+			return
+		}
+		if added == nil {
+			added = []string{}
+		}
+		if removed == nil {
+			removed = []string{}
+		}
+		a.Logger.Printf("tenant %s %s: %d added, %d removed", id, list, len(added), len(removed))
+		respondJSON(w, http.StatusOK, map[string]any{"tenant": id, "list": list, "added": added, "removed": removed})
+	default:
+		respondMethodNotAllowed(w, http.MethodGet, http.MethodPost, http.MethodDelete)
+	}
+}
+
+// tenant serves GET and DELETE /tenants/{id}.
+func (a *API) tenant(w http.ResponseWriter, r *http.Request, id string) {
+	switch r.Method {
+	case http.MethodGet:
+		allow, err := a.Check.TenantEntries(id, "allowlist")
+		if err != nil {
+			respondTenantError(w, id, err)
+			return
+		}
+		block, err := a.Check.TenantEntries(id, "blocklist")
+		if err != nil {
+			respondTenantError(w, id, err)
+			return
+		}
+		respondJSON(w, http.StatusOK, map[string]any{
+			"id":              id,
+			"allowlist_count": len(allow),
+			"blocklist_count": len(block),
+			"allowlist":       allow,
+			"blocklist":       block,
+		})
+	case http.MethodDelete:
+		if err := a.Check.DeleteTenant(id); err != nil {
+			respondTenantError(w, id, err)
+			return
+		}
+		a.Logger.Printf("tenant %s deleted", id)
+		respondJSON(w, http.StatusOK, map[string]any{"deleted": id})
+	default:
+		respondMethodNotAllowed(w, http.MethodGet, http.MethodDelete)
+	}
+}
+
+func respondTenantError(w http.ResponseWriter, id string, err error) {
+	switch {
+	case errors.Is(err, domain.ErrUnknownTenant):
+		writeAPIError(w, http.StatusNotFound, "unknown_tenant", "unknown tenant", map[string]any{"tenant": id})
+	case errors.Is(err, domain.ErrInvalidTenant), errors.Is(err, domain.ErrUnknownList), errors.Is(err, domain.ErrInvalidEntry):
+		respondError(w, http.StatusBadRequest, err.Error())
+	default:
+		respondError(w, http.StatusInternalServerError, err.Error())
+	}
+}
@@ -57,7 +57,13 @@ func (a *API) Index(w http.ResponseWriter, r *http.Request) {
 		{Method: "GET", Path: "/status", Desc: "Status snapshot", SampleURL: "/status", RespType: statusType, ContentType: "application/json"},
 		{Method: "GET", Path: "/readyz", Desc: "Readiness probe", SampleURL: "/readyz", RespType: fmt.Sprintf("%T", map[string]any{}), ContentType: "application/json"},
 		{Method: "GET", Path: "/blocklist", Desc: "List blocklist (use ?summary=true or paginate ?offset=&limit=)", SampleURL: "/blocklist?summary=true", RespType: fmt.Sprintf("%T", map[string]any{}), ContentType: "application/json"},
-		{Method: "POST", Path: "/blocklist", Desc: "Extend blocklist (entries/url(s))", SampleURL: "/blocklist", RespType: fmt.Sprintf("%T", map[string]any{}), ContentType: "application/json", BodyTemplate: `{"entries":["foo.com","bar.io"]}`, NeedsToken: true},
+		{Method: "POST", Path: "/blocklist", Desc: "Extend blocklist (entries/url(s); ?dry_run=true previews)", SampleURL: "/blocklist", RespType: fmt.Sprintf("%T", map[string]any{}), ContentType: "application/json", BodyTemplate: `{"entries":["foo.com","bar.io"]}`, NeedsToken: true},
+		{Method: "DELETE", Path: "/blocklist", Desc: "Remove blocklist entries and tombstone them (?entry= or entries; ?tombstone=false)", SampleURL: "/blocklist", RespType: fmt.Sprintf("%T", map[string]any{}), ContentType: "application/json", BodyTemplate: `{"entries":["foo.com"],"reason":"false positive"}`, NeedsToken: true},
+		{Method: "GET", Path: "/blocklist/tombstones", Desc: "Entries removed on purpose (skipped by imports)", SampleURL: "/blocklist/tombstones", RespType: fmt.Sprintf("%T", map[string]any{}), ContentType: "application/json", NeedsToken: true},
+		{Method: "DELETE", Path: "/blocklist/tombstones", Desc: "Lift tombstones so imports may re-add entries", SampleURL: "/blocklist/tombstones", RespType: fmt.Sprintf("%T", map[string]any{}), ContentType: "application/json", BodyTemplate: `{"entries":["foo.com"]}`, NeedsToken: true},
+		{Method: "GET", Path: "/allowlist", Desc: "List allowlist (use ?summary=true or paginate ?offset=&limit=)", SampleURL: "/allowlist", RespType: fmt.Sprintf("%T", map[string]any{}), ContentType: "application/json"},
+		{Method: "POST", Path: "/allowlist", Desc: "Extend allowlist (reports overridden blocklist entries, ?dry_run=true previews)", SampleURL: "/allowlist", RespType: fmt.Sprintf("%T", map[string]any{}), ContentType: "application/json", BodyTemplate: `{"entries":["example.org"]}`, NeedsToken: true},
+		{Method: "DELETE", Path: "/allowlist", Desc: "Remove allowlist entries (?entry= or entries)", SampleURL: "/allowlist", RespType: fmt.Sprintf("%T", map[string]any{}), ContentType: "application/json", BodyTemplate: `{"entries":["example.org"]}`, NeedsToken: true},
 		{Method: "GET", Path: "/check", Desc: "Check via ?q=", SampleURL: "/check?q=test@example.com", RespType: resultType, ContentType: "application/json"},
 		{Method: "GET", Path: "/q", Desc: "Alias for /check?q= (WAF-safe)", SampleURL: "/q?q=test@example.com", RespType: resultType, ContentType: "application/json"},
 		{Method: "GET", Path: "/check/emails/{email}", Desc: "Check email", SampleURL: "/check/emails/test%40example.com", RespType: resultType, ContentType: "application/json"},
@@ -69,6 +75,7 @@ func (a *API) Index(w http.ResponseWriter, r *http.Request) {
 		{Method: "POST", Path: "/check/emails", Desc: "Batch emails (JSON or text)", SampleURL: "/check/emails", RespType: "[]" + resultType, ContentType: "application/json", BodyTemplate: `{"items":["a@b.com","c@d.com"]}`},
 		{Method: "POST", Path: "/check/domains", Desc: "Batch domains (JSON or text)", SampleURL: "/check/domains", RespType: "[]" + resultType, ContentType: "application/json", BodyTemplate: `{"items":["example.com","a.b.com"]}`},
 		{Method: "GET", Path: "/validate", Desc: "Validate lists", SampleURL: "/validate", RespType: reportType, ContentType: "application/json"},
+		{Method: "POST", Path: "/validate/fix", Desc: "Fix validation findings (?dry_run=true previews, ?reduce=true reduces to eTLD+1)", SampleURL: "/validate/fix?dry_run=true", RespType: fmt.Sprintf("%T", domain.FixReport{}), ContentType: "application/json", NeedsToken: true},
 		{Method: "POST", Path: "/reload", Desc: "Full reload", SampleURL: "/reload", RespType: fmt.Sprintf("%T", map[string]any{}), ContentType: "application/json", NeedsToken: true},
 		{Method: "GET", Path: "/report", Desc: "Validate report (HTML)", SampleURL: "/report", RespType: "text/html", ContentType: "text/html"},
 		{Method: "GET", Path: "/report/check", Desc: "Check report via ?input=", SampleURL: "/report/check?input=test%40example.com", RespType: "text/html", ContentType: "text/html"},
@@ -76,11 +83,17 @@ func (a *API) Index(w http.ResponseWriter, r *http.Request) {
 		{Method: "GET", Path: "/report/domains/{domain}", Desc: "Check report (HTML)", SampleURL: "/report/domains/example.com", RespType: "text/html", ContentType: "text/html"},
 		{Method: "GET", Path: "/allowlist.conf", Desc: "Download allowlist", SampleURL: "/allowlist.conf", RespType: "text/plain", ContentType: "text/plain"},
 		{Method: "GET", Path: "/blocklist.conf", Desc: "Download blocklist", SampleURL: "/blocklist.conf", RespType: "text/plain", ContentType: "text/plain"},
+		{Method: "GET", Path: "/rolelist.conf", Desc: "Download role account list", SampleURL: "/rolelist.conf", RespType: "text/plain", ContentType: "text/plain"},
 		{Method: "GET", Path: "/public_suffix_list.dat", Desc: "Download PSL snapshot", SampleURL: "/public_suffix_list.dat", RespType: "text/plain", ContentType: "text/plain"},
 		{Method: "GET", Path: "/psl", Desc: "Download PSL snapshot (alias)", SampleURL: "/psl", RespType: "text/plain", ContentType: "text/plain"},
 		{Method: "GET", Path: "/psl.txt", Desc: "Download PSL snapshot (alias)", SampleURL: "/psl.txt", RespType: "text/plain", ContentType: "text/plain"},
 		{Method: "GET", Path: "/metrics", Desc: "Prometheus metrics", SampleURL: "/metrics", RespType: "text/plain; OpenMetrics", ContentType: "text/plain"},
 		{Method: "POST", Path: "/admin/psl/refresh", Desc: "Force PSL refresh", SampleURL: "/admin/psl/refresh", RespType: fmt.Sprintf("%T", map[string]any{}), ContentType: "application/json", NeedsToken: true},
+		{Method: "GET", Path: "/admin/history", Desc: "List versions journal (?offset=&limit=)", SampleURL: "/admin/history?limit=20", RespType: fmt.Sprintf("%T", map[string]any{}), ContentType: "application/json", NeedsToken: true},
+		{Method: "GET", Path: "/admin/history/diff", Desc: "Diff two list versions (?from=&to=)", SampleURL: "/admin/history/diff?from=1", RespType: fmt.Sprintf("%T", map[string]any{}), ContentType: "application/json", NeedsToken: true},
+		{Method: "POST", Path: "/admin/history/rollback", Desc: "Roll the lists back to a version", SampleURL: "/admin/history/rollback", RespType: fmt.Sprintf("%T", map[string]any{}), ContentType: "application/json", BodyTemplate: `{"version":1}`, NeedsToken: true},
+		{Method: "GET", Path: "/admin/subscriptions", Desc: "Upstream list subscriptions and their refresh state", SampleURL: "/admin/subscriptions", RespType: fmt.Sprintf("%T", map[string]any{}), ContentType: "application/json", NeedsToken: true},
+		{Method: "POST", Path: "/admin/subscriptions/refresh", Desc: "Refresh subscriptions now (?name=)", SampleURL: "/admin/subscriptions/refresh", RespType: fmt.Sprintf("%T", map[string]any{}), ContentType: "application/json", NeedsToken: true},
 	}
 	epJSON, _ := json.Marshal(eps)
 
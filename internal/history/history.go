@@ -0,0 +1,841 @@
+package history
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"disposable-email-domains/internal/metrics"
+)
+
+// Operations recorded in Version.Op.
+const (
+	OpStartup  = "startup"  // lists found on disk when the server started
+	OpReload   = "reload"   // lists changed outside the API and were reloaded
+	OpAppend   = "append"   // entries added via the API
+	OpRemove   = "remove"   // entries removed via the API
+	OpLift     = "lift"     // blocklist tombstones lifted
+	OpFix      = "fix"      // mechanical fixes applied by /validate/fix
+	OpRollback = "rollback" // files restored from an earlier version
+	OpSync     = "sync"     // blocklist synced with an upstream subscription
+)
+
+// JournalEntries caps the entries a journal record lists per list and
+// direction; the counts and the snapshots cover the rest.
+const JournalEntries = 100
+
+var (
+	// ErrUnknownVersion is returned for versions not in the journal.
+	ErrUnknownVersion = errors.New("unknown version")
+	// ErrNoSnapshot is returned for versions whose snapshot was pruned.
+	ErrNoSnapshot = errors.New("snapshot no longer retained")
+	// ErrBeforeHistory is returned by AsOf for times before the first version.
+	ErrBeforeHistory = errors.New("no version recorded before that time")
+)
+
+// Change describes a list mutation to record. Without Lists, what changed is
+// derived by comparing the files with the previous version.
+type Change struct {
+	Op      string
+	Actor   string   // fingerprint of the admin token, if any
+	Sources []string // import URLs or "manual"
+	// Lists holds, per list name, the entries the caller added and removed;
+	// a non-nil empty map records that no list entries changed. Only the
+	// entry lists are read; the counts are derived.
+	Lists map[string]ListDiff
+}
+
+// Version is one journal record: a mutation and the list state after it.
+type Version struct {
+	ID         int                 `json:"version"`
+	At         time.Time           `json:"at"`
+	Op         string              `json:"op"`
+	Actor      string              `json:"actor,omitempty"`
+	Sources    []string            `json:"sources,omitempty"`
+	RollbackTo int                 `json:"rollback_to,omitempty"`
+	Changes    map[string]ListDiff `json:"changes,omitempty"` // per list, relative to the previous version
+	Generation uint64              `json:"generation"`        // list generation published by the recording process
+	Sum        string              `json:"sum"`               // SHA-256 over the snapshotted files
+	Full       bool                `json:"full,omitempty"`    // stored as a full snapshot rather than a delta
+	Snapshot   bool                `json:"snapshot"`          // the files can still be restored
+}
+
+// ListDiff lists the entries added to and removed from a list.
+type ListDiff struct {
+	Added        []string `json:"added"`
+	Removed      []string `json:"removed"`
+	AddedCount   int      `json:"added_count"`
+	RemovedCount int      `json:"removed_count"`
+}
+
+// Truncate caps both entry lists at n entries; n <= 0 keeps all.
+func (d ListDiff) Truncate(n int) ListDiff {
+	if n > 0 {
+		d.Added = d.Added[:min(n, len(d.Added))]
+		d.Removed = d.Removed[:min(n, len(d.Removed))]
+	}
+	return d
+}
+
+// Store journals list mutations in Dir/journal.jsonl so retained versions can
+// be diffed and restored. Every SnapshotEvery versions the files are kept as a
+// gzipped tar snapshot; the versions in between store a delta against the
+// previous version (the changed byte range per file) and are rebuilt from the
+// nearest snapshot. Callers serialize Record and Rollback with the other
+// writers of the files.
+type Store struct {
+	Dir           string
+	Lists         map[string]string // list name -> file, compared entry by entry
+	Files         []string          // further files captured in snapshots, e.g. sidecars
+	Keep          int               // versions retained restorable; 0 keeps all
+	SnapshotEvery int               // versions per full snapshot; <= 1 snapshots each one
+	// Reload republishes the lists after a rollback.
+	Reload func(strict bool) error
+	// Generation, when set, reports the published list generation.
+	Generation func() uint64
+	// Wrote, when set, is called with the contents a rollback restores to
+	// each file; nil data for files it removes.
+	Wrote  func(path string, data []byte)
+	Logger *log.Logger
+
+	mu       sync.Mutex
+	versions []Version
+	// files of the latest recorded version, so recording does not rebuild them
+	latest   map[string][]byte
+	latestID int
+}
+
+func New(logger *log.Logger, dir string, lists map[string]string, files ...string) *Store {
+	return &Store{Dir: dir, Lists: lists, Files: files, Keep: 50, SnapshotEvery: 10, Logger: logger}
+}
+
+func (s *Store) journalPath() string { return filepath.Join(s.Dir, "journal.jsonl") }
+
+func (s *Store) snapshotPath(id int) string {
+	return filepath.Join(s.Dir, fmt.Sprintf("v%06d.tar.gz", id))
+}
+
+func (s *Store) deltaPath(id int) string {
+	return filepath.Join(s.Dir, fmt.Sprintf("v%06d.delta.gz", id))
+}
+
+// Open creates Dir and loads the journal; malformed lines are skipped.
+func (s *Store) Open() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := os.MkdirAll(s.Dir, 0o755); err != nil {
+		return err
+	}
+	f, err := os.Open(s.journalPath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	defer f.Close()
+	var versions []Version
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 0, 64*1024), 4*1024*1024)
+	for sc.Scan() {
+		var v Version
+		if json.Unmarshal(sc.Bytes(), &v) != nil || v.ID <= 0 {
+			continue
+		}
+		// A delta is only restorable while the chain back to its snapshot
+		// is complete.
+		v.Full, v.Snapshot = false, false
+		if _, err := os.Stat(s.snapshotPath(v.ID)); err == nil {
+			v.Full, v.Snapshot = true, true
+		} else if _, err := os.Stat(s.deltaPath(v.ID)); err == nil && len(versions) > 0 {
+			prev := versions[len(versions)-1]
+			v.Snapshot = prev.Snapshot && prev.ID == v.ID-1
+		}
+		versions = append(versions, v)
+	}
+	if err := sc.Err(); err != nil {
+		return err
+	}
+	s.versions = versions
+	return nil
+}
+
+// Versions returns the journal, oldest first.
+func (s *Store) Versions() []Version {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Clone(s.versions)
+}
+
+// Version returns the journal record of version id.
+func (s *Store) Version(id int) (Version, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	i := s.index(id)
+	if i < 0 {
+		return Version{}, false
+	}
+	return s.versions[i], true
+}
+
+func (s *Store) index(id int) int {
+	i, ok := slices.BinarySearchFunc(s.versions, id, func(v Version, id int) int { return v.ID - id })
+	if !ok {
+		return -1
+	}
+	return i
+}
+
+// AsOf returns the version in effect at t: the latest one recorded at or
+// before t.
+func (s *Store) AsOf(t time.Time) (Version, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	i, _ := slices.BinarySearchFunc(s.versions, t, func(v Version, t time.Time) int {
+		if v.At.After(t) {
+			return 1
+		}
+		return -1
+	})
+	if i == 0 {
+		return Version{}, ErrBeforeHistory
+	}
+	return s.versions[i-1], nil
+}
+
+// Extract writes the files of version id below dir and returns where each
+// snapshotted path ended up; files missing from the version are mapped but
+// not written.
+func (s *Store) Extract(id int, dir string) (map[string]string, error) {
+	s.mu.Lock()
+	files, err := s.snapshot(id)
+	s.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	out := make(map[string]string)
+	for _, p := range s.paths() {
+		dst := filepath.Join(dir, filepath.Clean("/"+p))
+		out[p] = dst
+		data, ok := files[p]
+		if !ok {
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+			return nil, err
+		}
+		if err := os.WriteFile(dst, data, 0o644); err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
+
+// Record snapshots the files and journals ch as a new version. Nothing is
+// recorded when the files are unchanged since the latest version. The files
+// are stored as a delta against the latest version, found by comparing the
+// lines they share at the start and end, and the journaled changes are
+// taken from ch.Lists when the caller knows them.
+func (s *Store) Record(ch Change) (Version, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.record(ch, 0)
+}
+
+func (s *Store) record(ch Change, rollbackTo int) (Version, bool, error) {
+	files, err := s.readFiles()
+	if err != nil {
+		return Version{}, false, err
+	}
+	sum := checksum(files)
+	v := Version{ID: 1, At: time.Now().UTC(), Op: ch.Op, Actor: ch.Actor, Sources: ch.Sources, RollbackTo: rollbackTo, Sum: sum, Snapshot: true}
+	var old map[string][]byte
+	sinceFull := 0
+	if n := len(s.versions); n > 0 {
+		prev := s.versions[n-1]
+		if prev.Sum == sum {
+			return Version{}, false, nil
+		}
+		v.ID = prev.ID + 1
+		if old, err = s.snapshot(prev.ID); err == nil && ch.Lists == nil {
+			v.Changes = s.diff(old, files, JournalEntries)
+		}
+		if ch.Lists != nil {
+			v.Changes = listChanges(ch.Lists, JournalEntries)
+		}
+		for i := n - 1; i >= 0 && !s.versions[i].Full; i-- {
+			sinceFull++
+		}
+	} else {
+		v.Changes = s.diff(nil, files, JournalEntries)
+	}
+	if s.Generation != nil {
+		v.Generation = s.Generation()
+	}
+	// A delta needs the previous files; one larger than half the files is
+	// not worth rebuilding through.
+	var d delta
+	if old != nil && sinceFull+1 < s.SnapshotEvery {
+		if d = makeDelta(old, files); d.size() > totalSize(files)/2 {
+			d = nil
+		}
+	}
+	if d != nil {
+		err = writeDelta(s.deltaPath(v.ID), d)
+	} else {
+		v.Full = true
+		err = writeSnapshot(s.snapshotPath(v.ID), files)
+	}
+	if err != nil {
+		return Version{}, false, fmt.Errorf("write snapshot: %w", err)
+	}
+	line, err := json.Marshal(v)
+	if err != nil {
+		return Version{}, false, err
+	}
+	if err := appendLine(s.journalPath(), line); err != nil {
+		return Version{}, false, fmt.Errorf("append journal: %w", err)
+	}
+	s.versions = append(s.versions, v)
+	s.latest, s.latestID = files, v.ID
+	s.prune()
+	metrics.ListHistoryVersionsTotal.WithLabelValues(v.Op).Inc()
+	return v, true, nil
+}
+
+// prune drops the files of versions older than the latest Keep, except the
+// snapshot and deltas the oldest retained version is rebuilt from. Their
+// journal records stay.
+func (s *Store) prune() {
+	cut := len(s.versions) - s.Keep
+	if s.Keep <= 0 || cut <= 0 {
+		return
+	}
+	for cut > 0 && !s.versions[cut].Full {
+		cut--
+	}
+	for i := range cut {
+		v := &s.versions[i]
+		if !v.Snapshot {
+			continue
+		}
+		path := s.deltaPath(v.ID)
+		if v.Full {
+			path = s.snapshotPath(v.ID)
+		}
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			s.Logger.Printf("history: prune v%d: %v", v.ID, err)
+			continue
+		}
+		v.Snapshot = false
+	}
+}
+
+// Diff compares the lists of two retained versions. Entry lists are capped
+// at limit entries (0 = all); the counts are exact.
+func (s *Store) Diff(from, to, limit int) (map[string]ListDiff, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	a, err := s.snapshot(from)
+	if err != nil {
+		return nil, err
+	}
+	b, err := s.snapshot(to)
+	if err != nil {
+		return nil, err
+	}
+	return s.diff(a, b, limit), nil
+}
+
+// Rollback restores the files of version id, reloads the lists and records
+// the restore as a new version. When the reload fails the previous files are
+// put back. It reports false when the files already match version id.
+func (s *Store) Rollback(id int, actor string) (Version, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	target, err := s.snapshot(id)
+	if err != nil {
+		return Version{}, false, err
+	}
+	cur, err := s.readFiles()
+	if err != nil {
+		return Version{}, false, err
+	}
+	if checksum(cur) == checksum(target) {
+		return Version{}, false, nil
+	}
+	if err := s.restore(target); err != nil {
+		if rerr := s.restore(cur); rerr != nil {
+			s.Logger.Printf("history: restore after failed rollback: %v", rerr)
+		}
+		return Version{}, false, fmt.Errorf("restore v%d: %w", id, err)
+	}
+	if s.Reload != nil {
+		if err := s.Reload(false); err != nil {
+			if rerr := s.restore(cur); rerr != nil {
+				s.Logger.Printf("history: restore after failed rollback: %v", rerr)
+			} else if rerr := s.Reload(false); rerr != nil {
+				s.Logger.Printf("history: reload after failed rollback: %v", rerr)
+			}
+			return Version{}, false, fmt.Errorf("reload v%d: %w", id, err)
+		}
+	}
+	return s.record(Change{Op: OpRollback, Actor: actor}, id)
+}
+
+// snapshot reads the files of version id, applying the deltas recorded
+// since the nearest full snapshot.
+func (s *Store) snapshot(id int) (map[string][]byte, error) {
+	i := s.index(id)
+	if i < 0 {
+		return nil, fmt.Errorf("%w %d", ErrUnknownVersion, id)
+	}
+	if !s.versions[i].Snapshot {
+		return nil, fmt.Errorf("version %d: %w", id, ErrNoSnapshot)
+	}
+	if id == s.latestID && s.latest != nil {
+		return maps.Clone(s.latest), nil
+	}
+	base := i
+	for !s.versions[base].Full {
+		if base--; base < 0 || !s.versions[base].Snapshot {
+			return nil, fmt.Errorf("version %d: %w", id, ErrNoSnapshot)
+		}
+	}
+	files, err := s.readSnapshot(s.versions[base].ID)
+	if err != nil {
+		return nil, err
+	}
+	for _, v := range s.versions[base+1 : i+1] {
+		d, err := readDelta(s.deltaPath(v.ID))
+		if err != nil {
+			return nil, fmt.Errorf("version %d: %w", v.ID, err)
+		}
+		if err := d.apply(files); err != nil {
+			return nil, fmt.Errorf("version %d: %w", v.ID, err)
+		}
+	}
+	return files, nil
+}
+
+// paths returns the snapshotted files: the lists by name, then Files.
+func (s *Store) paths() []string {
+	var out []string
+	for _, name := range slices.Sorted(maps.Keys(s.Lists)) {
+		out = append(out, s.Lists[name])
+	}
+	for _, p := range s.Files {
+		if !slices.Contains(out, p) {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+// readFiles reads the snapshotted files; missing files are left out.
+func (s *Store) readFiles() (map[string][]byte, error) {
+	out := make(map[string][]byte)
+	for _, p := range s.paths() {
+		data, err := os.ReadFile(p)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, err
+		}
+		out[p] = data
+	}
+	return out, nil
+}
+
+// restore writes the files of a snapshot in place, each through a temp file
+// and rename, and removes the files it does not contain.
+func (s *Store) restore(files map[string][]byte) error {
+	for _, p := range s.paths() {
+		data, ok := files[p]
+		if !ok {
+			if err := os.Remove(p); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+			if s.Wrote != nil {
+				s.Wrote(p, nil)
+			}
+			continue
+		}
+		tmp := p + ".restore"
+		if err := os.WriteFile(tmp, data, 0o644); err != nil {
+			return err
+		}
+		if err := os.Rename(tmp, p); err != nil {
+			_ = os.Remove(tmp)
+			return err
+		}
+		if s.Wrote != nil {
+			s.Wrote(p, data)
+		}
+	}
+	return nil
+}
+
+func (s *Store) diff(from, to map[string][]byte, limit int) map[string]ListDiff {
+	out := make(map[string]ListDiff)
+	for name, p := range s.Lists {
+		a, b := entries(from[p]), entries(to[p])
+		var d ListDiff
+		for e := range b {
+			if _, ok := a[e]; !ok {
+				d.Added = append(d.Added, e)
+			}
+		}
+		for e := range a {
+			if _, ok := b[e]; !ok {
+				d.Removed = append(d.Removed, e)
+			}
+		}
+		if d, ok := d.finish(limit); ok {
+			out[name] = d
+		}
+	}
+	return out
+}
+
+// listChanges is diff for entries reported by the caller.
+func listChanges(lists map[string]ListDiff, limit int) map[string]ListDiff {
+	out := make(map[string]ListDiff)
+	for name, d := range lists {
+		d.Added, d.Removed = slices.Clone(d.Added), slices.Clone(d.Removed)
+		if d, ok := d.finish(limit); ok {
+			out[name] = d
+		}
+	}
+	return out
+}
+
+// finish sorts and counts the entries and caps them at limit; it reports
+// false when nothing changed.
+func (d ListDiff) finish(limit int) (ListDiff, bool) {
+	if len(d.Added) == 0 && len(d.Removed) == 0 {
+		return ListDiff{}, false
+	}
+	slices.Sort(d.Added)
+	slices.Sort(d.Removed)
+	d.AddedCount, d.RemovedCount = len(d.Added), len(d.Removed)
+	if d.Added == nil {
+		d.Added = []string{}
+	}
+	if d.Removed == nil {
+		d.Removed = []string{}
+	}
+	return d.Truncate(limit), true
+}
+
+// entries returns the set of non-empty, non-comment lines of a list file.
+func entries(data []byte) map[string]struct{} {
+	out := make(map[string]struct{})
+	for line := range strings.Lines(string(data)) {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		out[line] = struct{}{}
+	}
+	return out
+}
+
+func checksum(files map[string][]byte) string {
+	h := sha256.New()
+	for _, p := range slices.Sorted(maps.Keys(files)) {
+		fmt.Fprintf(h, "%s\x00%d\x00", p, len(files[p]))
+		h.Write(files[p])
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func writeSnapshot(path string, files map[string][]byte) error {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(zw)
+	for _, p := range slices.Sorted(maps.Keys(files)) {
+		hdr := &tar.Header{Name: filepath.ToSlash(p), Mode: 0o644, Size: int64(len(files[p]))}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		if _, err := tw.Write(files[p]); err != nil {
+			return err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, buf.Bytes(), 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// delta holds the changes of one version per file: the new content as runs
+// of lines copied from the previous content and inserted lines, or Absent
+// for files that were removed.
+type delta map[string]fileDelta
+
+type fileDelta struct {
+	Ops    []deltaOp `json:"ops,omitempty"`
+	Absent bool      `json:"absent,omitempty"`
+}
+
+// deltaOp copies Copy[1] lines starting at line Copy[0] of the previous
+// content, or inserts Insert.
+type deltaOp struct {
+	Copy   *[2]int  `json:"c,omitempty"`
+	Insert []string `json:"i,omitempty"`
+}
+
+func makeDelta(from, to map[string][]byte) delta {
+	d := make(delta)
+	for p := range from {
+		if _, ok := to[p]; !ok {
+			d[p] = fileDelta{Absent: true}
+		}
+	}
+	for p, b := range to {
+		a, ok := from[p]
+		if ok && bytes.Equal(a, b) {
+			continue
+		}
+		d[p] = fileDelta{Ops: fileOps(a, b)}
+	}
+	return d
+}
+
+// fileOps is lineOps for whole files. The lines both share at the start and
+// the end are copied without looking at them individually, so appends and
+// small removals cost a comparison rather than an index of every line.
+func fileOps(a, b []byte) []deltaOp {
+	p := 0
+	for p < len(a) && p < len(b) && a[p] == b[p] {
+		p++
+	}
+	p = bytes.LastIndexByte(a[:p], '\n') + 1
+	s := 0
+	for s < len(a)-p && s < len(b)-p && a[len(a)-1-s] == b[len(b)-1-s] {
+		s++
+	}
+	for s > 0 && len(a)-s > p && a[len(a)-s-1] != '\n' {
+		s--
+	}
+	var ops []deltaOp
+	head := bytes.Count(a[:p], []byte{'\n'})
+	if head > 0 {
+		ops = append(ops, deltaOp{Copy: &[2]int{0, head}})
+	}
+	for _, op := range lineOps(lines(a[p:len(a)-s]), lines(b[p:len(b)-s])) {
+		if op.Copy != nil {
+			op.Copy[0] += head
+		}
+		ops = append(ops, op)
+	}
+	if tail := lineCount(a[len(a)-s:]); tail > 0 {
+		ops = append(ops, deltaOp{Copy: &[2]int{lineCount(a) - tail, tail}})
+	}
+	return ops
+}
+
+// lineCount is len(lines(data)) without splitting.
+func lineCount(data []byte) int {
+	n := bytes.Count(data, []byte{'\n'})
+	if len(data) > 0 && data[len(data)-1] != '\n' {
+		n++
+	}
+	return n
+}
+
+// lineOps expresses b in terms of a: each line of b extends the current copy
+// run when it is the next line of a, else starts a run at its first
+// occurrence in a after the previous run, else is inserted.
+func lineOps(a, b []string) []deltaOp {
+	at := make(map[string][]int, len(a))
+	for i, l := range a {
+		at[l] = append(at[l], i)
+	}
+	var ops []deltaOp
+	next := -1 // line of a after the current copy run
+	for _, l := range b {
+		if n := len(ops); n > 0 && ops[n-1].Copy != nil && next < len(a) && a[next] == l {
+			ops[n-1].Copy[1]++
+			next++
+			continue
+		}
+		if pos := at[l]; len(pos) > 0 {
+			i := pos[0]
+			if k, _ := slices.BinarySearch(pos, next); k < len(pos) {
+				i = pos[k]
+			}
+			ops = append(ops, deltaOp{Copy: &[2]int{i, 1}})
+			next = i + 1
+			continue
+		}
+		if n := len(ops); n > 0 && ops[n-1].Copy == nil {
+			ops[n-1].Insert = append(ops[n-1].Insert, l)
+		} else {
+			ops = append(ops, deltaOp{Insert: []string{l}})
+		}
+	}
+	return ops
+}
+
+// lines splits data after each newline; a final line without one is kept.
+func lines(data []byte) []string {
+	var out []string
+	for l := range strings.Lines(string(data)) {
+		out = append(out, l)
+	}
+	return out
+}
+
+// size approximates the encoded size of d.
+func (d delta) size() int {
+	n := 0
+	for _, f := range d {
+		for _, op := range f.Ops {
+			n += 16
+			for _, l := range op.Insert {
+				n += len(l)
+			}
+		}
+	}
+	return n
+}
+
+func (d delta) apply(files map[string][]byte) error {
+	for p, f := range d {
+		if f.Absent {
+			delete(files, p)
+			continue
+		}
+		a := lines(files[p])
+		var b bytes.Buffer
+		for _, op := range f.Ops {
+			if op.Copy == nil {
+				for _, l := range op.Insert {
+					b.WriteString(l)
+				}
+				continue
+			}
+			start, n := op.Copy[0], op.Copy[1]
+			if start < 0 || n < 0 || start+n > len(a) {
+				return fmt.Errorf("delta does not fit %s", p)
+			}
+			for _, l := range a[start : start+n] {
+				b.WriteString(l)
+			}
+		}
+		files[p] = b.Bytes()
+	}
+	return nil
+}
+
+func totalSize(files map[string][]byte) int {
+	n := 0
+	for _, data := range files {
+		n += len(data)
+	}
+	return n
+}
+
+func writeDelta(path string, d delta) error {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if err := json.NewEncoder(zw).Encode(d); err != nil {
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, buf.Bytes(), 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+func readDelta(path string) (delta, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, ErrNoSnapshot
+		}
+		return nil, err
+	}
+	defer f.Close()
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		return nil, err
+	}
+	var d delta
+	if err := json.NewDecoder(zr).Decode(&d); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+func (s *Store) readSnapshot(id int) (map[string][]byte, error) {
+	f, err := os.Open(s.snapshotPath(id))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("version %d: %w", id, ErrNoSnapshot)
+		}
+		return nil, err
+	}
+	defer f.Close()
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		return nil, err
+	}
+	out := make(map[string][]byte)
+	tr := tar.NewReader(zr)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return out, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		data, err := io.ReadAll(tr)
+		if err != nil {
+			return nil, err
+		}
+		out[filepath.FromSlash(hdr.Name)] = data
+	}
+}
+
+func appendLine(path string, line []byte) error {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,259 @@
+package history
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"disposable-email-domains/internal/domain"
+)
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRecordDiffRollback(t *testing.T) {
+	dir := t.TempDir()
+	allowPath := filepath.Join(dir, "allowlist.conf")
+	blockPath := filepath.Join(dir, "blocklist.conf")
+	writeFile(t, allowPath, "gmail.com\n")
+	writeFile(t, blockPath, "# blocklist\na.com\nb.com\n")
+	c := domain.NewChecker(allowPath, blockPath)
+	if err := c.Load(); err != nil {
+		t.Fatal(err)
+	}
+	s := New(log.New(io.Discard, "", 0), filepath.Join(dir, "history"), map[string]string{"allowlist": allowPath, "blocklist": blockPath}, c.ListFiles()...)
+	s.Keep = 3
+	s.SnapshotEvery = 1
+	s.Reload = c.Reload
+	s.Generation = c.Generation
+	if err := s.Open(); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok, err := s.Record(Change{Op: OpStartup}); !ok || err != nil {
+		t.Fatalf("expected startup version: %v %v", ok, err)
+	}
+	if _, ok, _ := s.Record(Change{Op: OpReload}); ok {
+		t.Fatal("unchanged files must not create a version")
+	}
+
+	// A bad import, then a removal.
+	writeFile(t, blockPath, "# blocklist\na.com\nb.com\nbad1.com\nbad2.com\n")
+	v2, _, err := s.Record(Change{Op: OpAppend, Actor: "abc", Sources: []string{"https://lists.example/bad.txt"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d := v2.Changes["blocklist"]; d.AddedCount != 2 || !slices.Equal(d.Added, []string{"bad1.com", "bad2.com"}) {
+		t.Fatalf("unexpected journal changes: %+v", v2.Changes)
+	}
+	if _, ok := v2.Changes["allowlist"]; ok {
+		t.Fatal("allowlist did not change")
+	}
+	writeFile(t, blockPath, "# blocklist\nb.com\nbad1.com\nbad2.com\n")
+	if _, _, err := s.Record(Change{Op: OpRemove}); err != nil {
+		t.Fatal(err)
+	}
+
+	diff, err := s.Diff(1, 3, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d := diff["blocklist"]; !slices.Equal(d.Added, []string{"bad1.com", "bad2.com"}) || !slices.Equal(d.Removed, []string{"a.com"}) {
+		t.Fatalf("unexpected diff: %+v", diff)
+	}
+
+	if _, _, err := s.Rollback(9, ""); !errors.Is(err, ErrUnknownVersion) {
+		t.Fatalf("expected ErrUnknownVersion, got %v", err)
+	}
+	v4, ok, err := s.Rollback(2, "abc")
+	if err != nil || !ok {
+		t.Fatalf("rollback: %v %v", ok, err)
+	}
+	if v4.ID != 4 || v4.Op != OpRollback || v4.RollbackTo != 2 || v4.Changes["blocklist"].AddedCount != 1 {
+		t.Fatalf("unexpected rollback version: %+v", v4)
+	}
+	if !c.Check("a.com").Blocklisted {
+		t.Fatal("expected a.com restored in memory")
+	}
+	if data, _ := os.ReadFile(blockPath); string(data) != "# blocklist\na.com\nb.com\nbad1.com\nbad2.com\n" {
+		t.Fatalf("unexpected restored file:\n%s", data)
+	}
+	if _, ok, _ := s.Rollback(4, ""); ok {
+		t.Fatal("rolling back to the current state must not create a version")
+	}
+	// The rollback pruned version 1 (Keep = 3).
+	if _, err := s.Diff(1, 2, 0); !errors.Is(err, ErrNoSnapshot) {
+		t.Fatalf("expected ErrNoSnapshot, got %v", err)
+	}
+
+	// The journal survives a restart.
+	s2 := New(log.New(io.Discard, "", 0), s.Dir, s.Lists, s.Files...)
+	if err := s2.Open(); err != nil {
+		t.Fatal(err)
+	}
+	vs := s2.Versions()
+	if len(vs) != 4 || vs[0].Snapshot || !vs[3].Snapshot || vs[1].Sources[0] != "https://lists.example/bad.txt" {
+		t.Fatalf("unexpected journal after reopen: %+v", vs)
+	}
+}
+
+func TestRollbackRestoresMissingFiles(t *testing.T) {
+	dir := t.TempDir()
+	list := filepath.Join(dir, "blocklist.conf")
+	sidecar := list + ".tombstones"
+	writeFile(t, list, "a.com\n")
+	s := New(log.New(io.Discard, "", 0), filepath.Join(dir, "history"), map[string]string{"blocklist": list}, sidecar)
+	if err := s.Open(); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := s.Record(Change{Op: OpStartup}); err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, sidecar, `{"entry":"b.com"}`+"\n")
+	if _, ok, _ := s.Record(Change{Op: OpRemove}); !ok {
+		t.Fatal("a sidecar change is a new version")
+	}
+	if _, _, err := s.Rollback(1, ""); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(sidecar); !os.IsNotExist(err) {
+		t.Fatalf("expected sidecar removed, got %v", err)
+	}
+}
+
+func TestIntervalSnapshots(t *testing.T) {
+	dir := t.TempDir()
+	list := filepath.Join(dir, "blocklist.conf")
+	content := "# blocklist\n"
+	for i := range 40 {
+		content += fmt.Sprintf("d%02d.com\n", i)
+	}
+	writeFile(t, list, content)
+	s := New(log.New(io.Discard, "", 0), filepath.Join(dir, "history"), map[string]string{"blocklist": list})
+	s.SnapshotEvery = 3
+	s.Keep = 4
+	if err := s.Open(); err != nil {
+		t.Fatal(err)
+	}
+	want := map[int]string{}
+	for i := range 7 {
+		if i > 0 {
+			content += fmt.Sprintf("new%d.com\n", i)
+			content = strings.Replace(content, fmt.Sprintf("d%02d.com\n", i), "", 1)
+			writeFile(t, list, content)
+		}
+		v, _, err := s.Record(Change{Op: OpAppend})
+		if err != nil {
+			t.Fatal(err)
+		}
+		want[v.ID] = content
+	}
+	var full []int
+	for _, v := range s.Versions() {
+		if v.Full {
+			full = append(full, v.ID)
+		}
+	}
+	if !slices.Equal(full, []int{1, 4, 7}) {
+		t.Fatalf("expected snapshots at 1, 4 and 7, got %v", full)
+	}
+
+	// Keep = 4 retains 4..7; 1..3 are pruned with their snapshot.
+	s2 := New(log.New(io.Discard, "", 0), s.Dir, s.Lists)
+	if err := s2.Open(); err != nil {
+		t.Fatal(err)
+	}
+	for id := 1; id <= 7; id++ {
+		got, err := s2.Extract(id, t.TempDir())
+		if id < 4 {
+			if !errors.Is(err, ErrNoSnapshot) {
+				t.Fatalf("v%d: expected ErrNoSnapshot, got %v", id, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("v%d: %v", id, err)
+		}
+		if data, _ := os.ReadFile(got[list]); string(data) != want[id] {
+			t.Fatalf("v%d rebuilt wrong:\n%s", id, data)
+		}
+	}
+	d, err := s2.Diff(5, 6, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b := d["blocklist"]; !slices.Equal(b.Added, []string{"new5.com"}) || !slices.Equal(b.Removed, []string{"d05.com"}) {
+		t.Fatalf("unexpected diff: %+v", d)
+	}
+}
+
+func TestFileOps(t *testing.T) {
+	cases := []struct{ a, b string }{
+		{"a\nb\n", "a\nb\nc\n"},
+		{"a\nb\nc\n", "a\nc\n"},
+		{"a\nb\nc\n", "b\nc\n"},
+		{"x\nfoo\n", "xyfoo\n"},
+		{"a\nb", "a\nb\nc"},
+		{"", "a\n"},
+		{"a\n", ""},
+		{"a\nb\nc\nd\n", "a\nd\nc\nb\n"},
+	}
+	for _, tc := range cases {
+		ops := fileOps([]byte(tc.a), []byte(tc.b))
+		files := map[string][]byte{"f": []byte(tc.a)}
+		if err := (delta{"f": {Ops: ops}}).apply(files); err != nil || string(files["f"]) != tc.b {
+			t.Errorf("%q -> %q: got %q, %v", tc.a, tc.b, files["f"], err)
+		}
+	}
+	// An append copies the old content as one run.
+	if ops := fileOps([]byte("a\nb\nc\n"), []byte("a\nb\nc\nd\n")); len(ops) != 2 || *ops[0].Copy != [2]int{0, 3} || !slices.Equal(ops[1].Insert, []string{"d\n"}) {
+		t.Fatalf("unexpected append ops: %+v", ops)
+	}
+}
+
+func TestRecordCallerChanges(t *testing.T) {
+	dir := t.TempDir()
+	list := filepath.Join(dir, "blocklist.conf")
+	content := "# blocklist\n"
+	for i := range 40 {
+		content += fmt.Sprintf("d%02d.com\n", i)
+	}
+	writeFile(t, list, content)
+	s := New(log.New(io.Discard, "", 0), filepath.Join(dir, "history"), map[string]string{"blocklist": list})
+	if err := s.Open(); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := s.Record(Change{Op: OpStartup}); err != nil {
+		t.Fatal(err)
+	}
+	content += "c.com\nb.com\n"
+	writeFile(t, list, content)
+	v, ok, err := s.Record(Change{Op: OpAppend, Lists: map[string]ListDiff{"blocklist": {Added: []string{"c.com", "b.com"}}, "allowlist": {}}})
+	if err != nil || !ok {
+		t.Fatalf("record: %v %v", ok, err)
+	}
+	if d := v.Changes["blocklist"]; d.AddedCount != 2 || !slices.Equal(d.Added, []string{"b.com", "c.com"}) || len(v.Changes) != 1 {
+		t.Fatalf("unexpected changes: %+v", v.Changes)
+	}
+	// Rebuilt from the delta by a fresh store, not from the cached files.
+	s2 := New(log.New(io.Discard, "", 0), s.Dir, s.Lists)
+	if err := s2.Open(); err != nil {
+		t.Fatal(err)
+	}
+	got, err := s2.Extract(v.ID, t.TempDir())
+	if err != nil || v.Full {
+		t.Fatalf("extract: %v, full %v", err, v.Full)
+	}
+	if data, _ := os.ReadFile(got[list]); string(data) != content {
+		t.Fatalf("rebuilt wrong:\n%s", data)
+	}
+}
@@ -36,12 +36,45 @@ var (
 	AllowlistSizeGauge = prometheus.NewGauge(
 		prometheus.GaugeOpts{Name: "allowlist_domains", Help: "Current number of allowlisted domains"},
 	)
+	RolelistSizeGauge = prometheus.NewGauge(
+		prometheus.GaugeOpts{Name: "rolelist_entries", Help: "Current number of role account entries"},
+	)
+	ListGenerationGauge = prometheus.NewGauge(
+		prometheus.GaugeOpts{Name: "list_generation", Help: "Generation number of the published list snapshot"},
+	)
+	BloomLookupsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{Name: "bloom_lookups_total", Help: "List Bloom filter lookups by list and result (negative, true_positive, false_positive)"},
+		[]string{"list", "result"},
+	)
+	ListWatchReloadsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{Name: "list_watch_reloads_total", Help: "Reloads triggered by list file changes by result (ok, rejected, error, unchanged)"},
+		[]string{"result"},
+	)
+	ListWatchLastReloadUnix = prometheus.NewGauge(
+		prometheus.GaugeOpts{Name: "list_watch_last_reload_unixtime", Help: "Unix timestamp of the last successful reload triggered by a file change"},
+	)
 	BlocklistAppendsTotal = prometheus.NewCounter(
 		prometheus.CounterOpts{Name: "blocklist_appends_total", Help: "Number of blocklist domains appended"},
 	)
 	BlocklistDuplicatesSkippedTotal = prometheus.NewCounter(
 		prometheus.CounterOpts{Name: "blocklist_duplicates_skipped_total", Help: "Duplicate blocklist domains skipped during append"},
 	)
+	AllowlistAppendsTotal = prometheus.NewCounter(
+		prometheus.CounterOpts{Name: "allowlist_appends_total", Help: "Number of allowlist entries appended"},
+	)
+	AllowlistRemovalsTotal = prometheus.NewCounter(
+		prometheus.CounterOpts{Name: "allowlist_removals_total", Help: "Number of allowlist entries removed"},
+	)
+	BlocklistRemovalsTotal = prometheus.NewCounter(
+		prometheus.CounterOpts{Name: "blocklist_removals_total", Help: "Number of blocklist entries removed"},
+	)
+	BlocklistTombstonedSkippedTotal = prometheus.NewCounter(
+		prometheus.CounterOpts{Name: "blocklist_tombstoned_skipped_total", Help: "Imported blocklist domains skipped because they were removed on purpose"},
+	)
+	ListHistoryVersionsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{Name: "list_history_versions_total", Help: "List versions recorded in the mutation journal by operation"},
+		[]string{"op"},
+	)
 	PSLRefreshSuccessTotal = prometheus.NewCounter(
 		prometheus.CounterOpts{Name: "psl_refresh_success_total", Help: "Successful PSL refreshes"},
 	)
@@ -57,12 +90,35 @@ var (
 	PSLSizeDeltaWarningsTotal = prometheus.NewCounter(
 		prometheus.CounterOpts{Name: "psl_size_delta_warnings_total", Help: "PSL refreshes with significant size delta"},
 	)
+	SubscriptionRefreshTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{Name: "subscription_refresh_total", Help: "Upstream list subscription refreshes by source and result (updated, not_modified, failed)"},
+		[]string{"source", "result"},
+	)
+	SubscriptionConsecutiveFailures = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{Name: "subscription_consecutive_failures", Help: "Number of consecutive failed refreshes per subscription source"},
+		[]string{"source"},
+	)
+	SubscriptionLastRefreshUnix = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{Name: "subscription_last_refresh_unixtime", Help: "Unix timestamp of the last successful refresh per subscription source"},
+		[]string{"source"},
+	)
+	SubscriptionEntriesTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{Name: "subscription_entries_total", Help: "Blocklist entries added or pruned by subscription syncs"},
+		[]string{"source", "change"},
+	)
 	AdminAuthFailuresTotal = prometheus.NewCounter(
 		prometheus.CounterOpts{Name: "admin_auth_failures_total", Help: "Total failed admin authentication attempts"},
 	)
 	AdminAuthSuccessTotal = prometheus.NewCounter(
 		prometheus.CounterOpts{Name: "admin_auth_success_total", Help: "Total successful admin authentication attempts"},
 	)
+	MXLookupsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{Name: "mx_lookups_total", Help: "MX infrastructure lookups by result (disposable, clean, error, cached)"},
+		[]string{"result"},
+	)
+	MXInfraEntriesGauge = prometheus.NewGauge(
+		prometheus.GaugeOpts{Name: "mx_infrastructure_entries", Help: "Current number of disposable MX infrastructure entries"},
+	)
 )
 
 var registered atomic.Bool
@@ -75,7 +131,7 @@ func Register() {
 		collectors.NewGoCollector(),
 		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
 	)
-	reg.MustRegister(HTTPRequestsTotal, HTTPRequestDuration, RateLimitRejectedTotal, BlocklistSizeGauge, AllowlistSizeGauge, BlocklistAppendsTotal, BlocklistDuplicatesSkippedTotal, PSLRefreshSuccessTotal, PSLRefreshFailureTotal, PSLLastRefreshUnix, PSLConsecutiveFailures, PSLSizeDeltaWarningsTotal, AdminAuthFailuresTotal, AdminAuthSuccessTotal)
+	reg.MustRegister(HTTPRequestsTotal, HTTPRequestDuration, RateLimitRejectedTotal, BlocklistSizeGauge, AllowlistSizeGauge, RolelistSizeGauge, ListGenerationGauge, BloomLookupsTotal, ListWatchReloadsTotal, ListWatchLastReloadUnix, BlocklistAppendsTotal, BlocklistDuplicatesSkippedTotal, BlocklistRemovalsTotal, BlocklistTombstonedSkippedTotal, AllowlistAppendsTotal, AllowlistRemovalsTotal, ListHistoryVersionsTotal, PSLRefreshSuccessTotal, PSLRefreshFailureTotal, PSLLastRefreshUnix, PSLConsecutiveFailures, PSLSizeDeltaWarningsTotal, SubscriptionRefreshTotal, SubscriptionConsecutiveFailures, SubscriptionLastRefreshUnix, SubscriptionEntriesTotal, AdminAuthFailuresTotal, AdminAuthSuccessTotal, MXLookupsTotal, MXInfraEntriesGauge)
 }
 
 // Returns the /metrics HTTP handler
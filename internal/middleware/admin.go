@@ -1,11 +1,13 @@
 package middleware
 
 import (
+	"context"
 	"crypto/rand"
 	"crypto/subtle"
 	"log"
 	"math/big"
 	"net/http"
+	"strings"
 	"time"
 
 	"disposable-email-domains/internal/metrics"
@@ -57,7 +59,13 @@ func AdminGuard(adminToken string, logger *log.Logger) Middleware {
 // AdminGuardMulti extends AdminGuard to support multiple rotating tokens. If the slice is
 // empty, the server operates in read-only mode blocking mutating methods. Comparison is
 // constant-time per candidate; early exit upon first match.
-func AdminGuardMulti(tokens []string, logger *log.Logger) Middleware {
+//
+// Requests below a private path prefix (the prefix itself or prefix + "/...") need the
+// token for every method, including GET; without tokens they are rejected. Public
+// reads that carry a valid token are marked so handlers can include admin-only
+// details (see IsAdmin); an invalid token on them is counted and delayed but
+// the request proceeds anonymously.
+func AdminGuardMulti(tokens []string, logger *log.Logger, private ...string) Middleware {
 	// Pre-materialize byte slices for constant-time compare
 	var tokenBytes [][]byte
 	for _, t := range tokens {
@@ -66,12 +74,35 @@ func AdminGuardMulti(tokens []string, logger *log.Logger) Middleware {
 		}
 		tokenBytes = append(tokenBytes, []byte(t))
 	}
+	valid := func(supplied string) bool {
+		sb := []byte(supplied)
+		for _, tb := range tokenBytes {
+			if subtle.ConstantTimeCompare(sb, tb) == 1 {
+				return true
+			}
+		}
+		return false
+	}
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			if r.Method == http.MethodGet || r.Method == http.MethodHead || r.Method == http.MethodOptions { // safe methods
+			safe := r.Method == http.MethodGet || r.Method == http.MethodHead || r.Method == http.MethodOptions
+			if safe && !privatePath(r.URL.Path, private) {
+				if supplied := r.Header.Get("X-Admin-Token"); supplied != "" && len(tokenBytes) > 0 {
+					if valid(supplied) {
+						metrics.AdminAuthSuccessTotal.Inc()
+						r = r.WithContext(context.WithValue(r.Context(), adminKey{}, true))
+					} else {
+						metrics.AdminAuthFailuresTotal.Inc()
+						sleepAuth()
+					}
+				}
 				next.ServeHTTP(w, r)
 				return
 			}
+			if len(tokenBytes) == 0 && safe {
+				writeAuthError(w, http.StatusForbidden, "read_only", "admin token not configured")
+				return
+			}
 			if len(tokenBytes) == 0 { // read-only
 				writeAuthError(w, http.StatusForbidden, "read_only", "read-only mode: admin token not configured")
 				return
@@ -83,26 +114,35 @@ func AdminGuardMulti(tokens []string, logger *log.Logger) Middleware {
 				writeAuthError(w, http.StatusUnauthorized, "missing_token", "missing admin token")
 				return
 			}
-			sb := []byte(supplied)
-			ok := false
-			for _, tb := range tokenBytes {
-				if subtle.ConstantTimeCompare(sb, tb) == 1 {
-					ok = true
-					break
-				}
-			}
-			if !ok {
+			if !valid(supplied) {
 				metrics.AdminAuthFailuresTotal.Inc()
 				sleepAuth()
 				writeAuthError(w, http.StatusForbidden, "invalid_token", "invalid admin token")
 				return
 			}
 			metrics.AdminAuthSuccessTotal.Inc()
-			next.ServeHTTP(w, r)
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), adminKey{}, true)))
 		})
 	}
 }
 
+type adminKey struct{}
+
+// IsAdmin reports whether AdminGuardMulti accepted an admin token for r.
+func IsAdmin(r *http.Request) bool {
+	ok, _ := r.Context().Value(adminKey{}).(bool)
+	return ok
+}
+
+func privatePath(path string, prefixes []string) bool {
+	for _, p := range prefixes {
+		if path == p || strings.HasPrefix(path, p+"/") {
+			return true
+		}
+	}
+	return false
+}
+
 // Introduces a small randomized delay (50-150ms) to slow brute-force attempts
 // without significantly impacting legitimate traffic (hopefully).
 func sleepAuth() {
@@ -96,3 +96,65 @@ func TestAdminGuardMultiReadOnly(t *testing.T) {
 	}
 	_ = resp.Body.Close()
 }
+
+func TestAdminGuardMultiPrivatePaths(t *testing.T) {
+	valid := []string{"this_is_a_valid_admin_token_123"}
+	okHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(204) })
+	get := func(mw Middleware, path, token string) int {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		if token != "" {
+			req.Header.Set("X-Admin-Token", token)
+		}
+		rr := httptest.NewRecorder()
+		mw(okHandler).ServeHTTP(rr, req)
+		return rr.Code
+	}
+	mw := AdminGuardMulti(valid, nil, "/admin/history")
+	for _, c := range []struct {
+		path, token string
+		want        int
+	}{
+		{"/admin/history", "", http.StatusUnauthorized},
+		{"/admin/history/diff", "bad_token", http.StatusForbidden},
+		{"/admin/history/diff", valid[0], 204},
+		{"/admin/historyx", "", 204},
+		{"/status", "", 204},
+	} {
+		if got := get(mw, c.path, c.token); got != c.want {
+			t.Errorf("GET %s token=%q: expected %d, got %d", c.path, c.token, c.want, got)
+		}
+	}
+	if got := get(AdminGuardMulti(nil, nil, "/admin/history"), "/admin/history", ""); got != http.StatusForbidden {
+		t.Errorf("expected 403 without configured tokens, got %d", got)
+	}
+}
+
+func TestAdminGuardMultiMarksAdmin(t *testing.T) {
+	valid := []string{"this_is_a_valid_admin_token_123"}
+	var admin bool
+	h := AdminGuardMulti(valid, nil, "/admin/history")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		admin = IsAdmin(r)
+		w.WriteHeader(204)
+	}))
+	for _, c := range []struct {
+		method, path, token string
+		want                bool
+	}{
+		{http.MethodGet, "/blocklist", "", false},
+		{http.MethodGet, "/blocklist", "bad_token", false},
+		{http.MethodGet, "/blocklist", valid[0], true},
+		{http.MethodGet, "/admin/history", valid[0], true},
+		{http.MethodPost, "/blocklist", valid[0], true},
+	} {
+		req := httptest.NewRequest(c.method, c.path, nil)
+		if c.token != "" {
+			req.Header.Set("X-Admin-Token", c.token)
+		}
+		rr := httptest.NewRecorder()
+		admin = false
+		h.ServeHTTP(rr, req)
+		if rr.Code != 204 || admin != c.want {
+			t.Errorf("%s %s token=%q: status %d admin=%v, want admin=%v", c.method, c.path, c.token, rr.Code, admin, c.want)
+		}
+	}
+}
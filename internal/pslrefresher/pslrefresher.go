@@ -21,11 +21,14 @@ import (
 // Refresher periodically downloads the public suffix list with integrity checks.
 // It performs conditional requests and exponential backoff on failure.
 type Refresher struct {
-	URL                 string
-	DestPath            string
-	Interval            time.Duration
-	Client              *http.Client
-	Logger              *log.Logger
+	URL      string
+	DestPath string
+	Interval time.Duration
+	Client   *http.Client
+	Logger   *log.Logger
+	// OnUpdate, when set, is called with the validated list contents after a
+	// new snapshot has been written to DestPath (not on 304 Not Modified).
+	OnUpdate            func(data []byte)
 	stopCh              chan struct{}
 	doneCh              chan struct{}
 	lastModified        string
@@ -206,6 +209,9 @@ func (r *Refresher) tryRefresh() bool {
 	}
 	r.lastSHA256 = sum
 	r.lastSize = len(data)
+	if r.OnUpdate != nil {
+		r.OnUpdate(data)
+	}
 	return true
 }
 
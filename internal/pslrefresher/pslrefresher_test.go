@@ -66,9 +66,14 @@ func TestRefreshNowWithServer(t *testing.T) {
 	logger := log.New(os.Stdout, "test", 0)
 	r := New(logger, dest)
 	r.URL = srv.URL
+	var updated []byte
+	r.OnUpdate = func(data []byte) { updated = data }
 	if ok := r.RefreshNow(); !ok {
 		t.Fatalf("expected refresh success")
 	}
+	if string(updated) != body {
+		t.Fatalf("expected OnUpdate to receive the refreshed list")
+	}
 	data, err := os.ReadFile(dest)
 	if err != nil {
 		t.Fatalf("read dest: %v", err)
@@ -7,9 +7,12 @@ import (
 	"disposable-email-domains/internal/config"
 	"disposable-email-domains/internal/domain"
 	"disposable-email-domains/internal/handlers"
+	"disposable-email-domains/internal/history"
 	"disposable-email-domains/internal/metrics"
 	"disposable-email-domains/internal/middleware"
 	"disposable-email-domains/internal/pslrefresher"
+	"disposable-email-domains/internal/subscriptions"
+	"disposable-email-domains/internal/watcher"
 )
 
 type storageAPI interface {
@@ -20,13 +23,28 @@ type storageAPI interface {
 	Delete(id string) bool
 }
 
-func New(store storageAPI, logger *log.Logger, checker *domain.Checker, cfg config.Config, refresher *pslrefresher.Refresher, version string) http.Handler {
-	api := &handlers.API{Store: store, Logger: logger, Check: checker}
+func New(store storageAPI, logger *log.Logger, checker *domain.Checker, cfg config.Config, refresher *pslrefresher.Refresher, watch *watcher.Watcher, hist *history.Store, subs *subscriptions.Subscriber, version string) http.Handler {
+	api := &handlers.API{Store: store, Logger: logger, Check: checker, History: hist}
 	// attach config pointer for batch limits
 	cfgCopy := cfg
 	api.SetConfig(&cfgCopy)
 	// Seed status counts after initial load (checker.Load already called in main before router.New)
 	api.InitStatus()
+	if watch != nil {
+		// Reloads serialize with the API's writers; the files the server
+		// writes itself are already patched in and need no reload.
+		api.ListWatch = watch
+		watch.Reload = api.ReloadLists
+		watch.OnReload = api.ListsReloaded
+		checker.SetWriteHook(watch.Sync)
+		if hist != nil {
+			hist.Wrote = watch.Sync
+		}
+	}
+	if subs != nil {
+		api.Subscriptions = subs
+		subs.OnUpdate = api.SyncSubscription
+	}
 
 	// configure trust proxy header behavior early
 	middleware.SetTrustProxyHeaders(cfg.TrustProxyHeaders)
@@ -45,6 +63,8 @@ func New(store storageAPI, logger *log.Logger, checker *domain.Checker, cfg conf
 	mux.HandleFunc("/check", api.CheckHandler)
 	// Aliases to avoid potential upstream WAF blocking of "/check" prefix
 	mux.HandleFunc("/q", api.CheckHandler) // GET /q?q=...
+	// Decision walkthrough (which list entry / line produced the verdict)
+	mux.HandleFunc("/explain", api.ExplainHandler)
 	// Batch JSON checks
 	mux.HandleFunc("/check/emails", api.CheckEmailsBatch)   // POST array or text
 	mux.HandleFunc("/check/domains", api.CheckDomainsBatch) // POST array or text
@@ -59,6 +79,7 @@ func New(store storageAPI, logger *log.Logger, checker *domain.Checker, cfg conf
 
 	// Validation + reports
 	mux.HandleFunc("/validate", api.ValidateHandler)
+	mux.HandleFunc("/validate/fix", api.ValidateFixHandler)
 	mux.HandleFunc("/report", api.ReportValidateHTML)
 	mux.HandleFunc("/report/emails/", api.ReportCheckEmailHTML)
 	mux.HandleFunc("/report/domains/", api.ReportCheckDomainHTML)
@@ -67,14 +88,29 @@ func New(store storageAPI, logger *log.Logger, checker *domain.Checker, cfg conf
 	// Lists download
 	mux.HandleFunc("/allowlist.conf", api.GetAllowlistFile)
 	mux.HandleFunc("/blocklist.conf", api.GetBlocklistFile)
+	mux.HandleFunc("/blocklist.bloom", api.GetBlocklistBloom)
+	mux.HandleFunc("/rolelist.conf", api.GetRolelistFile)
 	mux.HandleFunc("/public_suffix_list.dat", api.GetPSLFile)
 	mux.HandleFunc("/psl", api.GetPSLFile)
 	mux.HandleFunc("/psl.txt", api.GetPSLFile)
 
 	// Blocklist JSON management
 	mux.HandleFunc("/blocklist", api.Blocklist)
+	mux.HandleFunc("/blocklist/tombstones", api.BlocklistTombstones)
+	// Allowlist JSON management
+	mux.HandleFunc("/allowlist", api.Allowlist)
+	// Per-tenant overlay management
+	mux.HandleFunc("/tenants", api.Tenants)
+	mux.HandleFunc("/tenants/", api.Tenants)
 
 	mux.HandleFunc("/reload", api.ReloadHandler)
+	// Mutation journal: versions, diffs and rollbacks
+	mux.HandleFunc("/admin/history", api.HistoryHandler)
+	mux.HandleFunc("/admin/history/diff", api.HistoryDiffHandler)
+	mux.HandleFunc("/admin/history/rollback", api.HistoryRollbackHandler)
+	// Scheduled upstream list subscriptions
+	mux.HandleFunc("/admin/subscriptions", api.SubscriptionsHandler)
+	mux.HandleFunc("/admin/subscriptions/refresh", api.SubscriptionsRefreshHandler)
 	if refresher != nil {
 		mux.HandleFunc("/admin/psl/refresh", func(w http.ResponseWriter, r *http.Request) {
 			if r.Method != http.MethodPost {
@@ -102,7 +138,11 @@ func New(store storageAPI, logger *log.Logger, checker *domain.Checker, cfg conf
 		middleware.RequestIDMiddleware(),
 		middleware.RedirectCheckPaths(cfg.EnableCheckRedirects),
 		middleware.RateLimiter(cfg.RateLimitRPS, cfg.RateLimitBurst, cfg.RateLimiterTTL, logger, cfg.RateLimitBypassDomains),
-		middleware.AdminGuardMulti(adminTokenList, logger),
+		// The journal exposes token fingerprints, import URLs and list diffs;
+		// tombstones name who removed what and why; tenant overlays are one
+		// customer's lists. Public reads with a valid token are marked so
+		// handlers can include provenance.
+		middleware.AdminGuardMulti(adminTokenList, logger, "/admin/history", "/admin/subscriptions", "/blocklist/tombstones", "/tenants"),
 		middleware.Logging(logger),
 	)
 }
@@ -0,0 +1,470 @@
+// Package subscriptions keeps the blocklist in sync with upstream lists.
+// Each source is refreshed on its own schedule the way pslrefresher refreshes
+// the PSL: conditional requests, integrity checks and exponential backoff,
+// with a failure streak per source.
+package subscriptions
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"maps"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+
+	"disposable-email-domains/internal/metrics"
+)
+
+// ErrUnknownSource is returned by RefreshNow for names not configured.
+var ErrUnknownSource = errors.New("unknown subscription")
+
+// Source is an upstream list the blocklist follows.
+type Source struct {
+	Name  string `json:"name"` // file name and metric label
+	URL   string `json:"url"`
+	Prune bool   `json:"prune"` // remove entries the source stopped listing
+}
+
+// Update is a validated list body passed to OnUpdate.
+type Update struct {
+	Source Source
+	Data   []byte
+	// Others holds the last applied bodies of the other sources by name, so
+	// pruning can keep entries another source still lists. Only set for
+	// sources with Prune.
+	Others map[string][]byte
+}
+
+// Result summarizes how an update changed the blocklist.
+type Result struct {
+	Entries  int `json:"entries"`  // valid entries the source lists
+	Rejected int `json:"rejected"` // entries that are not registrable domains
+	Added    int `json:"added"`
+	Removed  int `json:"removed"` // pruned because the source dropped them
+	// PruneError is set when the additions were applied but pruning failed;
+	// the body is applied again on the next refresh.
+	PruneError string `json:"prune_error,omitempty"`
+}
+
+// Status is the refresh state of a source. It is persisted in Dir so
+// conditional requests and the shrink guard survive restarts.
+type Status struct {
+	Source
+	LastAttempt  time.Time `json:"last_attempt,omitzero"`
+	LastSuccess  time.Time `json:"last_success,omitzero"` // including 304 Not Modified
+	LastUpdate   time.Time `json:"last_update,omitzero"`  // last new body applied
+	LastError    string    `json:"last_error,omitempty"`
+	Failures     int       `json:"consecutive_failures"`
+	ETag         string    `json:"etag,omitempty"`
+	LastModified string    `json:"last_modified,omitempty"`
+	Size         int       `json:"size,omitempty"` // bytes of the last applied body
+	SHA256       string    `json:"sha256,omitempty"`
+	LastResult   *Result   `json:"last_result,omitempty"`
+	// Shrunk is the checksum of a body rejected by the shrink guard and
+	// ShrunkSeen how often in a row it was fetched; see shrinkConfirmations.
+	Shrunk     string `json:"shrunk_sha256,omitempty"`
+	ShrunkSeen int    `json:"shrunk_seen,omitempty"`
+}
+
+// shrinkConfirmations is how many consecutive fetches of the same shrunk body
+// it takes to accept it: a truncated download rarely repeats byte for byte,
+// a list cleaned up upstream does.
+const shrinkConfirmations = 3
+
+// Subscriber refreshes the configured sources in the background and hands
+// each new, validated body to OnUpdate. The last applied body and the state
+// of each source are kept in Dir.
+type Subscriber struct {
+	Dir      string
+	Interval time.Duration
+	MaxBytes int64 // largest accepted body
+	Client   *http.Client
+	Logger   *log.Logger
+	// OnUpdate applies a validated body. An error fails the refresh; the body
+	// is fetched in full again on the next attempt.
+	OnUpdate func(Update) (Result, error)
+
+	sources []*source
+	stateMu sync.Mutex // guards the state of all sources
+	applyMu sync.Mutex // serializes OnUpdate and the applied bodies
+	stopCh  chan struct{}
+	doneCh  chan struct{}
+}
+
+type source struct {
+	mu sync.Mutex // guards refresh (manual vs background)
+	st Status
+}
+
+func New(logger *log.Logger, dir string, sources ...Source) *Subscriber {
+	s := &Subscriber{
+		Dir:      dir,
+		Interval: 6 * time.Hour,
+		MaxBytes: 12 << 20,
+		Client:   &http.Client{Timeout: 20 * time.Second},
+		Logger:   logger,
+		stopCh:   make(chan struct{}),
+		doneCh:   make(chan struct{}),
+	}
+	for _, src := range sources {
+		s.sources = append(s.sources, &source{st: Status{Source: src}})
+	}
+	return s
+}
+
+// Sources builds the configured sources sorted by name from name -> URL;
+// prune names the sources whose dropped entries are removed, "*" for all.
+func Sources(urls map[string]string, prune []string) []Source {
+	out := make([]Source, 0, len(urls))
+	for _, name := range slices.Sorted(maps.Keys(urls)) {
+		p := slices.Contains(prune, "*") || slices.Contains(prune, name)
+		out = append(out, Source{Name: name, URL: urls[name], Prune: p})
+	}
+	return out
+}
+
+// Open creates Dir and restores the state saved by earlier runs. State saved
+// for a different URL is discarded.
+func (s *Subscriber) Open() error {
+	if err := os.MkdirAll(s.Dir, 0o755); err != nil {
+		return err
+	}
+	for _, src := range s.sources {
+		data, err := os.ReadFile(s.statePath(src.st.Name))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		var st Status
+		if err := json.Unmarshal(data, &st); err != nil {
+			s.Logger.Printf("subscription %s: ignoring unreadable state: %v", src.st.Name, err)
+			continue
+		}
+		if st.URL != src.st.URL {
+			s.Logger.Printf("subscription %s: url changed, starting over", src.st.Name)
+			_ = os.Remove(s.bodyPath(src.st.Name))
+			continue
+		}
+		st.Source = src.st.Source
+		src.st = st
+		if st.Failures > 0 {
+			metrics.SubscriptionConsecutiveFailures.WithLabelValues(st.Name).Set(float64(st.Failures))
+		}
+		if !st.LastSuccess.IsZero() {
+			metrics.SubscriptionLastRefreshUnix.WithLabelValues(st.Name).Set(float64(st.LastSuccess.Unix()))
+		}
+	}
+	return nil
+}
+
+// Start launches one background loop per source.
+func (s *Subscriber) Start() {
+	var wg sync.WaitGroup
+	for _, src := range s.sources {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.loop(src)
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(s.doneCh)
+	}()
+}
+
+// Stop signals termination and waits for the loops to finish.
+func (s *Subscriber) Stop() { close(s.stopCh); <-s.doneCh }
+
+func (s *Subscriber) loop(src *source) {
+	// A source refreshed recently by an earlier run waits for its next turn;
+	// anything else gets an attempt after a short delay.
+	delay := 5 * time.Second
+	s.stateMu.Lock()
+	if last := src.st.LastSuccess; !last.IsZero() && src.st.Failures == 0 {
+		delay = max(delay, time.Until(last.Add(s.Interval)))
+	}
+	name := src.st.Name
+	s.stateMu.Unlock()
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	var backoff time.Duration
+	for {
+		select {
+		case <-s.stopCh:
+			return
+		case <-timer.C:
+			if s.refresh(src, false) == nil {
+				backoff = 0
+				timer.Reset(s.Interval)
+			} else {
+				if backoff == 0 {
+					backoff = 5 * time.Minute
+				} else {
+					backoff *= 2
+				}
+				if backoff > 6*time.Hour {
+					backoff = 6 * time.Hour
+				}
+				timer.Reset(backoff)
+				s.Logger.Printf("subscription %s: retry scheduled in %s", name, backoff)
+			}
+		}
+	}
+}
+
+// RefreshNow triggers an immediate synchronous refresh of the named source
+// and returns its state afterwards. With force, a body that shrank is applied
+// without waiting for it to be confirmed.
+func (s *Subscriber) RefreshNow(name string, force bool) (Status, error) {
+	i := slices.IndexFunc(s.sources, func(src *source) bool { return src.st.Name == name })
+	if i < 0 {
+		return Status{}, fmt.Errorf("%w %q", ErrUnknownSource, name)
+	}
+	err := s.refresh(s.sources[i], force)
+	return s.status(s.sources[i]), err
+}
+
+// Statuses returns the state of every source in configuration order.
+func (s *Subscriber) Statuses() []Status {
+	out := make([]Status, 0, len(s.sources))
+	for _, src := range s.sources {
+		out = append(out, s.status(src))
+	}
+	return out
+}
+
+// Redacted returns the status without credentials in the source URL: user
+// info and the query string, which may carry access tokens.
+func (st Status) Redacted() Status {
+	u, err := url.Parse(st.URL)
+	if err != nil {
+		st.URL = ""
+		return st
+	}
+	u.User, u.RawQuery = nil, ""
+	st.URL = u.String()
+	return st
+}
+
+func (s *Subscriber) status(src *source) Status {
+	s.stateMu.Lock()
+	defer s.stateMu.Unlock()
+	st := src.st
+	if st.LastResult != nil {
+		r := *st.LastResult
+		st.LastResult = &r
+	}
+	return st
+}
+
+func (s *Subscriber) refresh(src *source, force bool) error {
+	src.mu.Lock()
+	defer src.mu.Unlock()
+	st := s.status(src)
+	s.stateMu.Lock()
+	src.st.LastAttempt = time.Now().UTC()
+	s.stateMu.Unlock()
+
+	req, err := http.NewRequest(http.MethodGet, st.URL, nil)
+	if err != nil {
+		return s.fail(src, err)
+	}
+	if st.LastModified != "" {
+		req.Header.Set("If-Modified-Since", st.LastModified)
+	}
+	if st.ETag != "" {
+		req.Header.Set("If-None-Match", st.ETag)
+	}
+	req.Header.Set("User-Agent", "disposable-email-domains/subscription")
+	resp, err := s.Client.Do(req)
+	if err != nil {
+		return s.fail(src, fmt.Errorf("fetch error: %w", err))
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotModified {
+		s.succeed(src, "not_modified", nil)
+		return nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return s.fail(src, fmt.Errorf("unexpected status %s", resp.Status))
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, s.MaxBytes+1))
+	if err != nil {
+		return s.fail(src, fmt.Errorf("read error: %w", err))
+	}
+	if err := validate(data, s.MaxBytes); err != nil {
+		return s.fail(src, fmt.Errorf("validation failed: %w", err))
+	}
+	sum := sha256.Sum256(data)
+	validators := func(st *Status) {
+		st.ETag, st.LastModified = resp.Header.Get("ETag"), resp.Header.Get("Last-Modified")
+	}
+	if hex.EncodeToString(sum[:]) == st.SHA256 { // server ignores conditional requests
+		s.succeed(src, "not_modified", validators)
+		return nil
+	}
+	// A list that suddenly shrinks is more likely truncated than cleaned up;
+	// pruning against it would empty the blocklist, and applying it would
+	// make it the baseline for the next check. It is applied once the same
+	// body has been fetched shrinkConfirmations times in a row, or forced.
+	if st.Size > 0 && len(data) < st.Size/2 && !force {
+		seen := 1
+		if st.Shrunk == hex.EncodeToString(sum[:]) {
+			seen = st.ShrunkSeen + 1
+		}
+		if seen < shrinkConfirmations {
+			s.stateMu.Lock()
+			src.st.Shrunk, src.st.ShrunkSeen = hex.EncodeToString(sum[:]), seen
+			s.stateMu.Unlock()
+			return s.fail(src, fmt.Errorf("validation failed: body shrank from %d to %d bytes, refusing to apply (seen %d of %d times)", st.Size, len(data), seen, shrinkConfirmations))
+		}
+		s.Logger.Printf("subscription %s: applying body shrunk from %d to %d bytes, fetched %d times in a row", st.Name, st.Size, len(data), seen)
+	}
+
+	s.applyMu.Lock()
+	u := Update{Source: st.Source, Data: data}
+	if st.Prune {
+		u.Others = s.appliedBodies(st.Name)
+	}
+	res, err := s.OnUpdate(u)
+	if err == nil {
+		if werr := writeFileAtomic(s.bodyPath(st.Name), data); werr != nil {
+			s.Logger.Printf("subscription %s: save body: %v", st.Name, werr)
+		}
+	}
+	s.applyMu.Unlock()
+	if err != nil {
+		return s.fail(src, fmt.Errorf("apply: %w", err))
+	}
+	metrics.SubscriptionEntriesTotal.WithLabelValues(st.Name, "added").Add(float64(res.Added))
+	metrics.SubscriptionEntriesTotal.WithLabelValues(st.Name, "removed").Add(float64(res.Removed))
+	s.Logger.Printf("subscription %s: updated size=%d sha256=%x entries=%d added=%d removed=%d", st.Name, len(data), sum[:6], res.Entries, res.Added, res.Removed)
+	if res.PruneError != "" {
+		s.Logger.Printf("subscription %s: prune failed, retrying on next refresh: %s", st.Name, res.PruneError)
+	}
+	s.succeed(src, "updated", func(st *Status) {
+		st.LastUpdate = time.Now().UTC()
+		st.Size = len(data)
+		st.LastResult = &res
+		st.Shrunk, st.ShrunkSeen = "", 0
+		// Without validators and checksum the next refresh fetches and
+		// applies the body in full, which retries the prune.
+		if res.PruneError == "" {
+			validators(st)
+			st.SHA256 = hex.EncodeToString(sum[:])
+		} else {
+			st.ETag, st.LastModified, st.SHA256 = "", "", ""
+		}
+	})
+	return nil
+}
+
+// appliedBodies reads the last applied bodies of the sources other than name.
+// Callers hold applyMu.
+func (s *Subscriber) appliedBodies(name string) map[string][]byte {
+	out := make(map[string][]byte)
+	for _, src := range s.sources {
+		if src.st.Name == name {
+			continue
+		}
+		if data, err := os.ReadFile(s.bodyPath(src.st.Name)); err == nil {
+			out[src.st.Name] = data
+		}
+	}
+	return out
+}
+
+func (s *Subscriber) succeed(src *source, result string, update func(*Status)) {
+	s.stateMu.Lock()
+	if update != nil {
+		update(&src.st)
+	}
+	src.st.LastSuccess = time.Now().UTC()
+	src.st.LastError = ""
+	src.st.Failures = 0
+	name := src.st.Name
+	s.stateMu.Unlock()
+	if result == "not_modified" {
+		s.Logger.Printf("subscription %s: not modified", name)
+	}
+	metrics.SubscriptionRefreshTotal.WithLabelValues(name, result).Inc()
+	metrics.SubscriptionLastRefreshUnix.WithLabelValues(name).Set(float64(time.Now().Unix()))
+	metrics.SubscriptionConsecutiveFailures.WithLabelValues(name).Set(0)
+	s.save(src)
+}
+
+func (s *Subscriber) fail(src *source, err error) error {
+	s.stateMu.Lock()
+	src.st.Failures++
+	src.st.LastError = err.Error()
+	name, n := src.st.Name, src.st.Failures
+	s.stateMu.Unlock()
+	s.Logger.Printf("subscription %s: %v", name, err)
+	metrics.SubscriptionRefreshTotal.WithLabelValues(name, "failed").Inc()
+	metrics.SubscriptionConsecutiveFailures.WithLabelValues(name).Set(float64(n))
+	if n == 5 || n == 10 {
+		s.Logger.Printf("subscription %s: ERROR: %d consecutive failures", name, n)
+	}
+	s.save(src)
+	return err
+}
+
+func (s *Subscriber) save(src *source) {
+	st := s.status(src)
+	data, err := json.Marshal(st)
+	if err == nil {
+		err = writeFileAtomic(s.statePath(st.Name), data)
+	}
+	if err != nil {
+		s.Logger.Printf("subscription %s: save state: %v", st.Name, err)
+	}
+}
+
+func (s *Subscriber) statePath(name string) string { return filepath.Join(s.Dir, name+".json") }
+
+func (s *Subscriber) bodyPath(name string) string { return filepath.Join(s.Dir, name+".list") }
+
+func writeFileAtomic(path string, data []byte) error {
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+	return nil
+}
+
+func validate(data []byte, maxBytes int64) error {
+	if int64(len(data)) > maxBytes {
+		return fmt.Errorf("body exceeds %d bytes", maxBytes)
+	}
+	if len(bytes.TrimSpace(data)) == 0 {
+		return errors.New("empty body")
+	}
+	head := data
+	if len(head) > 1024 {
+		head = head[:1024]
+	}
+	if bytes.Contains(bytes.ToLower(head), []byte("<html")) {
+		return errors.New("looks like html")
+	}
+	if bytes.IndexByte(head, 0) >= 0 {
+		return errors.New("looks like binary data")
+	}
+	return nil
+}
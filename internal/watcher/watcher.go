@@ -0,0 +1,271 @@
+package watcher
+
+import (
+	"crypto/sha256"
+	"errors"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"disposable-email-domains/internal/domain"
+	"disposable-email-domains/internal/metrics"
+)
+
+// Reload outcomes reported in Status.LastResult and the
+// list_watch_reloads_total metric.
+const (
+	ResultOK        = "ok"        // lists reloaded
+	ResultRejected  = "rejected"  // strict validation failed; previous lists kept
+	ResultError     = "error"     // files could not be loaded; previous lists kept
+	ResultUnchanged = "unchanged" // files were touched but their contents did not change
+)
+
+// Watcher reloads the lists when their files change on disk. Changes are
+// picked up through inotify where available and by polling otherwise, and
+// debounced so a deploy writing several files results in one reload.
+type Watcher struct {
+	Paths    []string
+	Debounce time.Duration // quiet period after the last change before reloading
+	Interval time.Duration // stat interval when polling
+	Poll     bool          // poll even when inotify is available
+	Strict   bool          // validate before publishing; passed to Reload
+	// Reload loads the lists; it must serialize with the server's own
+	// writers so a reload cannot interleave with an in-memory patch.
+	Reload func(strict bool) error
+	// OnReload, when set, is called after a successful reload.
+	OnReload func()
+	Logger   *log.Logger
+
+	stopCh   chan struct{}
+	doneCh   chan struct{}
+	changeCh chan struct{}
+	notifier io.Closer
+
+	mu     sync.Mutex // guards status and sums
+	status Status
+	sums   map[string][sha256.Size]byte
+}
+
+// Status describes the watcher for /status.
+type Status struct {
+	Mode       string    `json:"mode"` // "inotify" or "poll"
+	Paths      []string  `json:"paths"`
+	Strict     bool      `json:"strict"`
+	LastChange time.Time `json:"last_change,omitzero"`
+	LastReload time.Time `json:"last_reload,omitzero"`
+	LastResult string    `json:"last_result,omitempty"` // see Result* constants
+	LastError  string    `json:"last_error,omitempty"`
+	Reloads    int       `json:"reloads"`
+	Failures   int       `json:"failures"` // rejected or failed reloads
+}
+
+func New(logger *log.Logger, reload func(strict bool) error, paths ...string) *Watcher {
+	return &Watcher{
+		Paths:    paths,
+		Debounce: 2 * time.Second,
+		Interval: 5 * time.Second,
+		Reload:   reload,
+		Logger:   logger,
+		stopCh:   make(chan struct{}),
+		doneCh:   make(chan struct{}),
+		changeCh: make(chan struct{}, 1),
+	}
+}
+
+// Start records the current file contents and launches the background loop.
+// It falls back to polling when inotify is unavailable.
+func (w *Watcher) Start() {
+	sums := w.checksums()
+	w.mu.Lock()
+	w.sums = sums
+	w.mu.Unlock()
+	mode := "poll"
+	if !w.Poll {
+		n, err := notify(w.Paths, w.changed)
+		if err == nil {
+			w.notifier = n
+			mode = "inotify"
+		} else {
+			w.Logger.Printf("watch: inotify unavailable, polling every %s: %v", w.Interval, err)
+		}
+	}
+	if w.notifier == nil {
+		go w.poll(w.stat())
+	}
+	w.mu.Lock()
+	w.status.Mode = mode
+	w.status.Paths = w.Paths
+	w.status.Strict = w.Strict
+	w.mu.Unlock()
+	go w.loop()
+}
+
+// Stop ends the background loop and waits for a reload in progress.
+func (w *Watcher) Stop() {
+	close(w.stopCh)
+	if w.notifier != nil {
+		_ = w.notifier.Close()
+	}
+	<-w.doneCh
+}
+
+// Status returns a copy of the watcher state.
+func (w *Watcher) Status() Status {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.status
+}
+
+// Sync records data as the loaded contents of path. The server calls it with
+// the bytes it wrote itself after patching the lists in memory, so the events
+// of its own writes do not trigger a second, full reload, while an edit made
+// by anyone else in the meantime still differs. Unwatched paths are ignored.
+func (w *Watcher) Sync(path string, data []byte) {
+	path = filepath.Clean(path)
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for _, p := range w.Paths {
+		if filepath.Clean(p) == path {
+			if w.sums == nil {
+				w.sums = make(map[string][sha256.Size]byte, len(w.Paths))
+			}
+			w.sums[p] = sha256.Sum256(data)
+		}
+	}
+}
+
+// changed signals a change without blocking the event source.
+func (w *Watcher) changed() {
+	select {
+	case w.changeCh <- struct{}{}:
+	default:
+	}
+}
+
+func (w *Watcher) loop() {
+	defer close(w.doneCh)
+	timer := time.NewTimer(w.Debounce)
+	timer.Stop()
+	defer timer.Stop()
+	for {
+		select {
+		case <-w.stopCh:
+			return
+		case <-w.changeCh:
+			w.mu.Lock()
+			w.status.LastChange = time.Now().UTC()
+			w.mu.Unlock()
+			timer.Reset(w.Debounce)
+		case <-timer.C:
+			w.reload()
+		}
+	}
+}
+
+// reload reloads the lists unless their contents are unchanged since the
+// last successful reload.
+func (w *Watcher) reload() {
+	sums := w.checksums()
+	w.mu.Lock()
+	same := len(sums) == len(w.sums)
+	for p, s := range sums {
+		same = same && w.sums[p] == s
+	}
+	w.mu.Unlock()
+	if same {
+		metrics.ListWatchReloadsTotal.WithLabelValues(ResultUnchanged).Inc()
+		return
+	}
+
+	err := w.Reload(w.Strict)
+	result := ResultOK
+	switch {
+	case errors.Is(err, domain.ErrValidation):
+		result = ResultRejected
+		w.Logger.Printf("watch: lists changed but failed validation; keeping previous lists")
+	case err != nil:
+		result = ResultError
+		w.Logger.Printf("watch: reload failed; keeping previous lists: %v", err)
+	default:
+		w.Logger.Printf("watch: lists reloaded")
+	}
+	metrics.ListWatchReloadsTotal.WithLabelValues(result).Inc()
+
+	w.mu.Lock()
+	w.status.LastReload = time.Now().UTC()
+	w.status.LastResult = result
+	w.status.LastError = ""
+	if err != nil {
+		w.status.LastError = err.Error()
+		w.status.Failures++
+	} else {
+		w.status.Reloads++
+		w.sums = sums
+	}
+	w.mu.Unlock()
+	if err == nil {
+		metrics.ListWatchLastReloadUnix.Set(float64(time.Now().Unix()))
+		if w.OnReload != nil {
+			w.OnReload()
+		}
+	}
+}
+
+// checksums hashes the watched files; missing or unreadable files hash as
+// empty.
+func (w *Watcher) checksums() map[string][sha256.Size]byte {
+	sums := make(map[string][sha256.Size]byte, len(w.Paths))
+	for _, p := range w.Paths {
+		h := sha256.New()
+		if f, err := os.Open(p); err == nil {
+			_, _ = io.Copy(h, f)
+			f.Close()
+		}
+		var s [sha256.Size]byte
+		copy(s[:], h.Sum(nil))
+		sums[p] = s
+	}
+	return sums
+}
+
+// fileStamp is what polling compares to detect a change.
+type fileStamp struct {
+	size  int64
+	mtime int64
+}
+
+func (w *Watcher) stat() map[string]fileStamp {
+	m := make(map[string]fileStamp, len(w.Paths))
+	for _, p := range w.Paths {
+		if fi, err := os.Stat(p); err == nil {
+			m[p] = fileStamp{fi.Size(), fi.ModTime().UnixNano()}
+		}
+	}
+	return m
+}
+
+// poll stats the files every Interval and signals when the size or
+// modification time of one of them differs from last.
+func (w *Watcher) poll(last map[string]fileStamp) {
+	ticker := time.NewTicker(w.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-w.stopCh:
+			return
+		case <-ticker.C:
+			cur := w.stat()
+			diff := len(cur) != len(last)
+			for p, s := range cur {
+				diff = diff || last[p] != s
+			}
+			if diff {
+				w.changed()
+			}
+			last = cur
+		}
+	}
+}
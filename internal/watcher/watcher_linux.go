@@ -0,0 +1,63 @@
+//go:build linux
+
+package watcher
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"os"
+	"path/filepath"
+	"syscall"
+)
+
+// notify watches the directories of paths, since editors, config management
+// and git replace files by renaming over them, and calls changed for events
+// on one of the files. The returned closer stops it.
+func notify(paths []string, changed func()) (io.Closer, error) {
+	fd, err := syscall.InotifyInit1(syscall.IN_CLOEXEC | syscall.IN_NONBLOCK)
+	if err != nil {
+		return nil, err
+	}
+	const mask = syscall.IN_MODIFY | syscall.IN_CLOSE_WRITE | syscall.IN_CREATE | syscall.IN_DELETE | syscall.IN_MOVED_FROM | syscall.IN_MOVED_TO
+	files := make(map[int32]map[string]bool) // watch descriptor -> watched names
+	dirs := make(map[string]int32)
+	for _, p := range paths {
+		dir := filepath.Dir(p)
+		wd, ok := dirs[dir]
+		if !ok {
+			n, err := syscall.InotifyAddWatch(fd, dir, mask)
+			if err != nil {
+				syscall.Close(fd)
+				return nil, err
+			}
+			wd = int32(n)
+			dirs[dir] = wd
+			files[wd] = make(map[string]bool)
+		}
+		files[wd][filepath.Base(p)] = true
+	}
+	// A non-blocking descriptor is served by the runtime poller, so Close
+	// interrupts a pending Read.
+	f := os.NewFile(uintptr(fd), "inotify")
+	go func() {
+		buf := make([]byte, 64*(syscall.SizeofInotifyEvent+syscall.NAME_MAX+1))
+		for {
+			n, err := f.Read(buf)
+			if err != nil {
+				return
+			}
+			for off := 0; off+syscall.SizeofInotifyEvent <= n; {
+				wd := int32(binary.NativeEndian.Uint32(buf[off:]))
+				evMask := binary.NativeEndian.Uint32(buf[off+4:])
+				nameLen := int(binary.NativeEndian.Uint32(buf[off+12:]))
+				name := buf[off+syscall.SizeofInotifyEvent : off+syscall.SizeofInotifyEvent+nameLen]
+				off += syscall.SizeofInotifyEvent + nameLen
+				if evMask&syscall.IN_Q_OVERFLOW != 0 || files[wd][string(bytes.TrimRight(name, "\x00"))] {
+					changed()
+				}
+			}
+		}
+	}()
+	return f, nil
+}
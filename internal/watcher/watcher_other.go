@@ -0,0 +1,13 @@
+//go:build !linux
+
+package watcher
+
+import (
+	"errors"
+	"io"
+)
+
+// notify is only implemented on Linux; other platforms poll.
+func notify(paths []string, changed func()) (io.Closer, error) {
+	return nil, errors.ErrUnsupported
+}
@@ -0,0 +1,142 @@
+package watcher
+
+import (
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"disposable-email-domains/internal/domain"
+)
+
+func waitFor(t *testing.T, w *Watcher, cond func(Status) bool) Status {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if st := w.Status(); cond(st) {
+			return st
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("timed out, status %+v", w.Status())
+	return Status{}
+}
+
+func newTestWatcher(poll bool, reload func(bool) error, paths ...string) *Watcher {
+	w := New(log.New(io.Discard, "", 0), reload, paths...)
+	w.Debounce = 30 * time.Millisecond
+	w.Interval = 10 * time.Millisecond
+	w.Poll = poll
+	return w
+}
+
+func TestWatcherReloadsOnChange(t *testing.T) {
+	for _, poll := range []bool{true, false} {
+		mode := map[bool]string{true: "poll", false: "inotify"}[poll]
+		t.Run(mode, func(t *testing.T) {
+			dir := t.TempDir()
+			path := filepath.Join(dir, "blocklist.conf")
+			if err := os.WriteFile(path, []byte("a.com\n"), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			reloads := make(chan bool, 10)
+			w := newTestWatcher(poll, func(strict bool) error { reloads <- strict; return nil }, path)
+			w.Strict = true
+			w.Start()
+			defer w.Stop()
+			if st := w.Status(); st.Mode != mode {
+				if !poll {
+					t.Skipf("inotify unavailable: mode %s", st.Mode)
+				}
+				t.Fatalf("expected mode %s, got %s", mode, st.Mode)
+			}
+
+			// Replace the file the way editors and git do: write and rename.
+			tmp := path + ".tmp"
+			if err := os.WriteFile(tmp, []byte("a.com\nb.com\n"), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.Rename(tmp, path); err != nil {
+				t.Fatal(err)
+			}
+			st := waitFor(t, w, func(st Status) bool { return st.Reloads == 1 })
+			if st.LastResult != ResultOK || !<-reloads {
+				t.Fatalf("expected a strict reload, got %+v", st)
+			}
+
+			// Rewriting identical content does not reload.
+			if err := os.WriteFile(path, []byte("a.com\nb.com\n"), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			time.Sleep(150 * time.Millisecond)
+			if st := w.Status(); st.Reloads != 1 || len(reloads) != 0 {
+				t.Fatalf("unexpected reload for unchanged contents: %+v", st)
+			}
+
+			// Neither do the server's own writes once synced.
+			own := []byte("a.com\nb.com\nc.com\n")
+			if err := os.WriteFile(path, own, 0o644); err != nil {
+				t.Fatal(err)
+			}
+			w.Sync(path, own)
+			time.Sleep(150 * time.Millisecond)
+			if st := w.Status(); st.Reloads != 1 || len(reloads) != 0 {
+				t.Fatalf("unexpected reload after own write: %+v", st)
+			}
+
+			// An edit racing the server's write is not taken for it.
+			own = []byte("a.com\nb.com\nc.com\nd.com\n")
+			if err := os.WriteFile(path, append(own, "e.com\n"...), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			w.Sync(path, own)
+			st = waitFor(t, w, func(st Status) bool { return st.Reloads == 2 })
+			if st.LastResult != ResultOK {
+				t.Fatalf("expected the external edit reloaded, got %+v", st)
+			}
+		})
+	}
+}
+
+func TestWatcherKeepsListsOnValidationFailure(t *testing.T) {
+	dir := t.TempDir()
+	allowPath := filepath.Join(dir, "allowlist.conf")
+	blockPath := filepath.Join(dir, "blocklist.conf")
+	if err := os.WriteFile(allowPath, []byte("good.com\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(blockPath, []byte("bad.com\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	c := domain.NewChecker(allowPath, blockPath)
+	if err := c.Load(); err != nil {
+		t.Fatal(err)
+	}
+	gen := c.Generation()
+	reloaded := make(chan struct{}, 1)
+	w := newTestWatcher(true, c.Reload, allowPath, blockPath)
+	w.Strict = true
+	w.OnReload = func() { reloaded <- struct{}{} }
+	w.Start()
+	defer w.Stop()
+
+	// A bare public suffix is a validation error.
+	if err := os.WriteFile(blockPath, []byte("bad.com\ncom\nworse.com\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	st := waitFor(t, w, func(st Status) bool { return st.Failures == 1 })
+	if st.LastResult != ResultRejected || c.Generation() != gen || c.Check("worse.com").Status != "neutral" {
+		t.Fatalf("expected previous lists kept, got %+v (generation %d)", st, c.Generation())
+	}
+
+	if err := os.WriteFile(blockPath, []byte("bad.com\nworse.com\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, w, func(st Status) bool { return st.Reloads == 1 })
+	<-reloaded
+	if c.Check("worse.com").Status != "block" || c.Generation() != gen+1 {
+		t.Fatalf("expected fixed lists published")
+	}
+}